@@ -20,7 +20,10 @@ type WorkerDaemonOption struct {
 	ManualSyncPort    string
 	ManualSyncAuth    string
 	TaskWorkspaceGUID string
+	WorkerLabel       string
 	TLSEnabled        bool
+	EnrollToken       string
+	EnrollName        string
 }
 
 func parseDaemonWorkerOption() *WorkerDaemonOption {
@@ -32,11 +35,14 @@ func parseDaemonWorkerOption() *WorkerDaemonOption {
 	flag.IntVar(&option.WorkerPerformance, "p", 0, "worker performance,default is autodetect (0:autodetect, 1:high, 2:normal)")
 	flag.StringVar(&option.WorkerRunTaskMode, "m", "0", "worker run task mode; 0: all, 1:active, 2:finger, 3:passive, 4:pocscan, 5:custom; run multiple mode separated by \",\"")
 	flag.StringVar(&option.TaskWorkspaceGUID, "w", "", "workspace guid for custom task; multiple workspace separated by \",\"")
+	flag.StringVar(&option.WorkerLabel, "l", "", "worker label for targeted task; multiple label separated by \",\"")
 	flag.StringVar(&option.ManualSyncHost, "mh", "", "manual file sync host address")
 	flag.StringVar(&option.ManualSyncPort, "mp", "", "manual file sync port,default is 5002")
 	flag.StringVar(&option.ManualSyncAuth, "ma", "", "manual file sync auth key")
 	flag.BoolVar(&option.NoFilesync, "nf", option.NoFilesync, "disable file sync")
 	flag.BoolVar(&option.TLSEnabled, "tls", false, "use TLS for RPC and filesync")
+	flag.StringVar(&option.EnrollToken, "enroll", "", "enroll worker with the token generated by server, and get the worker cert for mutual TLS")
+	flag.StringVar(&option.EnrollName, "enroll-name", "", "worker name in the cert for enrollment, default is hostname")
 	flag.Parse()
 
 	return option
@@ -62,6 +68,18 @@ func main() {
 	comm.TLSEnabled = option.TLSEnabled
 	filesync.TLSEnabled = option.TLSEnabled
 
+	if option.EnrollToken != "" {
+		workerName := option.EnrollName
+		if workerName == "" {
+			workerName, _ = os.Hostname()
+		}
+		if err := comm.DoEnrollWorker(option.EnrollToken, workerName); err != nil {
+			logging.CLILog.Errorf("enroll worker fail:%v", err)
+			return
+		}
+		logging.CLILog.Infof("enroll worker %s success,please restart daemon_worker with -tls", workerName)
+		return
+	}
 	if option.ManualSyncHost != "" && option.ManualSyncPort != "" && option.ManualSyncAuth != "" {
 		logging.RuntimeLog.Info("start onetime file sync...")
 		logging.CLILog.Info("start onetime file sync...")
@@ -70,5 +88,5 @@ func main() {
 	}
 	go comm.StartSaveRuntimeLog(comm.GetWorkerNameBySelf())
 	go setupCloseHandler()
-	comm.StartWorkerDaemon(option.WorkerRunTaskMode, option.TaskWorkspaceGUID, option.Concurrency, option.WorkerPerformance, option.NoFilesync)
+	comm.StartWorkerDaemon(option.WorkerRunTaskMode, option.TaskWorkspaceGUID, option.WorkerLabel, option.Concurrency, option.WorkerPerformance, option.NoFilesync)
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/jsonl"
+	"github.com/hanc00l/nemo_go/pkg/task/workerapi"
+	"io"
+	"os"
+	"strings"
+)
+
+type ScanOption struct {
+	workerapi.LocalScanConfig
+	TargetFile string
+	OutputFile string
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [options]\n\ncommands:\n  scan\trun scan on local machine and output results as JSON Lines\n", os.Args[0])
+}
+
+func parseScanOption(args []string) *ScanOption {
+	option := &ScanOption{}
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	fs.StringVar(&option.Target, "t", "", "target ip/subnet/domain; multiple target separated by \",\"")
+	fs.StringVar(&option.TargetFile, "tf", "", "file of targets, one target per line")
+	fs.StringVar(&option.Port, "p", "", "port for portscan, such as \"80,443,8000-9000\" or \"--top-ports 1000\"; default is port of worker.yml")
+	fs.BoolVar(&option.IsSubDomainFinder, "subfinder", false, "subdomain finder")
+	fs.BoolVar(&option.IsSubDomainBrute, "brute", false, "subdomain brute")
+	fs.BoolVar(&option.IsSubDomainCrawler, "crawler", false, "subdomain crawler")
+	fs.BoolVar(&option.IsPermutation, "permutation", false, "subdomain permutation")
+	fs.BoolVar(&option.IsZoneTransfer, "zonetransfer", false, "dns zone transfer (axfr) and nsec zone walking")
+	fs.BoolVar(&option.IsFingerprint, "finger", false, "fingerprint (httpx and fingerprinthub)")
+	fs.BoolVar(&option.IsXrayPoc, "xray", false, "xray poc scan")
+	fs.StringVar(&option.XrayPocFile, "xraypoc", "", "xray poc file, default is all")
+	fs.BoolVar(&option.IsNucleiPoc, "nuclei", false, "nuclei poc scan")
+	fs.StringVar(&option.NucleiPocFile, "nucleipoc", "", "nuclei poc file, default is all")
+	fs.BoolVar(&option.IsGobyPoc, "goby", false, "goby poc scan")
+	fs.StringVar(&option.OutputFile, "o", "-", "output JSON Lines file, \"-\" is stdout")
+	fs.Parse(args)
+
+	if option.TargetFile != "" {
+		content, err := os.ReadFile(option.TargetFile)
+		if err != nil {
+			logging.CLILog.Error(err)
+			return nil
+		}
+		var targets []string
+		if option.Target != "" {
+			targets = append(targets, option.Target)
+		}
+		scanner := bufio.NewScanner(strings.NewReader(string(content)))
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				targets = append(targets, line)
+			}
+		}
+		option.Target = strings.Join(targets, ",")
+	}
+	if option.Target == "" {
+		fs.Usage()
+		return nil
+	}
+	return option
+}
+
+// doScan 执行本地扫描并输出JSON Lines格式的结果
+func doScan(option *ScanOption) bool {
+	scan, err := workerapi.NewLocalScan(option.LocalScanConfig)
+	if err != nil {
+		logging.CLILog.Error(err)
+		return false
+	}
+	var out io.Writer = os.Stdout
+	if option.OutputFile != "-" {
+		f, err := os.Create(option.OutputFile)
+		if err != nil {
+			logging.CLILog.Error(err)
+			return false
+		}
+		defer f.Close()
+		out = f
+	}
+	scan.Do()
+
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+	writer := jsonl.NewWriter(w)
+	ipCount, err := writer.WriteIPResult(&scan.ResultIP)
+	if err != nil {
+		logging.CLILog.Error(err)
+		return false
+	}
+	domainCount, err := writer.WriteDomainResult(&scan.ResultDomain)
+	if err != nil {
+		logging.CLILog.Error(err)
+		return false
+	}
+	vulCount, err := writer.WriteVulnerability(scan.ResultVul)
+	if err != nil {
+		logging.CLILog.Error(err)
+		return false
+	}
+	logging.CLILog.Infof("scan finished,ip:%d,domain:%d,vulnerability:%d", ipCount, domainCount, vulCount)
+	return true
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+	switch os.Args[1] {
+	case "scan":
+		option := parseScanOption(os.Args[2:])
+		if option == nil || !doScan(option) {
+			os.Exit(1)
+		}
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
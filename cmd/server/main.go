@@ -2,6 +2,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"github.com/beego/beego/v2/core/logs"
 	"github.com/beego/beego/v2/server/web"
 	beegoContext "github.com/beego/beego/v2/server/web/context"
@@ -13,6 +14,7 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/runner"
+	"github.com/hanc00l/nemo_go/pkg/task/workerapi"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	_ "github.com/hanc00l/nemo_go/pkg/web/routers"
 	"net/http"
@@ -26,6 +28,8 @@ type ServerOption struct {
 	TLSEnabled  bool
 	TLSCertFile string
 	TLSKeyFile  string
+	AllInOne    bool
+	Concurrency int
 }
 
 var UrlFilterWhiteList = []string{"/"}
@@ -40,8 +44,15 @@ func parseServerOption() *ServerOption {
 	flag.BoolVar(&option.TLSEnabled, "tls", false, "use TLS for web、RPC and filesync")
 	flag.StringVar(&option.TLSKeyFile, "key", "server.key", "TLS private key file")
 	flag.StringVar(&option.TLSCertFile, "cert", "server.crt", "TLS cert file")
+	flag.BoolVar(&option.AllInOne, "aio", false, "all-in-one mode: run task queue and worker in server process, no rabbitmq、RPC、worker and MySQL needed (use embedded sqlite database unless database type is configured)")
+	flag.IntVar(&option.Concurrency, "c", 2, "concurrent number of tasks for all-in-one mode")
 	flag.Parse()
 
+	if option.AllInOne {
+		option.NoFilesync = true
+		option.NoRPC = true
+	}
+
 	return option
 }
 
@@ -107,6 +118,47 @@ func filterLoginCheck(ctx *beegoContext.Context) {
 	}
 }
 
+// StartEmbeddedWorker all-in-one模式下，在server进程内启动worker执行全部topic的任务
+func StartEmbeddedWorker(concurrency int) {
+	topics := map[string]struct{}{
+		ampq.TopicActive:  {},
+		ampq.TopicFinger:  {},
+		ampq.TopicPassive: {},
+		ampq.TopicPocscan: {},
+	}
+	for workspaceGUID := range ampq.CustomTaskWorkspaceMap {
+		topics[fmt.Sprintf("%s.%s", ampq.TopicCustom, workspaceGUID)] = struct{}{}
+	}
+	// 内置worker同时执行worker配置文件中标签的任务
+	for _, label := range conf.GlobalWorkerConfig().Labels {
+		if ampq.CheckWorkerLabel(label) {
+			topics[ampq.GetTopicByWorkerLabel(label)] = struct{}{}
+		}
+	}
+	workerapi.WStatus.WorkerName = comm.GetWorkerNameBySelf()
+	workerapi.WStatus.CreateTime = time.Now()
+	workerapi.WStatus.UpdateTime = time.Now()
+	workerapi.WStatus.WorkerTopics = utils.SetToString(topics)
+	workerapi.WStatus.Capability = workerapi.DetectWorkerCapability()
+	go workerapi.StartResourceMonitor()
+	for topic := range topics {
+		go func(topicName string) {
+			if err := workerapi.StartWorker(topicName, concurrency); err != nil {
+				logging.CLILog.Error(err)
+				logging.RuntimeLog.Error(err)
+			}
+		}(topic)
+	}
+	go func() {
+		for {
+			workerapi.WStatus.Lock()
+			comm.DoKeepAlive(&workerapi.WStatus)
+			workerapi.WStatus.Unlock()
+			time.Sleep(60 * time.Second)
+		}
+	}()
+}
+
 func loadCustomTaskWorkspace() {
 	ampq.CustomTaskWorkspaceMap = custom.LoadCustomTaskWorkspace()
 }
@@ -116,6 +168,7 @@ func main() {
 	if option == nil {
 		return
 	}
+	conf.AllInOneMode = option.AllInOne
 
 	if option.TLSEnabled {
 		if !utils.CheckFileExist(filepath.Join(conf.GetRootPath(), option.TLSCertFile)) || !utils.CheckFileExist(filepath.Join(conf.GetRootPath(), option.TLSKeyFile)) {
@@ -125,6 +178,13 @@ func main() {
 			}
 			logging.CLILog.Info("generate selfsigned cert...")
 		}
+		// server作为CA签发worker证书，RPC及文件同步使用双向TLS
+		if !option.AllInOne {
+			if err := comm.InitWorkerCA(); err != nil {
+				logging.CLILog.Error(err)
+				return
+			}
+		}
 	}
 	if !option.NoFilesync {
 		filesync.TLSEnabled = option.TLSEnabled
@@ -145,6 +205,10 @@ func main() {
 	loadCustomTaskWorkspace()
 	StartCronTask()
 	StartMainTaskDemon()
+	if option.AllInOne {
+		logging.CLILog.Info("start embedded worker for all-in-one mode...")
+		StartEmbeddedWorker(option.Concurrency)
+	}
 	time.Sleep(time.Second * 1)
 
 	err := comm.GenerateRSAKey()
@@ -26,6 +26,7 @@ type WorkerOption struct {
 	WorkerPerformance int
 	WorkerTopic       map[string]struct{}
 	TLSEnabled        bool
+	WorkerLabels      []string
 }
 
 func parseWorkerOptions() *WorkerOption {
@@ -35,10 +36,12 @@ func parseWorkerOptions() *WorkerOption {
 
 	var workerRunTaskMode string
 	var taskWorkspaceGUID string
+	var workerLabel string
 	flag.IntVar(&option.Concurrency, "c", 3, "concurrent number of tasks")
 	flag.IntVar(&option.WorkerPerformance, "p", 0, "worker performance,default is autodetect (0:autodetect, 1:high, 2:normal)")
 	flag.StringVar(&workerRunTaskMode, "m", "0", "worker run task mode; 0: all, 1:active, 2:finger, 3:passive, 4:pocscan, 5:custom; run multiple mode separated by \",\"")
 	flag.StringVar(&taskWorkspaceGUID, "w", "", "workspace guid for custom task; multiple workspace separated by \",\"")
+	flag.StringVar(&workerLabel, "l", "", "worker label for targeted task; multiple label separated by \",\"")
 	flag.BoolVar(&option.TLSEnabled, "tls", false, "use TLS for RPC and filesync")
 	flag.Parse()
 
@@ -86,6 +89,24 @@ func parseWorkerOptions() *WorkerOption {
 		logging.CLILog.Error("error worker run task mode...")
 		return nil
 	}
+	// worker标签：命令行参数与配置文件中的标签合并，每个标签订阅对应的队列
+	labels := make(map[string]struct{})
+	for _, label := range append(strings.Split(workerLabel, ","), conf.GlobalWorkerConfig().Labels...) {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+		if !ampq.CheckWorkerLabel(label) {
+			logging.CLILog.Errorf("error worker label:%s", label)
+			return nil
+		}
+		if _, ok := labels[label]; ok {
+			continue
+		}
+		labels[label] = struct{}{}
+		option.WorkerLabels = append(option.WorkerLabels, label)
+		option.WorkerTopic[ampq.GetTopicByWorkerLabel(label)] = struct{}{}
+	}
 	return option
 }
 
@@ -94,7 +115,7 @@ func keepAlive() {
 	time.Sleep(10 * time.Second)
 	for {
 		workerapi.WStatus.Lock()
-		if !comm.DoKeepAlive(workerapi.WStatus) {
+		if !comm.DoKeepAlive(&workerapi.WStatus) {
 			logging.RuntimeLog.Errorf("keep alive fail")
 			logging.CLILog.Error("keep alive fail")
 		}
@@ -134,6 +155,7 @@ func initWorkerStatus(option *WorkerOption) {
 	workerapi.WStatus.CreateTime = time.Now()
 	workerapi.WStatus.UpdateTime = time.Now()
 	workerapi.WStatus.WorkerTopics = utils.SetToString(option.WorkerTopic)
+	workerapi.WStatus.Capability = workerapi.DetectWorkerCapability()
 }
 
 func startWorker(option *WorkerOption) {
@@ -164,8 +186,10 @@ func main() {
 	comm.TLSEnabled = option.TLSEnabled
 	go keepAlive()
 	go comm.StartSaveRuntimeLog(comm.GetWorkerNameBySelf())
+	go comm.StartSpoolReplay()
 	checkWorkerPerformance(option.WorkerPerformance)
 	initWorkerStatus(option)
+	go workerapi.StartResourceMonitor()
 	startWorker(option)
 	setupCloseHandler()
 }
@@ -617,6 +617,49 @@ LOCK TABLES `vulnerability` WRITE;
 /*!40000 ALTER TABLE `vulnerability` ENABLE KEYS */;
 UNLOCK TABLES;
 
+--
+-- Table structure for table `worker_cert`
+--
+
+DROP TABLE IF EXISTS `worker_cert`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `worker_cert` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `worker_name` varchar(100) NOT NULL,
+  `serial_number` varchar(100) NOT NULL,
+  `fingerprint` char(64) NOT NULL,
+  `not_after` datetime NOT NULL,
+  `is_revoked` tinyint(1) NOT NULL DEFAULT '0',
+  `revoke_datetime` datetime DEFAULT NULL,
+  `create_datetime` datetime NOT NULL,
+  `update_datetime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `worker_cert_serial_number_uindex` (`serial_number`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `worker_secret`
+--
+
+DROP TABLE IF EXISTS `worker_secret`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `worker_secret` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `secret_name` varchar(50) NOT NULL,
+  `scope_type` varchar(20) NOT NULL,
+  `scope_value` varchar(100) NOT NULL DEFAULT '',
+  `secret_value` text NOT NULL,
+  `description` varchar(200) DEFAULT NULL,
+  `create_datetime` datetime NOT NULL,
+  `update_datetime` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `worker_secret_name_scope_uindex` (`secret_name`,`scope_type`,`scope_value`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
 --
 -- Table structure for table `workspace`
 --
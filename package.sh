@@ -9,12 +9,15 @@ echo > log/access.log
 CGO_ENABLED=0 GOOS=darwin GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o server_darwin_amd64 cmd/server/main.go
 CGO_ENABLED=0 GOOS=darwin GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o worker_darwin_amd64 cmd/worker/main.go
 CGO_ENABLED=0 GOOS=darwin GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o daemon_worker_darwin_amd64 cmd/daemon_worker/main.go
+CGO_ENABLED=0 GOOS=darwin GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o nemo_darwin_amd64 cmd/nemo/main.go
 CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o server_linux_amd64 cmd/server/main.go
 CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o worker_linux_amd64 cmd/worker/main.go
 CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o daemon_worker_linux_amd64 cmd/daemon_worker/main.go
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o nemo_linux_amd64 cmd/nemo/main.go
 CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o server_windows_amd64.exe cmd/server/main.go
 CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o worker_windows_amd64.exe cmd/worker/main.go
 CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o daemon_worker_windows_amd64.exe cmd/daemon_worker/main.go
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -ldflags "-s -w" -trimpath -o nemo_windows_amd64.exe cmd/nemo/main.go
 
 tar -cvzf release/nemo_darwin_amd64.tar \
   --exclude=thirdparty/xray/xray_linux_amd64 \
@@ -29,9 +32,6 @@ tar -cvzf release/nemo_darwin_amd64.tar \
   --exclude=thirdparty/httpx/httpx_linux_amd64 \
   --exclude=thirdparty/goby/goby-cmd.exe \
   --exclude=thirdparty/goby/goby-cmd-linux \
-  --exclude=thirdparty/massdns/massdns_windows_amd64.exe \
-  --exclude=thirdparty/massdns/cygwin1.dll \
-  --exclude=thirdparty/massdns/massdns_linux_amd64 \
   server_darwin_amd64 worker_darwin_amd64 daemon_worker_darwin_amd64 version.txt \
   conf log thirdparty web
 
@@ -48,9 +48,6 @@ tar -cvzf release/nemo_linux_amd64.tar \
   --exclude=thirdparty/httpx/httpx_darwin_amd64 \
   --exclude=thirdparty/goby/goby-cmd.exe \
   --exclude=thirdparty/goby/goby-cmd \
-  --exclude=thirdparty/massdns/massdns_windows_amd64.exe \
-  --exclude=thirdparty/massdns/cygwin1.dll \
-  --exclude=thirdparty/massdns/massdns_darwin_amd64 \
   server_linux_amd64 worker_linux_amd64 daemon_worker_linux_amd64 version.txt \
   conf log thirdparty web docker* Dockerfile*
 
@@ -67,8 +64,6 @@ tar -cvzf release/nemo_windows_amd64.tar \
   --exclude=thirdparty/httpx/httpx_linux_amd64 \
   --exclude=thirdparty/goby/goby-cmd \
   --exclude=thirdparty/goby/goby-cmd-linux \
-  --exclude=thirdparty/massdns/massdns_darwin_amd64 \
-  --exclude=thirdparty/massdns/massdns_linux_amd64 \
   server_windows_amd64.exe worker_windows_amd64.exe daemon_worker_windows_amd64.exe version.txt \
   conf log thirdparty web
 
@@ -86,10 +81,7 @@ tar -cvzf release/worker_linux_amd64.tar \
   --exclude=conf/app.conf --exclude=server.yml \
   --exclude=thirdparty/goby/goby-cmd.exe \
   --exclude=thirdparty/goby/goby-cmd \
-  --exclude=thirdparty/massdns/massdns_windows_amd64.exe \
-  --exclude=thirdparty/massdns/cygwin1.dll \
-  --exclude=thirdparty/massdns/massdns_darwin_amd64 \
-  worker_linux_amd64 daemon_worker_linux_amd64 conf log thirdparty version.txt
+  worker_linux_amd64 daemon_worker_linux_amd64 nemo_linux_amd64 conf log thirdparty version.txt
 
 tar -cvzf release/worker_darwin_amd64.tar \
   --exclude=thirdparty/xray/xray_linux_amd64 \
@@ -105,10 +97,7 @@ tar -cvzf release/worker_darwin_amd64.tar \
   --exclude=conf/app.conf --exclude=server.yml \
   --exclude=thirdparty/goby/goby-cmd.exe \
   --exclude=thirdparty/goby/goby-cmd-linux \
-  --exclude=thirdparty/massdns/massdns_windows_amd64.exe \
-  --exclude=thirdparty/massdns/cygwin1.dll \
-  --exclude=thirdparty/massdns/massdns_linux_amd64 \
-  worker_darwin_amd64 daemon_worker_darwin_amd64 conf log thirdparty version.txt
+  worker_darwin_amd64 daemon_worker_darwin_amd64 nemo_darwin_amd64 conf log thirdparty version.txt
 
 tar -cvzf release/worker_windows_amd64.tar \
   --exclude=thirdparty/xray/xray_darwin_amd64 \
@@ -124,12 +113,10 @@ tar -cvzf release/worker_windows_amd64.tar \
   --exclude=conf/app.conf --exclude=server.yml \
   --exclude=thirdparty/goby/goby-cmd \
   --exclude=thirdparty/goby/goby-cmd-linux \
-  --exclude=thirdparty/massdns/massdns_darwin_amd64 \
-  --exclude=thirdparty/massdns/massdns_linux_amd64 \
-  worker_windows_amd64.exe daemon_worker_windows_amd64.exe conf log thirdparty version.txt
+  worker_windows_amd64.exe daemon_worker_windows_amd64.exe nemo_windows_amd64.exe conf log thirdparty version.txt
 
-rm -f server_darwin_amd64 worker_darwin_amd64 daemon_worker_darwin_amd64 \
-  server_linux_amd64 worker_linux_amd64 daemon_worker_linux_amd64 \
-  server_windows_amd64.exe worker_windows_amd64.exe daemon_worker_windows_amd64.exe \
+rm -f server_darwin_amd64 worker_darwin_amd64 daemon_worker_darwin_amd64 nemo_darwin_amd64 \
+  server_linux_amd64 worker_linux_amd64 daemon_worker_linux_amd64 nemo_linux_amd64 \
+  server_windows_amd64.exe worker_windows_amd64.exe daemon_worker_windows_amd64.exe nemo_windows_amd64.exe \
 
 echo "package done..."
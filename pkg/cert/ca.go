@@ -0,0 +1,301 @@
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+)
+
+const (
+	// CertDir 证书保存的目录（不在文件同步的白名单中，不会同步到worker）
+	CertDir = "cert"
+	// server作为CA签发worker证书
+	CACertFile = "ca.crt"
+	CAKeyFile  = "ca.key"
+	// worker注册后获得的证书及server证书的指纹
+	WorkerCertFile = "worker.crt"
+	WorkerKeyFile  = "worker.key"
+	ServerPinFile  = "server.pin"
+	// SecretKeyFile server加密保存worker密钥的主密钥
+	SecretKeyFile = "secret.key"
+	// CACommonName CA证书的名称
+	CACommonName = "nemo worker CA"
+)
+
+// CA server的CA，用于签发worker的客户端证书
+type CA struct {
+	Cert    *x509.Certificate
+	Key     *rsa.PrivateKey
+	CertPEM []byte
+}
+
+// WorkerIdentity worker注册后的身份：客户端证书及固定的server证书指纹
+type WorkerIdentity struct {
+	Certificate tls.Certificate
+	ServerPin   string
+}
+
+// getCertFilePath 证书文件的路径
+func getCertFilePath(fileName string) string {
+	return filepath.Join(conf.GetRootPath(), CertDir, fileName)
+}
+
+// LoadOrCreateCA 加载CA证书，如果不存在则生成新的CA
+func LoadOrCreateCA() (*CA, error) {
+	certFile, keyFile := getCertFilePath(CACertFile), getCertFilePath(CAKeyFile)
+	certPEM, errCert := os.ReadFile(certFile)
+	keyPEM, errKey := os.ReadFile(keyFile)
+	if errCert == nil && errKey == nil {
+		return parseCA(certPEM, keyPEM)
+	}
+	key, err := NewPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CA private key: %v", err)
+	}
+	caCert, err := cert.NewSelfSignedCACert(cert.Config{CommonName: CACommonName}, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CA cert: %v", err)
+	}
+	certPEM, keyPEM = EncodeCertPEM(caCert), EncodePrivateKeyPEM(key)
+	if err = os.MkdirAll(filepath.Dir(certFile), 0700); err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return nil, err
+	}
+	return &CA{Cert: caCert, Key: key, CertPEM: certPEM}, nil
+}
+
+// LoadOrCreateSecretKey 加载加密worker密钥的主密钥（AES-256），如果不存在则随机生成
+func LoadOrCreateSecretKey() ([]byte, error) {
+	keyFile := getCertFilePath(SecretKeyFile)
+	if content, err := os.ReadFile(keyFile); err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(content)))
+		if err != nil || len(key) != 32 {
+			return nil, errors.New("invalid secret key")
+		}
+		return key, nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// parseCA 解析PEM格式的CA证书及私钥
+func parseCA(certPEM, keyPEM []byte) (*CA, error) {
+	certs, err := cert.ParseCertsPEM(certPEM)
+	if err != nil || len(certs) != 1 {
+		return nil, errors.New("invalid CA cert")
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid CA private key")
+	}
+	return &CA{Cert: certs[0], Key: privateKey, CertPEM: certPEM}, nil
+}
+
+// CertPool CA证书池，用于验证worker证书
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	return pool
+}
+
+// SignWorkerCSR 根据worker的证书请求签发客户端证书，证书的CommonName为worker的名称
+func (ca *CA) SignWorkerCSR(csrPEM []byte, workerName string, validDays int) (*x509.Certificate, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("invalid certificate request")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err = csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+	if validDays <= 0 {
+		validDays = 365
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	certTmpl := x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:   workerName,
+			Organization: []string{"nemo worker"},
+		},
+		SerialNumber: serial,
+		NotBefore:    time.Now().Add(-time.Hour).UTC(),
+		NotAfter:     time.Now().AddDate(0, 0, validDays).UTC(),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDERBytes, err := x509.CreateCertificate(rand.Reader, &certTmpl, ca.Cert, csr.PublicKey, ca.Key)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(certDERBytes)
+}
+
+// NewWorkerCSR 生成worker的私钥及证书请求，私钥只保存在worker本地
+func NewWorkerCSR(workerName string) (keyPEM, csrPEM []byte, err error) {
+	key, err := NewPrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	csrDERBytes, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: workerName},
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	csrPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDERBytes})
+	return EncodePrivateKeyPEM(key), csrPEM, nil
+}
+
+// Fingerprint 证书的SHA256指纹
+func Fingerprint(certDER []byte) string {
+	sum := sha256.Sum256(certDER)
+	return hex.EncodeToString(sum[:])
+}
+
+// GetCertFileFingerprint 获取证书文件的SHA256指纹
+func GetCertFileFingerprint(certFile string) (string, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return "", err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return "", errors.New("invalid cert file")
+	}
+	return Fingerprint(block.Bytes), nil
+}
+
+// SaveWorkerIdentity 保存worker注册后获得的证书、私钥及server证书的指纹
+func SaveWorkerIdentity(certPEM, keyPEM []byte, serverPin string) error {
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(getCertFilePath(WorkerCertFile)), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(getCertFilePath(WorkerCertFile), certPEM, 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(getCertFilePath(WorkerKeyFile), keyPEM, 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(getCertFilePath(ServerPinFile), []byte(serverPin), 0644)
+}
+
+// LoadWorkerIdentity 加载worker的身份，未注册时返回nil
+func LoadWorkerIdentity() *WorkerIdentity {
+	certificate, err := tls.LoadX509KeyPair(getCertFilePath(WorkerCertFile), getCertFilePath(WorkerKeyFile))
+	if err != nil {
+		return nil
+	}
+	pin, err := os.ReadFile(getCertFilePath(ServerPinFile))
+	if err != nil || len(strings.TrimSpace(string(pin))) == 0 {
+		return nil
+	}
+	return &WorkerIdentity{Certificate: certificate, ServerPin: strings.TrimSpace(string(pin))}
+}
+
+// CommonName worker证书的CN，即注册时的worker名称
+func (w *WorkerIdentity) CommonName() string {
+	if len(w.Certificate.Certificate) == 0 {
+		return ""
+	}
+	c, err := x509.ParseCertificate(w.Certificate.Certificate[0])
+	if err != nil {
+		return ""
+	}
+	return c.Subject.CommonName
+}
+
+// NewPinnedTLSConfig 只信任指定指纹的server证书
+func NewPinnedTLSConfig(serverPin string) *tls.Config {
+	return &tls.Config{
+		// 不使用CA验证server证书，而是固定server证书的指纹
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !strings.EqualFold(Fingerprint(rawCerts[0]), serverPin) {
+				return errors.New("server certificate fingerprint mismatch")
+			}
+			return nil
+		},
+	}
+}
+
+// NewWorkerTLSConfig worker连接server的TLS配置：已注册的worker使用双向TLS并固定server证书，否则保持原来的方式
+func NewWorkerTLSConfig() *tls.Config {
+	identity := LoadWorkerIdentity()
+	if identity == nil {
+		return &tls.Config{InsecureSkipVerify: true}
+	}
+	config := NewPinnedTLSConfig(identity.ServerPin)
+	config.Certificates = []tls.Certificate{identity.Certificate}
+	return config
+}
+
+// NewServerTLSConfig server的TLS配置：worker的证书由CA验证，验证通过后再由checkWorkerCert检查是否已吊销
+func NewServerTLSConfig(certFile, keyFile string, ca *CA, checkWorkerCert func(*x509.Certificate) error) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{certificate}}
+	if ca == nil {
+		return config, nil
+	}
+	// 未注册的worker仍可以使用authKey认证，是否必须使用证书由server配置决定
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	config.ClientCAs = ca.CertPool()
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 || checkWorkerCert == nil {
+			return nil
+		}
+		return checkWorkerCert(state.PeerCertificates[0])
+	}
+	return config, nil
+}
+
+// GetSerialNumber 证书序列号的字符串表示
+func GetSerialNumber(c *x509.Certificate) string {
+	return strings.ToUpper(c.SerialNumber.Text(16))
+}
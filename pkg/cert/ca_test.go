@@ -0,0 +1,105 @@
+package cert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/util/cert"
+)
+
+func newTestCA(t *testing.T) *CA {
+	key, err := NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := cert.NewSelfSignedCACert(cert.Config{CommonName: CACommonName}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := parseCA(EncodeCertPEM(caCert), EncodePrivateKeyPEM(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca
+}
+
+// handshake 模拟worker与server的TLS握手
+func handshake(serverConfig, clientConfig *tls.Config) (serverErr, clientErr error) {
+	serverConn, clientConn := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		s := tls.Server(serverConn, serverConfig)
+		done <- s.Handshake()
+		s.Close()
+	}()
+	c := tls.Client(clientConn, clientConfig)
+	clientErr = c.Handshake()
+	c.Close()
+	return <-done, clientErr
+}
+
+func TestCA_SignWorkerCSR(t *testing.T) {
+	ca := newTestCA(t)
+	keyPEM, csrPEM, err := NewWorkerCSR("worker-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	workerCert, err := ca.SignWorkerCSR(csrPEM, "worker-1", 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(workerCert.Subject.CommonName, GetSerialNumber(workerCert), workerCert.NotAfter)
+	workerCertificate, err := tls.X509KeyPair(EncodeCertPEM(workerCert), keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// server证书
+	artifacts, err := (&SelfSignedCertGenerator{}).Generate("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	os.WriteFile(certFile, artifacts.Cert, 0644)
+	os.WriteFile(keyFile, artifacts.Key, 0600)
+	pin, _ := GetCertFileFingerprint(certFile)
+
+	revoked := false
+	serverConfig, err := NewServerTLSConfig(certFile, keyFile, ca, func(c *x509.Certificate) error {
+		if revoked {
+			return errors.New("revoked")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 双向TLS
+	clientConfig := NewPinnedTLSConfig(pin)
+	clientConfig.Certificates = []tls.Certificate{workerCertificate}
+	if serverErr, clientErr := handshake(serverConfig, clientConfig); serverErr != nil || clientErr != nil {
+		t.Errorf("mutual tls fail:%v,%v", serverErr, clientErr)
+	}
+	// server指纹不一致
+	if _, clientErr := handshake(serverConfig, NewPinnedTLSConfig("00")); clientErr == nil {
+		t.Error("expected pin mismatch")
+	}
+	// 证书已吊销
+	revoked = true
+	if serverErr, _ := handshake(serverConfig, clientConfig); serverErr == nil {
+		t.Error("expected revoked cert rejected")
+	}
+	// 其它CA签发的证书
+	otherCA := newTestCA(t)
+	otherCert, _ := otherCA.SignWorkerCSR(csrPEM, "worker-1", 30)
+	otherCertificate, _ := tls.X509KeyPair(EncodeCertPEM(otherCert), keyPEM)
+	clientConfig.Certificates = []tls.Certificate{otherCertificate}
+	if serverErr, _ := handshake(serverConfig, clientConfig); serverErr == nil {
+		t.Error("expected unknown CA rejected")
+	}
+}
@@ -0,0 +1,73 @@
+package cert
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// server签名发布清单的私钥
+	ReleaseKeyFile = "release.key"
+	// worker固定的server发布清单公钥
+	ReleasePubFile = "release.pub"
+)
+
+// LoadOrCreateReleaseKey 加载签名发布清单的ed25519私钥，如果不存在则生成新的私钥
+func LoadOrCreateReleaseKey() (ed25519.PrivateKey, error) {
+	keyFile := getCertFilePath(ReleaseKeyFile)
+	if keyPEM, err := os.ReadFile(keyFile); err == nil {
+		block, _ := pem.Decode(keyPEM)
+		if block == nil {
+			return nil, errors.New("invalid release key")
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("invalid release key")
+		}
+		return privateKey, nil
+	}
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, err
+	}
+	return privateKey, nil
+}
+
+// LoadReleasePublicKey 加载worker固定的发布清单公钥，不存在时返回nil
+func LoadReleasePublicKey() ed25519.PublicKey {
+	content, err := os.ReadFile(getCertFilePath(ReleasePubFile))
+	if err != nil || len(content) != ed25519.PublicKeySize {
+		return nil
+	}
+	return content
+}
+
+// SaveReleasePublicKey 保存发布清单的公钥，之后只接受该公钥签名的清单
+func SaveReleasePublicKey(publicKey ed25519.PublicKey) error {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return errors.New("invalid release public key")
+	}
+	if err := os.MkdirAll(filepath.Dir(getCertFilePath(ReleasePubFile)), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(getCertFilePath(ReleasePubFile), publicKey, 0644)
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -16,15 +17,20 @@ var cmd *exec.Cmd
 var WorkerName string
 
 // StartWorkerDaemon 启动worker的daemon
-func StartWorkerDaemon(workerRunTaskMode, taskWorkspaceGUID string, concurrency, workerPerformance int, noFilesync bool) {
+func StartWorkerDaemon(workerRunTaskMode, taskWorkspaceGUID, workerLabel string, concurrency, workerPerformance int, noFilesync bool) {
 	fileSyncServer := conf.GlobalWorkerConfig().FileSync
+	filesync.ProgressReporter = doFileSyncProgress
 	if !noFilesync {
 		logging.CLILog.Info("start file sync...")
 		filesync.WorkerStartupSync(fileSyncServer.Host, fmt.Sprintf("%d", fileSyncServer.Port), fileSyncServer.AuthKey)
 	}
-	if success := StartWorker(workerRunTaskMode, taskWorkspaceGUID, concurrency, workerPerformance); success == false {
+	startWorker := func() bool {
+		return StartWorker(workerRunTaskMode, taskWorkspaceGUID, workerLabel, concurrency, workerPerformance)
+	}
+	if success := startWorker(); success == false {
 		return
 	}
+	labels := getDaemonWorkerLabels(workerLabel)
 	for {
 		time.Sleep(15 * time.Second)
 		replay, err := DoDaemonKeepAlive()
@@ -41,7 +47,9 @@ func StartWorkerDaemon(workerRunTaskMode, taskWorkspaceGUID string, concurrency,
 					logging.RuntimeLog.Info("manual reload to start file sync...")
 					filesync.WorkerStartupSync(fileSyncServer.Host, fmt.Sprintf("%d", fileSyncServer.Port), fileSyncServer.AuthKey)
 				}
-				StartWorker(workerRunTaskMode, taskWorkspaceGUID, concurrency, workerPerformance)
+				startWorker()
+				// 文件同步后立即检查worker的更新
+				lastReleaseCheckTime = time.Time{}
 			}
 			// 忽略文件同步（如果有）
 			continue
@@ -50,7 +58,10 @@ func StartWorkerDaemon(workerRunTaskMode, taskWorkspaceGUID string, concurrency,
 			logging.CLILog.Info("manual start file sync...")
 			logging.RuntimeLog.Info("manual start file sync...")
 			filesync.WorkerStartupSync(fileSyncServer.Host, fmt.Sprintf("%d", fileSyncServer.Port), fileSyncServer.AuthKey)
+			lastReleaseCheckTime = time.Time{}
 		}
+		// 同步的worker需要验证发布清单后再替换
+		doCheckRelease(replay, labels, startWorker)
 	}
 }
 
@@ -80,7 +91,7 @@ func KillWorker() bool {
 }
 
 // StartWorker 启动worker进程
-func StartWorker(workerRunTaskMode, taskWorkspaceGUID string, concurrency, workerPerformance int) bool {
+func StartWorker(workerRunTaskMode, taskWorkspaceGUID, workerLabel string, concurrency, workerPerformance int) bool {
 	workerBin := utils.GetThirdpartyBinNameByPlatform(utils.Worker)
 	//绝对路径
 	workerPathName, err := filepath.Abs(filepath.Join(conf.GetRootPath(), workerBin))
@@ -95,6 +106,9 @@ func StartWorker(workerRunTaskMode, taskWorkspaceGUID string, concurrency, worke
 		"-m", workerRunTaskMode,
 		"-w", taskWorkspaceGUID,
 	}
+	if workerLabel != "" {
+		cmdArgs = append(cmdArgs, "-l", workerLabel)
+	}
 	if TLSEnabled {
 		cmdArgs = append(cmdArgs, "-tls")
 	}
@@ -112,6 +126,12 @@ func StartWorker(workerRunTaskMode, taskWorkspaceGUID string, concurrency, worke
 	return true
 }
 
+// GetWorkerHostName worker所在主机的名称（不含进程号），用于关联daemon与worker
+func GetWorkerHostName(workerName string) string {
+	hostName, _, _ := strings.Cut(workerName, "#")
+	return hostName
+}
+
 func GetWorkerNameByDaemon() string {
 	return getWorkerName(cmd.Process.Pid)
 }
@@ -125,7 +145,10 @@ func getWorkerName(pid int) string {
 	if hostIP == "" {
 		hostIP, _ = utils.GetClientIp()
 	}
-	hostName, _ := os.Hostname()
+	hostName := getEnrolledWorkerName()
+	if hostName == "" {
+		hostName, _ = os.Hostname()
+	}
 
 	return fmt.Sprintf("%s@%s#%d", hostName, hostIP, pid)
 }
@@ -0,0 +1,146 @@
+package comm
+
+import (
+	"context"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"strings"
+)
+
+// LoadHoneyPotIPArgs 读取蜜罐IP的请求参数
+type LoadHoneyPotIPArgs struct {
+	TaskId string
+	Target string
+}
+
+// LoadHoneyPotIP 读取目标中的蜜罐：蜜罐评分达到阈值或者在honeypot.txt中定义的IP，以及解析到这些IP的域名（返回IP或域名）
+func (s *Service) LoadHoneyPotIP(ctx context.Context, args *LoadHoneyPotIPArgs, replay *[]string) error {
+	taskRun, err := checkDispatchedTask(ctx, args.TaskId)
+	if err != nil {
+		return err
+	}
+	hp := custom.NewHoneyPot()
+	checked := make(map[string]struct{})
+	for _, target := range strings.Split(args.Target, ",") {
+		host := utils.HostStrip(strings.TrimSpace(target))
+		if host == "" {
+			continue
+		}
+		if _, ok := checked[host]; ok {
+			continue
+		}
+		checked[host] = struct{}{}
+		var ips []string
+		if utils.CheckIPV4(host) {
+			ips = []string{host}
+		} else {
+			// 域名使用已保存的解析结果
+			domain := db.Domain{DomainName: host, WorkspaceId: taskRun.WorkspaceId}
+			if !domain.GetByDomain() {
+				continue
+			}
+			domainAttr := db.DomainAttr{RelatedId: domain.Id}
+			for _, da := range domainAttr.GetsByRelatedId() {
+				if da.Tag == "A" {
+					ips = append(ips, da.Content)
+				}
+			}
+		}
+		for _, ip := range ips {
+			if isHoneyPotIP(hp, taskRun.WorkspaceId, ip) {
+				*replay = append(*replay, host)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// isHoneyPotIP IP在honeypot.txt中定义，或者已保存的蜜罐评分达到阈值
+func isHoneyPotIP(hp *custom.HoneyPot, workspaceId int, ip string) bool {
+	if isHoneypot, _ := hp.CheckHoneyPot(ip, ""); isHoneypot {
+		return true
+	}
+	ipDb := db.Ip{IpName: ip, WorkspaceId: workspaceId}
+	if !ipDb.GetByIp() {
+		return false
+	}
+	ipAttr := db.IpAttr{RelatedId: ipDb.Id, Tag: "honeypot"}
+	if !ipAttr.GetByRelatedIdAndTag() {
+		return false
+	}
+	score, ok := custom.ParseHoneyPotScore(ipAttr.Content)
+	return ok && score.IsHoneyPot()
+}
+
+// honeyPotPortAttrTags 蜜罐评分使用的端口属性
+var honeyPotPortAttrTags = []string{"service", "banner", "title", "server", "fingerprint"}
+
+// updateHoneyPotScore 根据IP已保存的全部端口信息重新计算蜜罐评分；只计算本次结果中有端口的IP，端口及属性批量查询
+func updateHoneyPotScore(workspaceId int, ipResult map[string]*portscan.IPResult) {
+	var ipNames []string
+	for ipName, ipr := range ipResult {
+		if ipr != nil && len(ipr.Ports) > 0 {
+			ipNames = append(ipNames, ipName)
+		}
+	}
+	if len(ipNames) == 0 {
+		return
+	}
+	ipDb := db.Ip{WorkspaceId: workspaceId}
+	ips := ipDb.GetsByIps(ipNames)
+	if len(ips) == 0 {
+		return
+	}
+	var ipIds []int
+	for _, ip := range ips {
+		ipIds = append(ipIds, ip.Id)
+	}
+	portDb := db.Port{}
+	ports := portDb.GetsByIPIds(ipIds)
+	var portIds []int
+	for _, port := range ports {
+		portIds = append(portIds, port.Id)
+	}
+	portAttrDb := db.PortAttr{}
+	portAttrs := make(map[int][]db.PortAttr)
+	for _, pa := range portAttrDb.GetsByRelatedIds(portIds, honeyPotPortAttrTags) {
+		portAttrs[pa.RelatedId] = append(portAttrs[pa.RelatedId], pa)
+	}
+	ipPorts := make(map[int][]custom.HoneyPotPortInfo)
+	for _, port := range ports {
+		info := custom.HoneyPotPortInfo{Port: port.PortNum}
+		for _, pa := range portAttrs[port.Id] {
+			switch pa.Tag {
+			case "service":
+				if info.Service == "" {
+					info.Service = pa.Content
+				}
+			case "banner":
+				if info.Banner == "" {
+					info.Banner = pa.Content
+				}
+			case "title", "server", "fingerprint":
+				info.Fingerprints = append(info.Fingerprints, pa.Content)
+			}
+		}
+		ipPorts[port.IpId] = append(ipPorts[port.IpId], info)
+	}
+	for _, ip := range ips {
+		score := custom.ScoreHoneyPot(ipPorts[ip.Id])
+		ipAttr := db.IpAttr{RelatedId: ip.Id, Source: "honeypot", Tag: "honeypot", Content: score.String()}
+		if score.Score == 0 {
+			if ipAttr.GetByRelatedIdAndTag() {
+				ipAttr.Delete()
+			}
+			continue
+		}
+		if score.IsHoneyPot() {
+			logging.RuntimeLog.Warningf("ip:%s may be honeypot,score:%d,%s", ip.IpName, score.Score, strings.Join(score.Reasons, ";"))
+		}
+		ipAttr.SaveOrUpdateByTag()
+	}
+}
@@ -1,8 +1,12 @@
 package comm
 
 import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/filesync"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -14,19 +18,39 @@ type KeepAliveInfo struct {
 type WorkerDaemonManualInfo struct {
 	ManualReloadFlag   bool `json:"manual_reload_flag"`
 	ManualFileSyncFlag bool `json:"manual_file_sync_flag"`
+	// WorkerAlive daemon启动的worker在workerAliveTimeout内有心跳
+	WorkerAlive bool `json:"worker_alive"`
+	// WorkerUpdateTime worker最近一次心跳的时间，ServerTime为server的当前时间，均为server的时间
+	WorkerUpdateTime time.Time `json:"worker_update_time"`
+	ServerTime       time.Time `json:"server_time"`
 }
 
+// WorkerResourceSample worker资源使用情况的历史记录
+type WorkerResourceSample struct {
+	Time time.Time `json:"time"`
+	ampq.WorkerResource
+}
+
+// workerAliveTimeout worker心跳的超时时间（心跳间隔为60秒）
+const workerAliveTimeout = 3 * time.Minute
+
+// workerResourceHistoryNumber 每个worker保存的资源历史记录数（心跳间隔为60秒，约2个小时）
+const workerResourceHistoryNumber = 120
+
 var (
-	WorkerStatusMutex sync.Mutex
-	WorkerStatus      = make(map[string]*ampq.WorkerStatus)
+	WorkerStatusMutex     sync.Mutex
+	WorkerStatus          = make(map[string]*ampq.WorkerStatus)
+	WorkerResourceHistory = make(map[string][]WorkerResourceSample)
+	// WorkerFileSyncProgress worker文件同步的进度，key为worker的主机名称
+	WorkerFileSyncProgress = make(map[string]filesync.SyncProgress)
 )
 
 // DoKeepAlive worker请求keepAlive
-func DoKeepAlive(ws ampq.WorkerStatus) bool {
+func DoKeepAlive(ws *ampq.WorkerStatus) bool {
 	kari := newKeepAliveRequestInfo(ws)
 	var replay string
 
-	if err := CallXClient("KeepAlive", &kari, &replay); err != nil {
+	if err := CallXClient("KeepAlive", kari, &replay); err != nil {
 		logging.RuntimeLog.Errorf("keep alive fail:%v", err)
 		logging.CLILog.Errorf("keep alive fail:%v", err)
 		return false
@@ -44,11 +68,77 @@ func DoDaemonKeepAlive() (replay WorkerDaemonManualInfo, err error) {
 	return
 }
 
-// newKeepAliveRequestInfo worker请求的keepAlive数据
-func newKeepAliveRequestInfo(ws ampq.WorkerStatus) KeepAliveInfo {
-	kai := KeepAliveInfo{
-		WorkerStatus: ws,
+// newKeepAliveRequestInfo worker请求的keepAlive数据（调用者需锁定ws，复制时不复制锁）
+func newKeepAliveRequestInfo(ws *ampq.WorkerStatus) *KeepAliveInfo {
+	return &KeepAliveInfo{
+		WorkerStatus: ampq.WorkerStatus{
+			WorkerName:             ws.WorkerName,
+			WorkerTopics:           ws.WorkerTopics,
+			CreateTime:             ws.CreateTime,
+			UpdateTime:             time.Now(),
+			TaskExecutedNumber:     ws.TaskExecutedNumber,
+			ManualReloadFlag:       ws.ManualReloadFlag,
+			ManualFileSyncFlag:     ws.ManualFileSyncFlag,
+			WorkerDaemonUpdateTime: ws.WorkerDaemonUpdateTime,
+			Capability:             ws.Capability,
+			Resource:               ws.Resource,
+		},
+	}
+}
+
+// appendWorkerResourceHistory 保存worker的资源使用历史记录（需在WorkerStatusMutex锁定后调用）
+func appendWorkerResourceHistory(workerName string, resource ampq.WorkerResource) {
+	history := append(WorkerResourceHistory[workerName], WorkerResourceSample{Time: time.Now(), WorkerResource: resource})
+	if len(history) > workerResourceHistoryNumber {
+		history = history[len(history)-workerResourceHistoryNumber:]
+	}
+	WorkerResourceHistory[workerName] = history
+}
+
+// doFileSyncProgress daemon上报文件同步的进度
+func doFileSyncProgress(progress filesync.SyncProgress) {
+	progress.WorkerHost = GetWorkerHostName(getWorkerName(os.Getpid()))
+	var replay string
+	if err := CallXClient("FileSyncProgress", &progress, &replay); err != nil {
+		logging.RuntimeLog.Warningf("report file sync progress fail:%v", err)
+	}
+}
+
+// isWorkerAlive worker最近一次心跳是否在超时时间内
+func isWorkerAlive(updateTime, now time.Time) bool {
+	return !updateTime.IsZero() && now.Sub(updateTime) < workerAliveTimeout
+}
+
+// hasWorkerTopic worker心跳上报的任务队列中包含该队列
+func hasWorkerTopic(workerTopics, topic string) bool {
+	for _, t := range strings.Split(workerTopics, ",") {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTaskWorker 检查在线的worker是否能执行任务：订阅了任务队列的在线worker中至少有一个具备任务需要的能力；
+// 没有在线的worker订阅该队列时不作限制，任务等待worker启动后执行
+func checkTaskWorker(taskName, configJSON, topicName string) error {
+	WorkerStatusMutex.Lock()
+	defer WorkerStatusMutex.Unlock()
+
+	now := time.Now()
+	hasWorker := false
+	for _, ws := range WorkerStatus {
+		if !isWorkerAlive(ws.UpdateTime, now) || !hasWorkerTopic(ws.WorkerTopics, topicName) {
+			continue
+		}
+		hasWorker = true
+		// 未检测能力的worker不作限制
+		if ws.Capability.Items == nil || ampq.CheckTaskConfigCapability(taskName, configJSON, ws.Capability) {
+			return nil
+		}
+	}
+	if hasWorker {
+		return fmt.Errorf("no alive worker on %s can execute task %s", topicName, taskName)
 	}
-	kai.WorkerStatus.UpdateTime = time.Now()
-	return kai
+	return nil
 }
@@ -0,0 +1,36 @@
+package comm
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"testing"
+	"time"
+)
+
+func TestCheckTaskWorker(t *testing.T) {
+	WorkerStatus["worker01@127.0.0.1#1"] = &ampq.WorkerStatus{
+		WorkerTopics: "active,finger",
+		UpdateTime:   time.Now(),
+		Capability:   ampq.WorkerCapability{Items: map[string]string{ampq.CapabilityHttpx: "v1.3.4"}},
+	}
+	WorkerStatus["worker02@127.0.0.1#2"] = &ampq.WorkerStatus{
+		WorkerTopics: "pocscan",
+		UpdateTime:   time.Now().Add(-10 * time.Minute),
+		Capability:   ampq.WorkerCapability{Items: map[string]string{ampq.CapabilityNuclei: "v2.9.1"}},
+	}
+	defer func() {
+		delete(WorkerStatus, "worker01@127.0.0.1#1")
+		delete(WorkerStatus, "worker02@127.0.0.1#2")
+	}()
+
+	if err := checkTaskWorker("fingerprint", `{"IsHttpx":true}`, "finger"); err != nil {
+		t.Error(err)
+	}
+	// 在线的worker没有chrome，不能截图
+	if err := checkTaskWorker("fingerprint", `{"IsHttpx":true,"IsScreenshot":true}`, "finger"); err == nil {
+		t.Error("fingerprint with screenshot should fail")
+	}
+	// 没有在线的worker订阅队列时等待worker启动
+	if err := checkTaskWorker("nuclei", "{}", "pocscan"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,453 @@
+package comm
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/cert"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/filesync"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// worker更新的结果
+const (
+	ReleaseStatusUpdated    = "updated"
+	ReleaseStatusRolledBack = "rolledback"
+)
+
+const (
+	// releaseVerifyTimeout 更新后的worker在该时间内没有心跳则回滚
+	releaseVerifyTimeout = 3 * time.Minute
+	// releaseCheckInterval daemon检查是否可以更新worker的间隔
+	releaseCheckInterval = 5 * time.Minute
+	// releaseBackupSuffix 更新前的worker备份，用于回滚
+	releaseBackupSuffix = ".bak"
+)
+
+// ReleaseManifest 发布清单：版本及需要更新的文件的SHA256
+type ReleaseManifest struct {
+	Version    string            `json:"version"`
+	Files      map[string]string `json:"files"`
+	CreateTime time.Time         `json:"create_time"`
+}
+
+type GetReleaseManifestArgs struct {
+	WorkerHost string
+	Labels     []string
+}
+
+// SignedReleaseManifest server签名的发布清单，Allowed表示该worker是否可以开始更新
+type SignedReleaseManifest struct {
+	Manifest  []byte
+	Signature []byte
+	Allowed   bool
+}
+
+type ReportReleaseUpdateArgs struct {
+	WorkerHost string
+	Labels     []string
+	Digest     string
+	Version    string
+	Status     string
+}
+
+// releaseRollout 一个发布清单的分阶段更新状态
+type releaseRollout struct {
+	startTime         time.Time
+	canaryAllowed     bool
+	canaryUpdatedTime time.Time
+	halted            bool
+}
+
+// pendingRelease daemon已替换、等待验证的更新
+type pendingRelease struct {
+	manifest ReleaseManifest
+	digest   string
+	deadline time.Time
+	// applyTime 替换worker的时间（换算为server的时间），此后的心跳才是更新后的worker
+	applyTime time.Time
+}
+
+var (
+	releaseKey      ed25519.PrivateKey
+	releaseRollouts = make(map[string]*releaseRollout)
+	releaseMutex    sync.Mutex
+	// daemon的更新状态，只在daemon的心跳循环中使用
+	currentPendingRelease *pendingRelease
+	failedReleaseDigests  = make(map[string]struct{})
+	lastReleaseCheckTime  time.Time
+	// serverTimeOffset server与daemon的时间差，用于比较worker心跳的时间
+	serverTimeOffset time.Duration
+)
+
+// getReleaseKey 加载签名发布清单的私钥（需在releaseMutex锁定后调用）
+func getReleaseKey() (ed25519.PrivateKey, error) {
+	if releaseKey == nil {
+		key, err := cert.LoadOrCreateReleaseKey()
+		if err != nil {
+			return nil, err
+		}
+		releaseKey = key
+	}
+	return releaseKey, nil
+}
+
+// GetReleasePublicKey 发布清单的公钥，worker注册时保存
+func GetReleasePublicKey() []byte {
+	releaseMutex.Lock()
+	defer releaseMutex.Unlock()
+
+	key, err := getReleaseKey()
+	if err != nil {
+		logging.RuntimeLog.Errorf("load release key fail:%v", err)
+		return nil
+	}
+	return key.Public().(ed25519.PublicKey)
+}
+
+// sha256OfAFile 文件的SHA256
+func sha256OfAFile(fileName string) (string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// getReleaseDigest 发布清单中文件的摘要，同一个版本号重新编译后也视为新的发布
+func getReleaseDigest(files map[string]string) string {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(fmt.Sprintf("%s,,%s\n", name, files[name])))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// newReleaseManifest 根据server当前的文件生成发布清单
+func newReleaseManifest() (manifest ReleaseManifest, err error) {
+	manifest.Files = make(map[string]string)
+	if content, err := os.ReadFile(filepath.Join(conf.GetRootPath(), "version.txt")); err == nil {
+		manifest.Version = strings.TrimSpace(string(content))
+	}
+	for _, f := range filesync.StagedFileList {
+		var sum string
+		if sum, err = sha256OfAFile(filepath.Join(conf.GetRootPath(), f)); err != nil {
+			return
+		}
+		manifest.Files[f] = sum
+	}
+	manifest.CreateTime = time.Now()
+	return
+}
+
+// isCanaryWorker worker是否具有金丝雀标签
+func isCanaryWorker(labels []string) bool {
+	canaryLabel := conf.GlobalServerConfig().Release.CanaryLabel
+	if canaryLabel == "" {
+		return false
+	}
+	for _, label := range labels {
+		if label == canaryLabel {
+			return true
+		}
+	}
+	return false
+}
+
+// getCanaryPeriod 金丝雀worker的验证时间
+func getCanaryPeriod() time.Duration {
+	period := conf.GlobalServerConfig().Release.CanaryPeriod
+	if period <= 0 {
+		period = 10
+	}
+	return time.Duration(period) * time.Minute
+}
+
+// checkRolloutAllowed 分阶段更新：金丝雀worker先更新，金丝雀更新成功并经过验证时间后（或在验证时间内没有金丝雀worker）再更新其它worker，金丝雀回滚则停止更新
+func checkRolloutAllowed(digest string, labels []string) bool {
+	rollout, ok := releaseRollouts[digest]
+	if !ok {
+		rollout = &releaseRollout{startTime: time.Now()}
+		releaseRollouts[digest] = rollout
+	}
+	if rollout.halted {
+		return false
+	}
+	if isCanaryWorker(labels) {
+		rollout.canaryAllowed = true
+		return true
+	}
+	if !rollout.canaryUpdatedTime.IsZero() {
+		return time.Now().After(rollout.canaryUpdatedTime.Add(getCanaryPeriod()))
+	}
+	return !rollout.canaryAllowed && time.Now().After(rollout.startTime.Add(getCanaryPeriod()))
+}
+
+// GetReleaseManifest daemon获取签名的发布清单
+func (s *Service) GetReleaseManifest(ctx context.Context, args *GetReleaseManifestArgs, replay *SignedReleaseManifest) error {
+	if err := checkWorkerIdentity(ctx, args.WorkerHost); err != nil {
+		return err
+	}
+	manifest, err := newReleaseManifest()
+	if err != nil {
+		logging.RuntimeLog.Errorf("create release manifest fail:%v", err)
+		return err
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	releaseMutex.Lock()
+	defer releaseMutex.Unlock()
+
+	key, err := getReleaseKey()
+	if err != nil {
+		logging.RuntimeLog.Errorf("load release key fail:%v", err)
+		return err
+	}
+	replay.Manifest = data
+	replay.Signature = ed25519.Sign(key, data)
+	replay.Allowed = checkRolloutAllowed(getReleaseDigest(manifest.Files), args.Labels)
+	if replay.Allowed {
+		logging.RuntimeLog.Infof("release %s allowed for worker:%s", manifest.Version, args.WorkerHost)
+	}
+	return nil
+}
+
+// ReportReleaseUpdate daemon报告worker更新的结果
+func (s *Service) ReportReleaseUpdate(ctx context.Context, args *ReportReleaseUpdateArgs, replay *string) error {
+	if err := checkWorkerIdentity(ctx, args.WorkerHost); err != nil {
+		return err
+	}
+	releaseMutex.Lock()
+	defer releaseMutex.Unlock()
+
+	rollout, ok := releaseRollouts[args.Digest]
+	if !ok {
+		rollout = &releaseRollout{startTime: time.Now()}
+		releaseRollouts[args.Digest] = rollout
+	}
+	switch args.Status {
+	case ReleaseStatusUpdated:
+		logging.RuntimeLog.Infof("worker:%s updated to release %s", args.WorkerHost, args.Version)
+		if isCanaryWorker(args.Labels) && rollout.canaryUpdatedTime.IsZero() {
+			rollout.canaryUpdatedTime = time.Now()
+		}
+	case ReleaseStatusRolledBack:
+		logging.RuntimeLog.Errorf("worker:%s rolled back release %s,stop rollout", args.WorkerHost, args.Version)
+		rollout.halted = true
+	default:
+		return errors.New("invalid release status")
+	}
+	return nil
+}
+
+// getDaemonWorkerLabels daemon启动的worker的标签
+func getDaemonWorkerLabels(workerLabel string) (labels []string) {
+	for _, label := range append(strings.Split(workerLabel, ","), conf.GlobalWorkerConfig().Labels...) {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return
+}
+
+// getReleaseFilePath worker本地文件的路径
+func getReleaseFilePath(fileName string) string {
+	return filepath.Join(conf.GetRootPath(), fileName)
+}
+
+// hasStagedRelease 文件同步后是否有等待更新的文件
+func hasStagedRelease() bool {
+	for _, f := range filesync.StagedFileList {
+		if _, err := os.Stat(getReleaseFilePath(f + filesync.StagedFileSuffix)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchReleaseManifest 获取并验证发布清单的签名；公钥只来自注册（验证了server指纹）或手工复制的release.pub，没有公钥时不更新
+func fetchReleaseManifest(labels []string) (manifest ReleaseManifest, allowed bool, err error) {
+	args := GetReleaseManifestArgs{WorkerHost: GetWorkerHostName(GetWorkerNameBySelf()), Labels: labels}
+	var replay SignedReleaseManifest
+	if err = CallXClient("GetReleaseManifest", &args, &replay); err != nil {
+		return
+	}
+	publicKey := cert.LoadReleasePublicKey()
+	if publicKey == nil {
+		err = errors.New("no release public key,enroll worker or copy cert/release.pub from server")
+		return
+	}
+	if !ed25519.Verify(publicKey, replay.Manifest, replay.Signature) {
+		err = errors.New("invalid release manifest signature")
+		return
+	}
+	if err = json.Unmarshal(replay.Manifest, &manifest); err != nil {
+		return
+	}
+	return manifest, replay.Allowed, nil
+}
+
+// reportReleaseUpdate 报告worker更新的结果
+func reportReleaseUpdate(labels []string, release *pendingRelease, status string) {
+	args := ReportReleaseUpdateArgs{
+		WorkerHost: GetWorkerHostName(GetWorkerNameBySelf()),
+		Labels:     labels,
+		Digest:     release.digest,
+		Version:    release.manifest.Version,
+		Status:     status,
+	}
+	var replay string
+	if err := CallXClient("ReportReleaseUpdate", &args, &replay); err != nil {
+		logging.RuntimeLog.Errorf("report release update fail:%v", err)
+	}
+}
+
+// checkStagedRelease 检查暂存文件与发布清单是否一致，不一致的暂存文件被删除
+func checkStagedRelease(manifest ReleaseManifest) (stagedFiles []string) {
+	for _, f := range filesync.StagedFileList {
+		stagedFile := getReleaseFilePath(f + filesync.StagedFileSuffix)
+		if _, err := os.Stat(stagedFile); err != nil {
+			continue
+		}
+		sum, err := sha256OfAFile(stagedFile)
+		if err != nil || sum != manifest.Files[f] {
+			logging.RuntimeLog.Errorf("staged file %s mismatch release manifest", f)
+			logging.CLILog.Errorf("staged file %s mismatch release manifest", f)
+			os.Remove(stagedFile)
+			return nil
+		}
+		stagedFiles = append(stagedFiles, f)
+	}
+	return
+}
+
+// doApplyRelease 验证发布清单后替换worker并重启，原文件备份用于回滚
+func doApplyRelease(labels []string, startWorker func() bool) {
+	if currentPendingRelease != nil || !hasStagedRelease() {
+		return
+	}
+	manifest, allowed, err := fetchReleaseManifest(labels)
+	if err != nil {
+		logging.RuntimeLog.Errorf("fetch release manifest fail:%v", err)
+		logging.CLILog.Errorf("fetch release manifest fail:%v", err)
+		return
+	}
+	digest := getReleaseDigest(manifest.Files)
+	if _, ok := failedReleaseDigests[digest]; ok || !allowed {
+		return
+	}
+	stagedFiles := checkStagedRelease(manifest)
+	if len(stagedFiles) == 0 {
+		return
+	}
+	logging.RuntimeLog.Infof("apply release %s...", manifest.Version)
+	logging.CLILog.Infof("apply release %s...", manifest.Version)
+	if !KillWorker() {
+		return
+	}
+	for _, f := range stagedFiles {
+		fileName := getReleaseFilePath(f)
+		if err = os.Rename(fileName, fileName+releaseBackupSuffix); err != nil {
+			logging.RuntimeLog.Error(err)
+		}
+		if err = os.Rename(fileName+filesync.StagedFileSuffix, fileName); err != nil {
+			logging.RuntimeLog.Error(err)
+		}
+	}
+	currentPendingRelease = &pendingRelease{
+		manifest:  manifest,
+		digest:    digest,
+		deadline:  time.Now().Add(releaseVerifyTimeout),
+		applyTime: time.Now().Add(serverTimeOffset),
+	}
+	// 新的worker启动失败时立即回滚
+	if !startWorker() {
+		doRollbackRelease(labels, startWorker)
+	}
+}
+
+// isReleaseWorkerAlive 替换后worker有新的心跳；替换前的心跳记录一直保留在server，不能作为依据
+func isReleaseWorkerAlive(release *pendingRelease, replay WorkerDaemonManualInfo) bool {
+	return replay.WorkerAlive && replay.WorkerUpdateTime.After(release.applyTime)
+}
+
+// doVerifyRelease 更新后的worker有心跳则更新成功，超时则回滚
+func doVerifyRelease(replay WorkerDaemonManualInfo, labels []string, startWorker func() bool) {
+	if currentPendingRelease == nil {
+		return
+	}
+	if isReleaseWorkerAlive(currentPendingRelease, replay) {
+		logging.RuntimeLog.Infof("worker updated to release %s", currentPendingRelease.manifest.Version)
+		logging.CLILog.Infof("worker updated to release %s", currentPendingRelease.manifest.Version)
+		for f := range currentPendingRelease.manifest.Files {
+			os.Remove(getReleaseFilePath(f + releaseBackupSuffix))
+		}
+		reportReleaseUpdate(labels, currentPendingRelease, ReleaseStatusUpdated)
+		currentPendingRelease = nil
+		return
+	}
+	if time.Now().After(currentPendingRelease.deadline) {
+		doRollbackRelease(labels, startWorker)
+	}
+}
+
+// doRollbackRelease 恢复更新前的worker并重启，该发布不再更新
+func doRollbackRelease(labels []string, startWorker func() bool) {
+	release := currentPendingRelease
+	logging.RuntimeLog.Errorf("release %s fail,rollback worker", release.manifest.Version)
+	logging.CLILog.Errorf("release %s fail,rollback worker", release.manifest.Version)
+	KillWorker()
+	for f := range release.manifest.Files {
+		fileName := getReleaseFilePath(f)
+		if _, err := os.Stat(fileName + releaseBackupSuffix); err != nil {
+			continue
+		}
+		if err := os.Rename(fileName+releaseBackupSuffix, fileName); err != nil {
+			logging.RuntimeLog.Error(err)
+		}
+	}
+	failedReleaseDigests[release.digest] = struct{}{}
+	currentPendingRelease = nil
+	startWorker()
+	reportReleaseUpdate(labels, release, ReleaseStatusRolledBack)
+}
+
+// doCheckRelease daemon心跳时检查worker的更新：验证已替换的worker，或定期检查暂存文件是否可以更新
+func doCheckRelease(replay WorkerDaemonManualInfo, labels []string, startWorker func() bool) {
+	if !replay.ServerTime.IsZero() {
+		serverTimeOffset = replay.ServerTime.Sub(time.Now())
+	}
+	if currentPendingRelease != nil {
+		doVerifyRelease(replay, labels, startWorker)
+		return
+	}
+	if time.Now().Before(lastReleaseCheckTime.Add(releaseCheckInterval)) {
+		return
+	}
+	lastReleaseCheckTime = time.Now()
+	doApplyRelease(labels, startWorker)
+}
@@ -0,0 +1,39 @@
+package comm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsWorkerAlive(t *testing.T) {
+	now := time.Now()
+	if !isWorkerAlive(now.Add(-time.Minute), now) {
+		t.Errorf("worker should be alive")
+	}
+	if isWorkerAlive(now.Add(-10*time.Minute), now) {
+		t.Errorf("stale worker should not be alive")
+	}
+	if isWorkerAlive(time.Time{}, now) {
+		t.Errorf("worker without keepalive should not be alive")
+	}
+}
+
+func TestIsReleaseWorkerAlive(t *testing.T) {
+	now := time.Now()
+	release := &pendingRelease{applyTime: now}
+	// 替换前的心跳记录仍在server，不能认为更新后的worker已启动
+	replay := WorkerDaemonManualInfo{WorkerAlive: true, WorkerUpdateTime: now.Add(-30 * time.Second), ServerTime: now.Add(15 * time.Second)}
+	if isReleaseWorkerAlive(release, replay) {
+		t.Errorf("stale keepalive before release applied should not be alive")
+	}
+	// 替换后有新的心跳
+	replay.WorkerUpdateTime = now.Add(10 * time.Second)
+	if !isReleaseWorkerAlive(release, replay) {
+		t.Errorf("keepalive after release applied should be alive")
+	}
+	// 心跳已超时
+	replay.WorkerAlive = false
+	if isReleaseWorkerAlive(release, replay) {
+		t.Errorf("timeout keepalive should not be alive")
+	}
+}
@@ -0,0 +1,227 @@
+package comm
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/cert"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"github.com/hanc00l/nemo_go/pkg/task/serverapi"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/smallnest/rpcx/server"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// worker密钥的名称
+const (
+	SecretFofa      = "fofa"
+	SecretHunter    = "hunter"
+	SecretQuake     = "quake"
+	SecretICP       = "icp"
+	SecretGoby      = "goby"
+	SecretSubfinder = "subfinder"
+)
+
+// worker密钥的作用范围，查找时优先级依次为工作空间、worker标签、全局
+const (
+	SecretScopeGlobal    = "global"
+	SecretScopeLabel     = "label"
+	SecretScopeWorkspace = "workspace"
+)
+
+// WorkerSecretNames 支持集中管理的密钥及说明
+var WorkerSecretNames = map[string]string{
+	SecretFofa:      "FOFA（email:key）",
+	SecretHunter:    "Hunter（key）",
+	SecretQuake:     "Quake（key）",
+	SecretICP:       "ICP备案（key）",
+	SecretGoby:      "Goby（user:pass）",
+	SecretSubfinder: "Subfinder（provider-config.yml内容）",
+}
+
+type GetWorkerSecretArgs struct {
+	Name   string
+	TaskId string
+}
+
+var (
+	secretKey      []byte
+	secretKeyMutex sync.Mutex
+)
+
+// getSecretKey 加载加密worker密钥的主密钥，主密钥只保存在server
+func getSecretKey() ([]byte, error) {
+	secretKeyMutex.Lock()
+	defer secretKeyMutex.Unlock()
+
+	if secretKey == nil {
+		key, err := cert.LoadOrCreateSecretKey()
+		if err != nil {
+			return nil, err
+		}
+		secretKey = key
+	}
+	return secretKey, nil
+}
+
+// CheckWorkerSecretScope 检查密钥名称及作用范围是否有效
+func CheckWorkerSecretScope(name, scopeType, scopeValue string) error {
+	if _, ok := WorkerSecretNames[name]; !ok {
+		return fmt.Errorf("invalid secret name:%s", name)
+	}
+	switch scopeType {
+	case SecretScopeGlobal:
+		if scopeValue != "" {
+			return errors.New("global secret has no scope value")
+		}
+	case SecretScopeLabel:
+		if !ampq.CheckWorkerLabel(scopeValue) {
+			return fmt.Errorf("invalid worker label:%s", scopeValue)
+		}
+	case SecretScopeWorkspace:
+		if id, err := strconv.Atoi(scopeValue); err != nil || id <= 0 {
+			return fmt.Errorf("invalid workspace id:%s", scopeValue)
+		}
+	default:
+		return fmt.Errorf("invalid scope type:%s", scopeType)
+	}
+	return nil
+}
+
+// SaveWorkerSecret 加密保存worker密钥，相同名称及作用范围的密钥已存在时更新（即密钥轮换）
+func SaveWorkerSecret(name, scopeType, scopeValue, value, description string) error {
+	if err := CheckWorkerSecretScope(name, scopeType, scopeValue); err != nil {
+		return err
+	}
+	key, err := getSecretKey()
+	if err != nil {
+		return err
+	}
+	encrypted, err := utils.AesEncryptGCM([]byte(value), key)
+	if err != nil {
+		return err
+	}
+	ws := db.WorkerSecret{SecretName: name, ScopeType: scopeType, ScopeValue: scopeValue}
+	if ws.GetByScope() {
+		if !ws.Update(map[string]interface{}{"secret_value": base64.StdEncoding.EncodeToString(encrypted), "description": description}) {
+			return errors.New("update secret fail")
+		}
+	} else {
+		ws.SecretValue = base64.StdEncoding.EncodeToString(encrypted)
+		ws.Description = description
+		if !ws.Add() {
+			return errors.New("add secret fail")
+		}
+	}
+	logging.RuntimeLog.Infof("save worker secret:%s,scope:%s %s", name, scopeType, scopeValue)
+
+	return nil
+}
+
+// decryptWorkerSecret 解密保存的worker密钥
+func decryptWorkerSecret(ws *db.WorkerSecret) (string, error) {
+	key, err := getSecretKey()
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(ws.SecretValue)
+	if err != nil {
+		return "", err
+	}
+	decrypted, err := utils.AesDecryptGCM(encrypted, key)
+	if err != nil {
+		return "", err
+	}
+	return string(decrypted), nil
+}
+
+// ResolveWorkerSecret 根据工作空间及worker标签查找密钥，依次为工作空间、worker标签、全局
+func ResolveWorkerSecret(name string, workspaceId int, workerLabel string) (value string, found bool) {
+	var scopes [][2]string
+	if workspaceId > 0 {
+		scopes = append(scopes, [2]string{SecretScopeWorkspace, strconv.Itoa(workspaceId)})
+	}
+	if workerLabel != "" {
+		scopes = append(scopes, [2]string{SecretScopeLabel, workerLabel})
+	}
+	scopes = append(scopes, [2]string{SecretScopeGlobal, ""})
+	for _, scope := range scopes {
+		ws := db.WorkerSecret{SecretName: name, ScopeType: scope[0], ScopeValue: scope[1]}
+		if !ws.GetByScope() {
+			continue
+		}
+		value, err := decryptWorkerSecret(&ws)
+		if err != nil {
+			logging.RuntimeLog.Errorf("decrypt worker secret %s fail:%v", name, err)
+			return "", false
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// checkSecureConn 密钥只能通过TLS或本机的连接传输（all-in-one模式下没有RPC连接）
+func checkSecureConn(ctx context.Context) bool {
+	conn, ok := ctx.Value(server.RemoteConnContextKey).(net.Conn)
+	if !ok {
+		return true
+	}
+	if _, ok = conn.(*tls.Conn); ok {
+		return true
+	}
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok && addr.IP.IsLoopback() {
+		return true
+	}
+	return false
+}
+
+// GetWorkerSecret worker执行任务时获取密钥，密钥不写入worker的磁盘
+func (s *Service) GetWorkerSecret(ctx context.Context, args *GetWorkerSecretArgs, replay *string) error {
+	if !checkSecureConn(ctx) {
+		return errors.New("secret only distributed over tls")
+	}
+	// 只能获取正在执行的任务所在工作空间的密钥
+	taskRun, err := checkDispatchedTask(ctx, args.TaskId)
+	if err != nil {
+		logging.RuntimeLog.Warningf("get worker secret %s fail:%v", args.Name, err)
+		return err
+	}
+	var workerLabel string
+	if taskRun.MainTaskId != "" {
+		dbMTask := db.TaskMain{TaskId: taskRun.MainTaskId}
+		if dbMTask.GetByTaskId() {
+			workerLabel = serverapi.GetMainTaskWorkerLabel(dbMTask.KwArgs)
+		}
+	}
+	// 标签范围的密钥只提供给具有该标签的worker
+	if workerLabel != "" && !hasWorkerLabel(taskRun.Worker, workerLabel) {
+		workerLabel = ""
+	}
+	value, found := ResolveWorkerSecret(args.Name, taskRun.WorkspaceId, workerLabel)
+	if !found {
+		return nil
+	}
+	*replay = value
+
+	return nil
+}
+
+// FetchWorkerSecret worker从server获取任务使用的密钥，未集中配置时返回空（使用worker本地的配置）
+func FetchWorkerSecret(name, taskId string) string {
+	if taskId == "" {
+		return ""
+	}
+	args := GetWorkerSecretArgs{Name: name, TaskId: taskId}
+	var value string
+	if err := CallXClient("GetWorkerSecret", &args, &value); err != nil {
+		logging.RuntimeLog.Warningf("fetch worker secret %s fail:%v", name, err)
+		return ""
+	}
+	return value
+}
@@ -3,12 +3,13 @@ package comm
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/cert"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/filesync"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/serverapi"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/smallnest/rpcx/protocol"
 	"github.com/smallnest/rpcx/server"
@@ -30,13 +31,12 @@ func StartRPCServer() {
 
 	var s *server.Server
 	if TLSEnabled {
-		cert, err := tls.LoadX509KeyPair(TLSCertFile, TLSKeyFile)
+		configs, err := cert.NewServerTLSConfig(TLSCertFile, TLSKeyFile, WorkerCA, CheckWorkerCert)
 		if err != nil {
 			logging.RuntimeLog.Infof("load tls cert fail:%s", err)
 			logging.CLILog.Infof("load tls cert fail:%s", err)
 			return
 		}
-		configs := &tls.Config{Certificates: []tls.Certificate{cert}}
 		s = server.NewServer(server.WithTLSConfig(configs))
 	} else {
 		s = server.NewServer()
@@ -48,6 +48,7 @@ func StartRPCServer() {
 		return
 	}
 	s.AuthFunc = auth
+	serverapi.CheckTaskWorker = checkTaskWorker
 	err = s.Serve("tcp", fmt.Sprintf("%s:%d", rpc.Host, rpc.Port))
 	if err != nil {
 		if err != nil {
@@ -58,8 +59,19 @@ func StartRPCServer() {
 	}
 }
 
-// auth RPC调用认证
+// auth RPC调用认证：已注册的worker使用证书认证，否则使用authKey认证
 func auth(ctx context.Context, req *protocol.Message, token string) error {
+	if c := getPeerCertificate(ctx); c != nil {
+		// 长连接在证书吊销后仍然存在，每次调用时都需要检查
+		return CheckWorkerCert(c)
+	}
+	// 注册时worker还没有证书，由EnrollWorker校验注册令牌
+	if req.ServiceMethod == "EnrollWorker" {
+		return nil
+	}
+	if conf.GlobalServerConfig().WorkerCert.Required {
+		return errors.New("worker cert required")
+	}
 	if token == conf.GlobalServerConfig().Rpc.AuthKey {
 		return nil
 	}
@@ -73,6 +85,11 @@ func StartFileSyncServer() {
 	logging.RuntimeLog.Infof("start filesync server running on tcp@%s:%d...", fileSyncServer.Host, fileSyncServer.Port)
 	logging.CLILog.Infof("start filesync server running on tcp@%s:%d...", fileSyncServer.Host, fileSyncServer.Port)
 
+	if filesync.TLSEnabled && WorkerCA != nil {
+		filesync.WorkerCA = WorkerCA
+		filesync.CheckWorkerCert = CheckWorkerCert
+		filesync.WorkerCertRequired = conf.GlobalServerConfig().WorkerCert.Required
+	}
 	filesync.StartFileSyncServer(fileSyncServer.Host, fmt.Sprintf("%d", fileSyncServer.Port), fileSyncServer.AuthKey)
 }
 
@@ -3,12 +3,13 @@ package comm
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/cert"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/filesync"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
@@ -24,6 +25,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -85,6 +87,18 @@ type LoadDomainOpenedPortArgs struct {
 	Target      map[string]struct{}
 }
 
+// LoadSubDomainArgs 读取域名已有子域名的请求参数
+type LoadSubDomainArgs struct {
+	TaskId string
+	Domain string
+}
+
+// LoadCDNOriginCandidateArgs 读取CDN域名的候选源站IP的请求参数
+type LoadCDNOriginCandidateArgs struct {
+	TaskId string
+	Domain string
+}
+
 type MainTaskResultMap struct {
 	IPResult         map[string]map[int]interface{}
 	DomainResult     map[string]interface{}
@@ -117,8 +131,19 @@ var (
 	TLSKeyFile  string
 )
 
+// cdnOriginMaxSubDomain 读取候选源站IP时最多检查的子域名数量
+const cdnOriginMaxSubDomain = 1000
+
 // CallXClient RPC远程调用
 func CallXClient(serviceMethod string, args interface{}, reply interface{}) error {
+	return callXClientContext(context.Background(), serviceMethod, args, reply)
+}
+
+// callXClientContext RPC远程调用，ctx中可以包含请求的元数据
+func callXClientContext(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	if conf.AllInOneMode {
+		return callLocalService(ctx, serviceMethod, args, reply)
+	}
 	globalXClientMutex.Lock()
 	defer globalXClientMutex.Unlock()
 
@@ -129,18 +154,49 @@ func CallXClient(serviceMethod string, args interface{}, reply interface{}) erro
 		}
 		option := client.DefaultOption
 		if TLSEnabled {
-			option.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+			option.TLSConfig = cert.NewWorkerTLSConfig()
 		}
 		d, _ := client.NewPeer2PeerDiscovery(fmt.Sprintf("tcp@%s:%d", host, conf.GlobalWorkerConfig().Rpc.Port), "")
 		globalXClient = client.NewXClient("Service", client.Failtry, client.RandomSelect, d, option)
 		globalXClient.Auth(conf.GlobalWorkerConfig().Rpc.AuthKey)
 	}
 
-	return globalXClient.Call(context.Background(), serviceMethod, args, reply)
+	return globalXClient.Call(ctx, serviceMethod, args, reply)
+}
+
+// callLocalService all-in-one模式下在进程内直接调用Service的方法，不经过RPC
+func callLocalService(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	method := reflect.ValueOf(new(Service)).MethodByName(serviceMethod)
+	if !method.IsValid() {
+		return fmt.Errorf("can't find method %s", serviceMethod)
+	}
+	argsType := method.Type().In(1)
+	argsValue := reflect.ValueOf(args)
+	if argsValue.Type() != argsType {
+		// 参数不是方法要求的指针类型（如直接传值），与RPC一样经过序列化转换
+		content, err := json.Marshal(args)
+		if err != nil {
+			return err
+		}
+		argsValue = reflect.New(argsType.Elem())
+		if err = json.Unmarshal(content, argsValue.Interface()); err != nil {
+			return err
+		}
+	}
+	results := method.Call([]reflect.Value{reflect.ValueOf(ctx), argsValue, reflect.ValueOf(reply)})
+	if err, ok := results[0].Interface().(error); ok && err != nil {
+		return err
+	}
+	return nil
 }
 
 // SaveScanResult 保存IP与域名的扫描结果
 func (s *Service) SaveScanResult(ctx context.Context, args *ScanResultArgs, replay *string) error {
+	// worker重新发送的已处理请求
+	if reserveIdempotentReply(ctx, replay) {
+		return nil
+	}
+	defer releaseIdempotentReply(ctx)
 	var msg []string
 	if args.IPConfig != nil && args.IPResult != nil {
 		r := portscan.Result{
@@ -150,6 +206,8 @@ func (s *Service) SaveScanResult(ctx context.Context, args *ScanResultArgs, repl
 		saveIPMutex.Lock()
 		msg = append(msg, r.SaveResult(*args.IPConfig))
 		saveIPMutex.Unlock()
+		// 根据保存后IP的全部端口信息更新蜜罐评分
+		updateHoneyPotScore(args.IPConfig.WorkspaceId, args.IPResult)
 
 		if len(args.IPResult) > 0 {
 			saveTaskResult(args.TaskID, args.IPResult)
@@ -171,12 +229,18 @@ func (s *Service) SaveScanResult(ctx context.Context, args *ScanResultArgs, repl
 	saveMainTaskResult(args.MainTaskId, args.IPResult, args.DomainResult, args.VulnerabilityResult, 0)
 	*replay = strings.Join(msg, ",")
 	saveMainTaskNewResult(args.MainTaskId, *replay)
+	saveIdempotentReply(ctx, replay)
 
 	return nil
 }
 
 // SaveScreenshotResult 保存Screenshot的结果到Server
 func (s *Service) SaveScreenshotResult(ctx context.Context, args *ScreenshotResultArgs, replay *string) error {
+	// worker重新发送的已处理请求
+	if reserveIdempotentReply(ctx, replay) {
+		return nil
+	}
+	defer releaseIdempotentReply(ctx)
 	ss := fingerprint.NewScreenShot()
 	//检查保存结果的路径
 	workspace := db.Workspace{Id: args.WorkspaceId}
@@ -191,11 +255,17 @@ func (s *Service) SaveScreenshotResult(ctx context.Context, args *ScreenshotResu
 	count := ss.SaveFile(screenshotPath, args.FileInfo)
 	saveMainTaskResult(args.MainTaskId, nil, nil, nil, count)
 	*replay = fmt.Sprintf("screenshot:%d", count)
+	saveIdempotentReply(ctx, replay)
 	return nil
 }
 
 // SaveIconImageResult 保存IconImage结果到Server
 func (s *Service) SaveIconImageResult(ctx context.Context, args *IconHashResultArgs, replay *string) error {
+	// worker重新发送的已处理请求
+	if reserveIdempotentReply(ctx, replay) {
+		return nil
+	}
+	defer releaseIdempotentReply(ctx)
 	workspace := db.Workspace{Id: args.WorkspaceId}
 	if workspace.Get() == false {
 		logging.RuntimeLog.Error("workspace error")
@@ -208,23 +278,35 @@ func (s *Service) SaveIconImageResult(ctx context.Context, args *IconHashResultA
 	}
 	hash := fingerprint.NewIconHash()
 	*replay = hash.SaveFile(iconImagePath, args.IconHashInfo)
+	saveIdempotentReply(ctx, replay)
 
 	return nil
 }
 
 // SaveVulnerabilityResult 保存漏洞结果
 func (s *Service) SaveVulnerabilityResult(ctx context.Context, args *ScanResultArgs, replay *string) error {
+	// worker重新发送的已处理请求
+	if reserveIdempotentReply(ctx, replay) {
+		return nil
+	}
+	defer releaseIdempotentReply(ctx)
 	*replay = pocscan.SaveResult(args.VulnerabilityResult)
 	if len(args.VulnerabilityResult) > 0 {
 		saveTaskResult(args.TaskID, args.VulnerabilityResult)
 		saveMainTaskResult(args.MainTaskId, nil, nil, args.VulnerabilityResult, 0)
 		saveMainTaskNewResult(args.MainTaskId, *replay)
 	}
+	saveIdempotentReply(ctx, replay)
 	return nil
 }
 
 // SaveICPResult 保存ICP查询结果到服务器的查询缓存文件中
 func (s *Service) SaveICPResult(ctx context.Context, args *map[string]*onlineapi.ICPInfo, replay *string) error {
+	// worker重新发送的已处理请求
+	if reserveIdempotentReply(ctx, replay) {
+		return nil
+	}
+	defer releaseIdempotentReply(ctx)
 	if *args == nil || len(*args) <= 0 {
 		*replay = "icp:0"
 		return nil
@@ -239,12 +321,18 @@ func (s *Service) SaveICPResult(ctx context.Context, args *map[string]*onlineapi
 		logging.RuntimeLog.Error("save icp fail")
 		*replay = "save icp fail"
 	}
+	saveIdempotentReply(ctx, replay)
 
 	return nil
 }
 
 // SaveWhoisResult 保存whois查询结果到服务器的查询缓存文件中
 func (s *Service) SaveWhoisResult(ctx context.Context, args *map[string]*whoisparser.WhoisInfo, replay *string) error {
+	// worker重新发送的已处理请求
+	if reserveIdempotentReply(ctx, replay) {
+		return nil
+	}
+	defer releaseIdempotentReply(ctx)
 	if *args == nil || len(*args) <= 0 {
 		*replay = "whois:0"
 		return nil
@@ -259,6 +347,7 @@ func (s *Service) SaveWhoisResult(ctx context.Context, args *map[string]*whoispa
 		logging.RuntimeLog.Error("save whois fail")
 		*replay = "save whois fail"
 	}
+	saveIdempotentReply(ctx, replay)
 
 	return nil
 }
@@ -287,6 +376,11 @@ func (s *Service) CheckTask(ctx context.Context, args *string, replay *TaskStatu
 
 // UpdateTask 更新任务状态到数据库中
 func (s *Service) UpdateTask(ctx context.Context, args *TaskStatusArgs, replay *bool) error {
+	// worker重新发送的已处理请求
+	if reserveIdempotentReply(ctx, replay) {
+		return nil
+	}
+	defer releaseIdempotentReply(ctx)
 	taskCheck := &db.TaskRun{TaskId: args.TaskID}
 	if !taskCheck.GetByTaskId() {
 		return nil
@@ -314,6 +408,7 @@ func (s *Service) UpdateTask(ctx context.Context, args *TaskStatusArgs, replay *
 	}
 	if task.SaveOrUpdate() {
 		*replay = true
+		saveIdempotentReply(ctx, replay)
 	} else {
 		logging.RuntimeLog.Errorf("update task:%s,state:%s fail !", args.TaskID, args.State)
 	}
@@ -326,9 +421,13 @@ func (s *Service) KeepAlive(ctx context.Context, args *KeepAliveInfo, replay *st
 		logging.RuntimeLog.Error("no worker name")
 		return nil
 	}
+	if err := checkWorkerIdentity(ctx, args.WorkerStatus.WorkerName); err != nil {
+		return err
+	}
 	WorkerStatusMutex.Lock()
 	WorkerStatus[args.WorkerStatus.WorkerName] = &args.WorkerStatus
 	WorkerStatus[args.WorkerStatus.WorkerName].UpdateTime = time.Now()
+	appendWorkerResourceHistory(args.WorkerStatus.WorkerName, args.WorkerStatus.Resource)
 	WorkerStatusMutex.Unlock()
 	msg := "ok"
 	*replay = msg
@@ -339,16 +438,21 @@ func (s *Service) KeepAlive(ctx context.Context, args *KeepAliveInfo, replay *st
 // KeepDaemonAlive worker的daemon通过RPC，保持与server的心跳与同步
 func (s *Service) KeepDaemonAlive(ctx context.Context, args *string, replay *WorkerDaemonManualInfo) error {
 	// args -> WorkName
-	wdm := WorkerDaemonManualInfo{}
+	wdm := WorkerDaemonManualInfo{ServerTime: time.Now()}
 	if *args == "" {
 		logging.RuntimeLog.Error("no worker name")
 		*replay = wdm
 		return nil
 	}
+	if err := checkWorkerIdentity(ctx, *args); err != nil {
+		return err
+	}
 	WorkerStatusMutex.Lock()
 	if _, ok := WorkerStatus[*args]; ok {
 		wdm.ManualReloadFlag = WorkerStatus[*args].ManualReloadFlag
 		wdm.ManualFileSyncFlag = WorkerStatus[*args].ManualFileSyncFlag
+		wdm.WorkerUpdateTime = WorkerStatus[*args].UpdateTime
+		wdm.WorkerAlive = isWorkerAlive(wdm.WorkerUpdateTime, wdm.ServerTime)
 		//
 		WorkerStatus[*args].WorkerDaemonUpdateTime = time.Now()
 		WorkerStatus[*args].ManualFileSyncFlag = false //重置文件同步请求标志
@@ -359,6 +463,21 @@ func (s *Service) KeepDaemonAlive(ctx context.Context, args *string, replay *Wor
 	return nil
 }
 
+// FileSyncProgress worker的daemon上报文件同步的进度
+func (s *Service) FileSyncProgress(ctx context.Context, args *filesync.SyncProgress, replay *string) error {
+	if args.WorkerHost == "" {
+		return errors.New("no worker host")
+	}
+	if err := checkWorkerIdentity(ctx, args.WorkerHost); err != nil {
+		return err
+	}
+	WorkerStatusMutex.Lock()
+	WorkerFileSyncProgress[args.WorkerHost] = *args
+	WorkerStatusMutex.Unlock()
+
+	return nil
+}
+
 // NewTask 创建一个新执行任务
 func (s *Service) NewTask(ctx context.Context, args *NewTaskArgs, replay *string) error {
 	if args.TaskName == "" || args.ConfigJSON == "" {
@@ -493,6 +612,114 @@ func (s *Service) LoadDomainOpenedPort(ctx context.Context, args *LoadDomainOpen
 	return nil
 }
 
+// LoadSubDomain 读取工作空间中指定域名已有的子域名
+func (s *Service) LoadSubDomain(ctx context.Context, args *LoadSubDomainArgs, replay *[]string) error {
+	if args.Domain == "" {
+		return errors.New("null domain")
+	}
+	taskRun, err := checkDispatchedTask(ctx, args.TaskId)
+	if err != nil {
+		return err
+	}
+	domain := db.Domain{}
+	for _, d := range domain.GetsForBlackListDomain("."+args.Domain, taskRun.WorkspaceId) {
+		*replay = append(*replay, d.DomainName)
+	}
+	return nil
+}
+
+// LoadWorkspaceDomain 读取工作空间中最近更新的域名（最多10000个），作为虚拟主机发现的候选域名
+func (s *Service) LoadWorkspaceDomain(ctx context.Context, args *string, replay *[]string) error {
+	// args -> TaskId，工作空间为任务所在的工作空间
+	if args == nil {
+		return errors.New("null taskId")
+	}
+	taskRun, err := checkDispatchedTask(ctx, *args)
+	if err != nil {
+		return err
+	}
+	searchMap := make(map[string]interface{})
+	searchMap["workspace_id"] = taskRun.WorkspaceId
+	domain := db.Domain{}
+	domains, _ := domain.Gets(searchMap, 1, 10000, true)
+	for _, d := range domains {
+		*replay = append(*replay, d.DomainName)
+	}
+	return nil
+}
+
+// LoadCDNOriginCandidate 从已保存的数据中读取CDN域名的候选源站IP（IP及来源）：域名历史的A记录、工作空间中TLS证书包含该域名的IP、未使用CDN的同主域名子域名的A记录
+func (s *Service) LoadCDNOriginCandidate(ctx context.Context, args *LoadCDNOriginCandidateArgs, replay *map[string]string) error {
+	if args.Domain == "" {
+		return errors.New("null domain")
+	}
+	taskRun, err := checkDispatchedTask(ctx, args.TaskId)
+	if err != nil {
+		return err
+	}
+	workspaceId := taskRun.WorkspaceId
+	result := make(map[string]string)
+	add := func(ip, source string) {
+		if _, ok := result[ip]; !ok && utils.CheckIPV4(ip) {
+			result[ip] = source
+		}
+	}
+	// 历史解析记录
+	domain := db.Domain{DomainName: args.Domain, WorkspaceId: workspaceId}
+	if domain.GetByDomain() {
+		domainAttr := db.DomainAttr{RelatedId: domain.Id}
+		for _, da := range domainAttr.GetsByRelatedId() {
+			if da.Tag == "A" {
+				add(da.Content, "history")
+			}
+		}
+	}
+	// 证书中包含域名（或通配符域名）的IP
+	names := []string{args.Domain}
+	if index := strings.Index(args.Domain, "."); index > 0 {
+		names = append(names, "*"+args.Domain[index:])
+	}
+	portAttr := db.PortAttr{}
+	for _, ip := range portAttr.GetsIpByTlsDataName(workspaceId, names) {
+		add(ip, "cert")
+	}
+	// 未使用CDN的同主域名的子域名
+	tld := domainscan.NewTldExtract()
+	fld := tld.ExtractFLD(args.Domain)
+	if fld == "" {
+		fld = args.Domain
+	}
+	subDomains := domain.GetsForBlackListDomain("."+fld, workspaceId)
+	if len(subDomains) > cdnOriginMaxSubDomain {
+		subDomains = subDomains[:cdnOriginMaxSubDomain]
+	}
+	for _, d := range subDomains {
+		if d.DomainName == args.Domain {
+			continue
+		}
+		domainAttr := db.DomainAttr{RelatedId: d.Id}
+		var ips []string
+		isCDN := false
+		for _, da := range domainAttr.GetsByRelatedId() {
+			if da.Tag == "CDN" {
+				isCDN = true
+				break
+			}
+			if da.Tag == "A" {
+				ips = append(ips, da.Content)
+			}
+		}
+		if isCDN {
+			continue
+		}
+		for _, ip := range ips {
+			add(ip, "subdomain:"+d.DomainName)
+		}
+	}
+	*replay = result
+	return nil
+}
+
 // SaveRuntimeLog 保存RuntimeLog
 func (s *Service) SaveRuntimeLog(ctx context.Context, args *RuntimeLogArgs, replay *string) error {
 	if len(args.Source) == 0 || len(args.LogMessage) == 0 {
@@ -0,0 +1,405 @@
+package comm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
+	whoisparser "github.com/likexian/whois-parser"
+	"github.com/smallnest/rpcx/client"
+	"github.com/smallnest/rpcx/share"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SpoolDir worker保存未发送结果的目录
+	SpoolDir = "spool"
+	// spoolReplayInterval 重新发送未发送结果的间隔
+	spoolReplayInterval = 30 * time.Second
+	// idempotencyKeyName RPC请求元数据中幂等键的名称
+	idempotencyKeyName = "idempotency_key"
+	// idempotencyExpiredTime server保存已处理请求的时间
+	idempotencyExpiredTime = 24 * time.Hour
+	// SpooledResult 结果已保存到本地队列、等待发送
+	SpooledResult = "spooled"
+	// idempotencyFile server保存已处理请求的文件（位于SpoolDir），server重启后仍能忽略worker重复发送的请求
+	idempotencyFile = "idempotency.log"
+)
+
+// spoolEntry 本地队列中未发送的RPC请求
+type spoolEntry struct {
+	Key        string          `json:"key"`
+	Method     string          `json:"method"`
+	Args       json.RawMessage `json:"args"`
+	CreateTime time.Time       `json:"create_time"`
+}
+
+// idempotentReply server已处理的请求的返回值
+type idempotentReply struct {
+	reply      []byte
+	updateTime time.Time
+}
+
+// idempotentRecord 已处理请求在文件中的记录（每行一条）
+type idempotentRecord struct {
+	Key        string          `json:"key"`
+	Reply      json.RawMessage `json:"reply"`
+	UpdateTime time.Time       `json:"update_time"`
+}
+
+// spoolMethodArgs 可以保存到本地队列的RPC方法及参数、返回值的类型
+var spoolMethodArgs = map[string]func() (args interface{}, reply interface{}){
+	"SaveScanResult":          func() (interface{}, interface{}) { return &ScanResultArgs{}, new(string) },
+	"SaveVulnerabilityResult": func() (interface{}, interface{}) { return &ScanResultArgs{}, new(string) },
+	"SaveScreenshotResult":    func() (interface{}, interface{}) { return &ScreenshotResultArgs{}, new(string) },
+	"SaveIconImageResult":     func() (interface{}, interface{}) { return &IconHashResultArgs{}, new(string) },
+	"UpdateTask":              func() (interface{}, interface{}) { return &TaskStatusArgs{}, new(bool) },
+	"SaveICPResult": func() (interface{}, interface{}) {
+		return &map[string]*onlineapi.ICPInfo{}, new(string)
+	},
+	"SaveWhoisResult": func() (interface{}, interface{}) {
+		return &map[string]*whoisparser.WhoisInfo{}, new(string)
+	},
+}
+
+var (
+	// spoolPendingNumber 本地队列中未发送的请求数
+	spoolPendingNumber int
+	spoolMutex         sync.Mutex
+	// spoolReplayMutex 同一时间只有一个重新发送的过程
+	spoolReplayMutex sync.Mutex
+	// idempotentReplies server已处理的请求，用于忽略worker重复发送的请求
+	idempotentReplies      = make(map[string]idempotentReply)
+	idempotentRepliesMutex sync.Mutex
+	idempotentRepliesOnce  sync.Once
+	// idempotentPending 正在处理的请求，处理结束（保存结果或失败）时关闭channel
+	idempotentPending = make(map[string]chan struct{})
+	// idempotentRecordNumber 文件中的记录数，过多时重写文件以去除过期的记录
+	idempotentRecordNumber int
+)
+
+// callXClientWithKey 带幂等键的RPC调用
+func callXClientWithKey(serviceMethod, key string, args interface{}, reply interface{}) error {
+	ctx := context.WithValue(context.Background(), share.ReqMetaDataKey, map[string]string{idempotencyKeyName: key})
+	return callXClientContext(ctx, serviceMethod, args, reply)
+}
+
+// isServiceError 是否为server处理请求返回的错误（而不是连接错误）
+func isServiceError(err error) bool {
+	_, ok := err.(client.ServiceError)
+	return ok
+}
+
+// CallXClientWithSpool 保存结果及任务状态的RPC调用：连接server失败时保存到本地队列，待server可以连接时按顺序重新发送
+func CallXClientWithSpool(serviceMethod string, args interface{}, reply interface{}) error {
+	key := uuid.New().String()
+	spoolMutex.Lock()
+	pending := spoolPendingNumber > 0
+	spoolMutex.Unlock()
+	// 有未发送的请求时直接追加到队列中，保持请求的顺序
+	if !pending {
+		err := callXClientWithKey(serviceMethod, key, args, reply)
+		if err == nil || isServiceError(err) {
+			return err
+		}
+		logging.RuntimeLog.Warningf("call %s fail:%v,spool to disk", serviceMethod, err)
+		logging.CLILog.Warningf("call %s fail:%v,spool to disk", serviceMethod, err)
+	}
+	spoolMutex.Lock()
+	defer spoolMutex.Unlock()
+	if err := writeSpoolEntry(key, serviceMethod, args); err != nil {
+		logging.RuntimeLog.Errorf("spool %s fail:%v", serviceMethod, err)
+		return err
+	}
+	spoolPendingNumber++
+	switch r := reply.(type) {
+	case *string:
+		*r = SpooledResult
+	case *bool:
+		*r = true
+	}
+	return nil
+}
+
+// getSpoolPath 本地队列的目录
+func getSpoolPath() string {
+	return filepath.Join(conf.GetRootPath(), SpoolDir)
+}
+
+// writeSpoolEntry 将请求写入本地队列，先写临时文件再rename，避免写入中断时留下不完整的文件
+func writeSpoolEntry(key, serviceMethod string, args interface{}) error {
+	content, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	entry := spoolEntry{Key: key, Method: serviceMethod, Args: content, CreateTime: time.Now()}
+	if content, err = json.Marshal(entry); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(getSpoolPath(), 0700); err != nil {
+		return err
+	}
+	// 文件名以时间开头，按文件名排序即为请求的顺序
+	fileName := filepath.Join(getSpoolPath(), fmt.Sprintf("%019d-%s.json", time.Now().UnixNano(), key))
+	tmpFile := fileName + ".tmp"
+	f, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(content); err == nil {
+		err = f.Sync()
+	}
+	f.Close()
+	if err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return os.Rename(tmpFile, fileName)
+}
+
+// getSpoolFiles 本地队列中未发送的请求文件
+func getSpoolFiles() (files []string) {
+	entries, err := os.ReadDir(getSpoolPath())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, filepath.Join(getSpoolPath(), e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return
+}
+
+// replaySpool 按顺序重新发送本地队列中的请求，连接失败时停止，下次再继续；RPC调用时不锁定本地队列，新的请求可以继续写入
+func replaySpool() {
+	spoolReplayMutex.Lock()
+	defer spoolReplayMutex.Unlock()
+
+	spoolMutex.Lock()
+	files := getSpoolFiles()
+	spoolPendingNumber = len(files)
+	spoolMutex.Unlock()
+	for _, file := range files {
+		ok, sent := replaySpoolFile(file)
+		if !sent {
+			return
+		}
+		if ok {
+			os.Remove(file)
+		} else {
+			// 无效的以及server处理失败的请求保留在本地，不再重新发送
+			os.Rename(file, file+".failed")
+		}
+		spoolMutex.Lock()
+		spoolPendingNumber--
+		spoolMutex.Unlock()
+	}
+}
+
+// replaySpoolFile 重新发送一个请求：ok为server处理成功，sent为false时连接server失败
+func replaySpoolFile(file string) (ok bool, sent bool) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		return false, true
+	}
+	var entry spoolEntry
+	if err = json.Unmarshal(content, &entry); err != nil {
+		entry.Method = ""
+	}
+	newArgs, exist := spoolMethodArgs[entry.Method]
+	if !exist {
+		logging.RuntimeLog.Errorf("invalid spool file:%s", file)
+		return false, true
+	}
+	args, reply := newArgs()
+	if err = json.Unmarshal(entry.Args, args); err != nil {
+		logging.RuntimeLog.Errorf("invalid spool file:%s", file)
+		return false, true
+	}
+	if err = callXClientWithKey(entry.Method, entry.Key, args, reply); err != nil {
+		if !isServiceError(err) {
+			return false, false
+		}
+		logging.RuntimeLog.Errorf("replay %s fail:%v", entry.Method, err)
+		return false, true
+	}
+	logging.RuntimeLog.Infof("replay spooled %s created at %s", entry.Method, entry.CreateTime.Format("2006-01-02 15:04:05"))
+	return true, true
+}
+
+// StartSpoolReplay worker定期重新发送本地队列中未发送的结果及任务状态
+func StartSpoolReplay() {
+	for {
+		replaySpool()
+		time.Sleep(spoolReplayInterval)
+	}
+}
+
+// getIdempotencyKey 获取RPC请求中的幂等键
+func getIdempotencyKey(ctx context.Context) string {
+	if meta, ok := ctx.Value(share.ReqMetaDataKey).(map[string]string); ok {
+		return meta[idempotencyKeyName]
+	}
+	return ""
+}
+
+// getIdempotencyFilePath server保存已处理请求的文件
+func getIdempotencyFilePath() string {
+	return filepath.Join(getSpoolPath(), idempotencyFile)
+}
+
+// loadIdempotentReplies 从文件加载未过期的已处理请求，并重写文件去除过期的记录（需在idempotentRepliesMutex锁定后调用）
+func loadIdempotentReplies() {
+	f, err := os.Open(getIdempotencyFilePath())
+	if err != nil {
+		return
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record idempotentRecord
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Key == "" {
+			continue
+		}
+		if time.Now().After(record.UpdateTime.Add(idempotencyExpiredTime)) {
+			continue
+		}
+		idempotentReplies[record.Key] = idempotentReply{reply: record.Reply, updateTime: record.UpdateTime}
+	}
+	f.Close()
+	rewriteIdempotentReplies()
+}
+
+// rewriteIdempotentReplies 将内存中的已处理请求重写到文件（需在idempotentRepliesMutex锁定后调用）
+func rewriteIdempotentReplies() {
+	if err := os.MkdirAll(getSpoolPath(), 0700); err != nil {
+		logging.RuntimeLog.Error(err)
+		return
+	}
+	tmpFile := getIdempotencyFilePath() + ".tmp"
+	f, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		return
+	}
+	w := bufio.NewWriter(f)
+	for k, r := range idempotentReplies {
+		content, _ := json.Marshal(idempotentRecord{Key: k, Reply: r.reply, UpdateTime: r.updateTime})
+		w.Write(append(content, '\n'))
+	}
+	err = w.Flush()
+	f.Close()
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		os.Remove(tmpFile)
+		return
+	}
+	os.Rename(tmpFile, getIdempotencyFilePath())
+	idempotentRecordNumber = len(idempotentReplies)
+}
+
+// appendIdempotentReply 追加一条已处理请求到文件（需在idempotentRepliesMutex锁定后调用）
+func appendIdempotentReply(key string, r idempotentReply) {
+	if err := os.MkdirAll(getSpoolPath(), 0700); err != nil {
+		logging.RuntimeLog.Error(err)
+		return
+	}
+	f, err := os.OpenFile(getIdempotencyFilePath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		return
+	}
+	defer f.Close()
+	content, _ := json.Marshal(idempotentRecord{Key: key, Reply: r.reply, UpdateTime: r.updateTime})
+	if _, err = f.Write(append(content, '\n')); err != nil {
+		logging.RuntimeLog.Error(err)
+		return
+	}
+	idempotentRecordNumber++
+}
+
+// reserveIdempotentReply 请求已经处理过时返回上次的结果；否则预留请求的幂等键，相同的请求正在处理时等待其结束
+func reserveIdempotentReply(ctx context.Context, replay interface{}) bool {
+	key := getIdempotencyKey(ctx)
+	if key == "" {
+		return false
+	}
+	for {
+		idempotentRepliesMutex.Lock()
+		idempotentRepliesOnce.Do(loadIdempotentReplies)
+		if r, ok := idempotentReplies[key]; ok && json.Unmarshal(r.reply, replay) == nil {
+			idempotentRepliesMutex.Unlock()
+			logging.RuntimeLog.Infof("ignore duplicated request:%s", key)
+			return true
+		}
+		done, ok := idempotentPending[key]
+		if !ok {
+			idempotentPending[key] = make(chan struct{})
+			idempotentRepliesMutex.Unlock()
+			return false
+		}
+		idempotentRepliesMutex.Unlock()
+		<-done
+	}
+}
+
+// releaseIdempotentReply 请求处理结束时释放预留的幂等键，处理失败未保存结果时相同的请求可以重新处理
+func releaseIdempotentReply(ctx context.Context) {
+	key := getIdempotencyKey(ctx)
+	if key == "" {
+		return
+	}
+	idempotentRepliesMutex.Lock()
+	defer idempotentRepliesMutex.Unlock()
+
+	releaseIdempotentPending(key)
+}
+
+// releaseIdempotentPending 释放正在处理的请求（需在idempotentRepliesMutex锁定后调用）
+func releaseIdempotentPending(key string) {
+	if done, ok := idempotentPending[key]; ok {
+		delete(idempotentPending, key)
+		close(done)
+	}
+}
+
+// saveIdempotentReply 保存已处理请求的结果，并清除过期的记录
+func saveIdempotentReply(ctx context.Context, replay interface{}) {
+	key := getIdempotencyKey(ctx)
+	if key == "" {
+		return
+	}
+	content, err := json.Marshal(replay)
+	if err != nil {
+		return
+	}
+	idempotentRepliesMutex.Lock()
+	defer idempotentRepliesMutex.Unlock()
+
+	idempotentRepliesOnce.Do(loadIdempotentReplies)
+	for k, r := range idempotentReplies {
+		if time.Now().After(r.updateTime.Add(idempotencyExpiredTime)) {
+			delete(idempotentReplies, k)
+		}
+	}
+	r := idempotentReply{reply: content, updateTime: time.Now()}
+	idempotentReplies[key] = r
+	releaseIdempotentPending(key)
+	if idempotentRecordNumber > 2*len(idempotentReplies)+1000 {
+		rewriteIdempotentReplies()
+	} else {
+		appendIdempotentReply(key, r)
+	}
+}
@@ -0,0 +1,273 @@
+package comm
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/cert"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"github.com/smallnest/rpcx/client"
+	"github.com/smallnest/rpcx/server"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+type EnrollWorkerArgs struct {
+	Token      string
+	WorkerName string
+	CSR        []byte
+}
+
+type EnrollWorkerReply struct {
+	Cert   []byte
+	CACert []byte
+	// ReleasePublicKey 验证发布清单签名的公钥
+	ReleasePublicKey []byte
+}
+
+// enrollTokenExpiredTime 注册令牌的有效时间
+const enrollTokenExpiredTime = 24 * time.Hour
+
+var (
+	// WorkerCA server签发worker证书的CA
+	WorkerCA *cert.CA
+	// revokedWorkerCerts 已吊销的worker证书序列号
+	revokedWorkerCerts      = make(map[string]struct{})
+	revokedWorkerCertsMutex sync.RWMutex
+	// enrollTokens 未使用的注册令牌及过期时间
+	enrollTokens      = make(map[string]time.Time)
+	enrollTokensMutex sync.Mutex
+)
+
+// InitWorkerCA 加载或生成签发worker证书的CA，并加载已吊销的证书
+func InitWorkerCA() (err error) {
+	if WorkerCA, err = cert.LoadOrCreateCA(); err != nil {
+		return err
+	}
+	wc := db.WorkerCert{}
+	results, _ := wc.Gets(map[string]interface{}{"is_revoked": true}, -1, -1)
+	revokedWorkerCertsMutex.Lock()
+	for _, r := range results {
+		revokedWorkerCerts[r.SerialNumber] = struct{}{}
+	}
+	revokedWorkerCertsMutex.Unlock()
+
+	return nil
+}
+
+// CheckWorkerCert 检查worker证书是否已吊销（证书的签发者由TLS握手时验证）
+func CheckWorkerCert(c *x509.Certificate) error {
+	revokedWorkerCertsMutex.RLock()
+	defer revokedWorkerCertsMutex.RUnlock()
+
+	if _, ok := revokedWorkerCerts[cert.GetSerialNumber(c)]; ok {
+		return fmt.Errorf("worker cert %s has been revoked", c.Subject.CommonName)
+	}
+	return nil
+}
+
+// RevokeWorkerCert 吊销worker证书，吊销后worker不能再连接RPC及文件同步
+func RevokeWorkerCert(id int) bool {
+	wc := db.WorkerCert{Id: id}
+	if !wc.Get() || !wc.Revoke() {
+		return false
+	}
+	revokedWorkerCertsMutex.Lock()
+	revokedWorkerCerts[wc.SerialNumber] = struct{}{}
+	revokedWorkerCertsMutex.Unlock()
+	logging.RuntimeLog.Infof("revoke worker cert:%s,serial:%s", wc.WorkerName, wc.SerialNumber)
+
+	return true
+}
+
+// NewEnrollToken 生成一次性的worker注册令牌，令牌中包含server证书的指纹，worker注册时据此验证server
+func NewEnrollToken() (string, error) {
+	if !TLSEnabled || WorkerCA == nil {
+		return "", errors.New("server未启用TLS")
+	}
+	pin, err := cert.GetCertFileFingerprint(TLSCertFile)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	enrollTokensMutex.Lock()
+	defer enrollTokensMutex.Unlock()
+	for t, expired := range enrollTokens {
+		if time.Now().After(expired) {
+			delete(enrollTokens, t)
+		}
+	}
+	enrollTokens[token] = time.Now().Add(enrollTokenExpiredTime)
+
+	return fmt.Sprintf("%s#%s", token, pin), nil
+}
+
+// useEnrollToken 校验并使用注册令牌，令牌只能使用一次
+func useEnrollToken(token string) bool {
+	enrollTokensMutex.Lock()
+	defer enrollTokensMutex.Unlock()
+
+	expired, ok := enrollTokens[token]
+	if !ok {
+		return false
+	}
+	delete(enrollTokens, token)
+	return time.Now().Before(expired)
+}
+
+// getPeerCertificate 获取RPC连接中worker的证书
+func getPeerCertificate(ctx context.Context) *x509.Certificate {
+	conn, ok := ctx.Value(server.RemoteConnContextKey).(net.Conn)
+	if !ok {
+		return nil
+	}
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+		return certs[0]
+	}
+	return nil
+}
+
+// getWorkerIdentityName worker名称中的身份部分（“@”之前），已注册的worker为证书的CN
+func getWorkerIdentityName(workerName string) string {
+	name, _, _ := strings.Cut(workerName, "@")
+	return name
+}
+
+// checkWorkerIdentity 使用证书认证的worker，RPC请求中的worker名称必须与证书的CN一致，防止冒用其它worker
+func checkWorkerIdentity(ctx context.Context, workerName string) error {
+	c := getPeerCertificate(ctx)
+	if c == nil {
+		return nil
+	}
+	if getWorkerIdentityName(workerName) != c.Subject.CommonName {
+		logging.RuntimeLog.Warningf("worker name %s mismatch cert %s", workerName, c.Subject.CommonName)
+		return fmt.Errorf("worker name %s mismatch cert", workerName)
+	}
+	return nil
+}
+
+// checkDispatchedTask 检查任务正在由调用的worker执行（任务开始时worker已更新任务状态），返回数据库中的任务；
+// 任务的工作空间、主任务均以数据库为准，不使用worker提交的参数
+func checkDispatchedTask(ctx context.Context, taskId string) (*db.TaskRun, error) {
+	if taskId == "" {
+		return nil, errors.New("null taskId")
+	}
+	taskRun := db.TaskRun{TaskId: taskId}
+	if !taskRun.GetByTaskId() {
+		return nil, fmt.Errorf("task %s not exist", taskId)
+	}
+	if taskRun.State != ampq.STARTED || taskRun.Worker == "" {
+		return nil, fmt.Errorf("task %s not running", taskId)
+	}
+	if err := checkWorkerIdentity(ctx, taskRun.Worker); err != nil {
+		return nil, err
+	}
+	return &taskRun, nil
+}
+
+// hasWorkerLabel worker心跳上报的任务队列中包含该标签
+func hasWorkerLabel(workerName, workerLabel string) bool {
+	WorkerStatusMutex.Lock()
+	defer WorkerStatusMutex.Unlock()
+
+	ws, ok := WorkerStatus[workerName]
+	if !ok {
+		return false
+	}
+	return hasWorkerTopic(ws.WorkerTopics, ampq.GetTopicByWorkerLabel(workerLabel))
+}
+
+// getEnrolledWorkerName 已注册并启用TLS的worker使用证书的CN作为名称，未注册时返回空
+func getEnrolledWorkerName() string {
+	if !TLSEnabled {
+		return ""
+	}
+	if identity := cert.LoadWorkerIdentity(); identity != nil {
+		return identity.CommonName()
+	}
+	return ""
+}
+
+// EnrollWorker worker使用注册令牌申请证书
+func (s *Service) EnrollWorker(ctx context.Context, args *EnrollWorkerArgs, replay *EnrollWorkerReply) error {
+	if WorkerCA == nil {
+		return errors.New("worker CA not initialized")
+	}
+	// worker名称为“证书CN@IP#进程号”，CN中不能包含分隔符
+	if args.WorkerName == "" || len(args.WorkerName) > 100 || strings.ContainsAny(args.WorkerName, "@#") {
+		return errors.New("invalid worker name")
+	}
+	if !useEnrollToken(args.Token) {
+		logging.RuntimeLog.Warningf("invalid enroll token from worker:%s", args.WorkerName)
+		return errors.New("invalid enroll token")
+	}
+	c, err := WorkerCA.SignWorkerCSR(args.CSR, args.WorkerName, conf.GlobalServerConfig().WorkerCert.ValidDays)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		return err
+	}
+	wc := db.WorkerCert{
+		WorkerName:   args.WorkerName,
+		SerialNumber: cert.GetSerialNumber(c),
+		Fingerprint:  cert.Fingerprint(c.Raw),
+		NotAfter:     c.NotAfter,
+	}
+	if !wc.Add() {
+		return errors.New("save worker cert fail")
+	}
+	logging.RuntimeLog.Infof("enroll worker:%s,serial:%s", wc.WorkerName, wc.SerialNumber)
+	replay.Cert = cert.EncodeCertPEM(c)
+	replay.CACert = WorkerCA.CertPEM
+	replay.ReleasePublicKey = GetReleasePublicKey()
+
+	return nil
+}
+
+// DoEnrollWorker worker向server注册：生成私钥和证书请求，使用注册令牌中的server指纹建立TLS连接并申请证书
+func DoEnrollWorker(enrollToken, workerName string) error {
+	token, pin, found := strings.Cut(enrollToken, "#")
+	if !found || token == "" || pin == "" {
+		return errors.New("invalid enroll token")
+	}
+	keyPEM, csrPEM, err := cert.NewWorkerCSR(workerName)
+	if err != nil {
+		return err
+	}
+	host := conf.GlobalWorkerConfig().Rpc.Host
+	if conf.RunMode == conf.Debug || host == "0.0.0.0" {
+		host = "127.0.0.1"
+	}
+	option := client.DefaultOption
+	option.TLSConfig = cert.NewPinnedTLSConfig(pin)
+	d, _ := client.NewPeer2PeerDiscovery(fmt.Sprintf("tcp@%s:%d", host, conf.GlobalWorkerConfig().Rpc.Port), "")
+	xc := client.NewXClient("Service", client.Failfast, client.RandomSelect, d, option)
+	defer xc.Close()
+
+	args := EnrollWorkerArgs{Token: token, WorkerName: workerName, CSR: csrPEM}
+	var replay EnrollWorkerReply
+	if err = xc.Call(context.Background(), "EnrollWorker", &args, &replay); err != nil {
+		return err
+	}
+	if err = cert.SaveWorkerIdentity(replay.Cert, keyPEM, pin); err != nil {
+		return err
+	}
+	return cert.SaveReleasePublicKey(replay.ReleasePublicKey)
+}
@@ -0,0 +1,17 @@
+package comm
+
+import (
+	"testing"
+)
+
+func TestGetWorkerIdentityName(t *testing.T) {
+	for workerName, name := range map[string]string{
+		"worker01@192.168.1.10#1234": "worker01",
+		"worker01@192.168.1.10":      "worker01",
+		"worker01":                   "worker01",
+	} {
+		if n := getWorkerIdentityName(workerName); n != name {
+			t.Errorf("%s:unexpected identity name:%s", workerName, n)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"gopkg.in/yaml.v2"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 )
 
 const (
@@ -12,14 +13,48 @@ const (
 	Debug   = "Debug"   //开发模式
 )
 
+const (
+	BrokerRabbitmq = "rabbitmq"
+	BrokerRedis    = "redis"
+)
+
+const (
+	DatabaseMysql  = "mysql"
+	DatabaseSqlite = "sqlite"
+)
+
 const (
 	HighPerformance   = "High"
 	NormalPerformance = "Normal"
+	LowPerformance    = "Low"
 )
 
 // WorkerPerformanceMode worker默认的性能模式为Normal
 var WorkerPerformanceMode = NormalPerformance
 
+// workerLowResource worker资源不足（内存压力）时为1，任务的线程数降为Low性能模式
+var workerLowResource int32
+
+// SetWorkerLowResource 设置worker是否资源不足
+func SetWorkerLowResource(low bool) {
+	if low {
+		atomic.StoreInt32(&workerLowResource, 1)
+	} else {
+		atomic.StoreInt32(&workerLowResource, 0)
+	}
+}
+
+// GetWorkerPerformanceMode 获取worker当前的性能模式：资源不足时为Low，否则为启动时确定的性能模式
+func GetWorkerPerformanceMode() string {
+	if atomic.LoadInt32(&workerLowResource) == 1 {
+		return LowPerformance
+	}
+	return WorkerPerformanceMode
+}
+
+// AllInOneMode 单机all-in-one运行模式：在server进程内运行任务队列和worker，不需要RabbitMQ、RPC及独立的worker
+var AllInOneMode = false
+
 // RunMode 运行模式：正式运行请使用Release模式，Debug模式只用于开发调试过程
 var RunMode = Release
 
@@ -54,26 +89,34 @@ func GlobalWorkerConfig() *Worker {
 }
 
 type Server struct {
-	Web      Web               `yaml:"web"`
-	Rpc      RPC               `yaml:"rpc"`
-	FileSync RPC               `yaml:"fileSync"`
-	WebAPI   WebAPI            `yaml:"api"`
-	Database Database          `yaml:"database"`
-	Rabbitmq Rabbitmq          `yaml:"rabbitmq"`
-	Task     Task              `yaml:"task"`
-	Notify   map[string]Notify `yaml:"notify"`
+	Web        Web               `yaml:"web"`
+	Rpc        RPC               `yaml:"rpc"`
+	FileSync   RPC               `yaml:"fileSync"`
+	WebAPI     WebAPI            `yaml:"api"`
+	Database   Database          `yaml:"database"`
+	Broker     string            `yaml:"broker"`
+	Rabbitmq   Rabbitmq          `yaml:"rabbitmq"`
+	Redis      Redis             `yaml:"redis"`
+	WorkerCert WorkerCert        `yaml:"workerCert"`
+	Release    WorkerRelease     `yaml:"release"`
+	Task       Task              `yaml:"task"`
+	Notify     map[string]Notify `yaml:"notify"`
 }
 
 type Worker struct {
 	Rpc         RPC         `yaml:"rpc"`
 	FileSync    RPC         `yaml:"fileSync"`
+	Broker      string      `yaml:"broker"`
 	Rabbitmq    Rabbitmq    `yaml:"rabbitmq"`
+	Redis       Redis       `yaml:"redis"`
+	Labels      []string    `yaml:"labels"`
 	API         API         `yaml:"api"`
 	Portscan    Portscan    `yaml:"portscan"`
 	Fingerprint Fingerprint `yaml:"fingerprint"`
 	Domainscan  Domainscan  `yaml:"domainscan"`
 	OnlineAPI   OnlineAPI   `yaml:"onlineapi"`
 	Pocscan     Pocscan     `yaml:"pocscan"`
+	IPLocation  IPLocation  `yaml:"iplocation"`
 }
 
 type Web struct {
@@ -93,6 +136,7 @@ type RPC struct {
 }
 
 type Database struct {
+	Type     string `yaml:"type"`
 	Host     string `yaml:"host"`
 	Port     int    `yaml:"port"`
 	Dbname   string `yaml:"name"`
@@ -100,6 +144,17 @@ type Database struct {
 	Password string `yaml:"password"`
 }
 
+// GetType 获取数据库类型：未配置时默认为mysql，all-in-one模式默认为内置的sqlite
+func (d Database) GetType() string {
+	if d.Type == "" {
+		if AllInOneMode {
+			return DatabaseSqlite
+		}
+		return DatabaseMysql
+	}
+	return d.Type
+}
+
 type Rabbitmq struct {
 	Host     string `yaml:"host"`
 	Port     int    `yaml:"port"`
@@ -107,6 +162,26 @@ type Rabbitmq struct {
 	Password string `yaml:"password"`
 }
 
+type Redis struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// WorkerCert worker证书（双向TLS）的配置
+type WorkerCert struct {
+	// Required 为true时worker必须使用注册的证书连接RPC及文件同步，不再接受只使用authKey的认证
+	Required  bool `yaml:"required"`
+	ValidDays int  `yaml:"validDays"`
+}
+
+// WorkerRelease worker自动更新的配置：先更新具有CanaryLabel标签的worker，验证CanaryPeriod分钟后再更新其它worker
+type WorkerRelease struct {
+	CanaryLabel  string `yaml:"canaryLabel"`
+	CanaryPeriod int    `yaml:"canaryPeriod"`
+}
+
 type Task struct {
 	IpSliceNumber   int `yaml:"ipSliceNumber"`
 	PortSliceNumber int `yaml:"portSliceNumber"`
@@ -133,11 +208,18 @@ type Portscan struct {
 	Cmdbin string `yaml:"cmdbin"`
 }
 
+// IPLocation IP归属地的配置：Provider为按优先级排列的数据源（qqwry、ip2region、mmdb）
+type IPLocation struct {
+	Provider []string `yaml:"provider"`
+}
+
 type Fingerprint struct {
 	IsHttpx          bool `yaml:"httpx"`
 	IsScreenshot     bool `yaml:"screenshot"`
 	IsFingerprintHub bool `yaml:"fingerprinthub"`
 	IsIconHash       bool `yaml:"iconhash"`
+	IsLeak           bool `yaml:"leak"`
+	IsWaf            bool `yaml:"waf"`
 }
 
 type Pocscan struct {
@@ -155,17 +237,24 @@ type Pocscan struct {
 }
 
 type Domainscan struct {
-	Resolver           string `yaml:"resolver"`
-	Wordlist           string `yaml:"wordlist"`
-	ProviderConfig     string `yaml:"providerConfig"`
-	IsSubDomainFinder  bool   `yaml:"subfinder"`
-	IsSubDomainBrute   bool   `yaml:"subdomainBrute"`
-	IsSubdomainCrawler bool   `yaml:"subdomainCrawler"`
-	IsIgnoreCDN        bool   `yaml:"ignoreCDN"`
-	IsIgnoreOutofChina bool   `yaml:"ignoreOutofChina"`
-	IsPortScan         bool   `yaml:"portscan"`
-	IsWhois            bool   `yaml:"whois"`
-	IsICP              bool   `yaml:"icp"`
+	Resolver            string `yaml:"resolver"`
+	ResolverCheckDomain string `yaml:"resolverCheckDomain"`
+	Wordlist            string `yaml:"wordlist"`
+	BruteRate           int    `yaml:"bruteRate"`
+	ProviderConfig      string `yaml:"providerConfig"`
+	IsSubDomainFinder   bool   `yaml:"subfinder"`
+	IsSubDomainBrute    bool   `yaml:"subdomainBrute"`
+	IsSubdomainCrawler  bool   `yaml:"subdomainCrawler"`
+	IsPermutation       bool   `yaml:"permutation"`
+	IsTakeover          bool   `yaml:"takeover"`
+	IsZoneTransfer      bool   `yaml:"zonetransfer"`
+	IsJSAnalysis        bool   `yaml:"jsanalysis"`
+	IsCDNOrigin         bool   `yaml:"cdnorigin"`
+	IsIgnoreCDN         bool   `yaml:"ignoreCDN"`
+	IsIgnoreOutofChina  bool   `yaml:"ignoreOutofChina"`
+	IsPortScan          bool   `yaml:"portscan"`
+	IsWhois             bool   `yaml:"whois"`
+	IsICP               bool   `yaml:"icp"`
 }
 
 type OnlineAPI struct {
@@ -40,6 +40,18 @@ func GetDB() *gorm.DB {
 
 func getDB() *gorm.DB {
 	database := conf.GlobalServerConfig().Database
+	if database.GetType() == conf.DatabaseSqlite {
+		db, err := getSqliteDB(database)
+		if err != nil {
+			logging.RuntimeLog.Error(err.Error())
+			return nil
+		}
+		db.Logger = logger.Default.LogMode(logger.Silent)
+		// SQLite只允许一个写入连接
+		sqlDB, _ := db.DB()
+		sqlDB.SetMaxOpenConns(1)
+		return db
+	}
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		database.Username, database.Password, database.Host, database.Port, database.Dbname)
 
@@ -69,6 +69,21 @@ func (ip *Ip) GetByIp() (success bool) {
 	}
 }
 
+// GetsByIps 根据工作空间和IP批量查询记录
+func (ip *Ip) GetsByIps(ipNames []string) (results []Ip) {
+	if len(ipNames) == 0 {
+		return
+	}
+	db := GetDB()
+	defer CloseDB(db)
+
+	if ip.WorkspaceId > 0 {
+		db = db.Where("workspace_id", ip.WorkspaceId)
+	}
+	db.Where("ip in ?", ipNames).Find(&results)
+	return
+}
+
 // Update 更新指定ID的一条记录，列名和内容位于map中
 func (ip *Ip) Update(updateMap map[string]interface{}) (success bool) {
 	updateMap["update_datetime"] = time.Now()
@@ -206,6 +221,10 @@ func (ip *Ip) makeWhere(searchMap map[string]interface{}) *gorm.DB {
 				db = db.Where("id in (?)", dbPorts)
 				CloseDB(dbPorts)
 			}
+		case "asn", "country", "region", "city":
+			ipAttr := GetDB().Model(&IpAttr{}).Select("r_id").Where("tag", column).Where("content", value)
+			db = db.Where("id in (?)", ipAttr)
+			CloseDB(ipAttr)
 		case "ip_http":
 			http := GetDB().Model(&IpHttp{}).Select("r_id").Where("content like ?", fmt.Sprintf("%%%s%%", value))
 			port := GetDB().Model(&Port{}).Select("ip_id").Where("id in (?)", http)
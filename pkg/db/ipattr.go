@@ -36,6 +36,31 @@ func (ipAttr *IpAttr) Add() (success bool) {
 	}
 }
 
+// GetByIpAttr 根据IP和属性查询一条记录
+func (ipAttr *IpAttr) GetByIpAttr() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+
+	hash := utils.MD5(fmt.Sprintf("%d%s%s%s", ipAttr.RelatedId, ipAttr.Source, ipAttr.Tag, ipAttr.Content))
+	if result := db.Where("hash", hash).First(ipAttr); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// GetByRelatedIdAndTag 根据IP、tag查询一条记录
+func (ipAttr *IpAttr) GetByRelatedIdAndTag() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+
+	if result := db.Where("r_id", ipAttr.RelatedId).Where("tag", ipAttr.Tag).First(ipAttr); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
 // Get 查询指定主键ID的一条记录
 func (ipAttr *IpAttr) Get() (success bool) {
 	db := GetDB()
@@ -72,6 +97,18 @@ func (ipAttr *IpAttr) GetsByRelatedId() (results []IpAttr) {
 	return ipAttr.Gets(searchMap, 0, 0)
 }
 
+// GetsDistinctContentByIp 获取满足IP查询条件的IP的指定属性的不重复内容
+func (ipAttr *IpAttr) GetsDistinctContentByIp(tag string, ipSearchMap map[string]interface{}) (contents []string) {
+	ip := &Ip{}
+	ipIds := ip.makeWhere(ipSearchMap).Model(ip).Select("id")
+	defer CloseDB(ipIds)
+
+	db := GetDB()
+	defer CloseDB(db)
+	db.Model(ipAttr).Distinct("content").Where("tag", tag).Where("r_id in (?)", ipIds).Order("content").Pluck("content", &contents)
+	return
+}
+
 // Update 更新指定ID的一条记录，列名和内容位于map中
 func (ipAttr *IpAttr) Update(updatedMap map[string]interface{}) (success bool) {
 	updatedMap["update_datetime"] = time.Now()
@@ -98,3 +135,45 @@ func (ipAttr *IpAttr) Delete() (success bool) {
 		return false
 	}
 }
+
+// ipAttrSingleValueTags 每个IP只保留一个值的属性
+var ipAttrSingleValueTags = map[string]struct{}{
+	"asn":       {},
+	"as_name":   {},
+	"as_prefix": {},
+	"country":   {},
+	"region":    {},
+	"city":      {},
+	"honeypot":  {},
+}
+
+// SaveOrUpdate 保存、更新一条记录
+func (ipAttr *IpAttr) SaveOrUpdate() (success bool) {
+	if ipAttr.GetByIpAttr() {
+		return ipAttr.Update(map[string]interface{}{})
+	} else {
+		return ipAttr.Add()
+	}
+}
+
+// SaveOrUpdateByTag 保存、更新一条记录，每个IP的同一tag只保留一条记录（删除该tag其它的旧记录）
+func (ipAttr *IpAttr) SaveOrUpdateByTag() (success bool) {
+	oldRecord := &IpAttr{RelatedId: ipAttr.RelatedId, Tag: ipAttr.Tag}
+	if !oldRecord.GetByRelatedIdAndTag() {
+		return ipAttr.Add()
+	}
+	ipAttr.Id = oldRecord.Id
+	// 先删除该tag其它的旧记录，避免更新后的hash与旧记录冲突
+	db := GetDB()
+	defer CloseDB(db)
+	db.Where("r_id", ipAttr.RelatedId).Where("tag", ipAttr.Tag).Where("id <> ?", ipAttr.Id).Delete(&IpAttr{})
+	// hash的计算与Add保持一致
+	ipAttr.Hash = utils.MD5(fmt.Sprintf("%d%s%s%s", ipAttr.RelatedId, ipAttr.Source, ipAttr.Tag, ipAttr.Content))
+	return ipAttr.Update(map[string]interface{}{"source": ipAttr.Source, "content": ipAttr.Content, "hash": ipAttr.Hash})
+}
+
+// IsSingleValueTag IP的属性是否只保留一个值（如ASN、归属地），变化时更新原记录而不是增加新记录
+func (ipAttr *IpAttr) IsSingleValueTag() bool {
+	_, ok := ipAttrSingleValueTags[ipAttr.Tag]
+	return ok
+}
@@ -51,6 +51,17 @@ func (port *Port) GetsByIPId() (results []Port) {
 	return
 }
 
+// GetsByIPIds 根据多个ip_id批量返回端口记录
+func (port *Port) GetsByIPIds(ipIds []int) (results []Port) {
+	if len(ipIds) == 0 {
+		return
+	}
+	db := GetDB()
+	defer CloseDB(db)
+	db.Order("ip_id,port").Where("ip_id in ?", ipIds).Find(&results)
+	return
+}
+
 // Update 更新指定ID的一条记录，列名和内容位于map中
 func (port *Port) Update(updatedMap map[string]interface{}) (success bool) {
 	updatedMap["update_datetime"] = time.Now()
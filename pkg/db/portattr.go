@@ -59,6 +59,40 @@ func (portAttr *PortAttr) GetsByRelatedId() (results []PortAttr) {
 	return
 }
 
+// GetsByRelatedIds 根据多个端口ID及属性标签批量查询记录
+func (portAttr *PortAttr) GetsByRelatedIds(relatedIds []int, tags []string) (results []PortAttr) {
+	if len(relatedIds) == 0 {
+		return
+	}
+	orderBy := "tag,update_datetime desc"
+
+	db := GetDB()
+	defer CloseDB(db)
+	db.Where("r_id in ?", relatedIds).Where("tag in ?", tags).Order(orderBy).Find(&results)
+	return
+}
+
+// GetsIpByTlsDataName 获取工作空间中TLS证书（tlsdata属性）包含指定域名的IP
+func (portAttr *PortAttr) GetsIpByTlsDataName(workspaceId int, names []string) (ips []string) {
+	if len(names) == 0 {
+		return
+	}
+	nameCondition := GetDB()
+	for _, name := range names {
+		nameCondition = nameCondition.Or("content like ?", fmt.Sprintf("%%\"%s\"%%", name))
+	}
+	dbAttr := GetDB().Model(portAttr).Select("r_id").Where("tag", "tlsdata").Where(nameCondition)
+	dbPort := GetDB().Model(&Port{}).Select("ip_id").Where("id in (?)", dbAttr)
+	db := GetDB()
+	defer CloseDB(db)
+
+	db.Model(&Ip{}).Distinct("ip").Where("workspace_id", workspaceId).Where("id in (?)", dbPort).Pluck("ip", &ips)
+	CloseDB(nameCondition)
+	CloseDB(dbAttr)
+	CloseDB(dbPort)
+	return
+}
+
 // Update 更新指定ID的一条记录，列名和内容位于map中
 func (portAttr *PortAttr) Update(updateMap map[string]interface{}) (success bool) {
 	updateMap["update_datetime"] = time.Now()
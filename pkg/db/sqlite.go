@@ -0,0 +1,304 @@
+package db
+
+import (
+	"fmt"
+	"github.com/glebarez/sqlite"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"gorm.io/gorm"
+	"path/filepath"
+)
+
+// sqliteSchema 内置SQLite数据库的表结构及默认的用户和工作空间，与docker/mysql/initdb.d/nemo.sql保持一致
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS workspace (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_name varchar(100) NOT NULL,
+  workspace_guid char(36) NOT NULL UNIQUE,
+  workspace_description varchar(200) DEFAULT NULL,
+  state varchar(20) NOT NULL,
+  sort_order int NOT NULL,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE TABLE IF NOT EXISTS user (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_name varchar(100) NOT NULL,
+  user_password char(48) NOT NULL,
+  user_description varchar(200) DEFAULT NULL,
+  user_role varchar(40) NOT NULL,
+  state varchar(40) NOT NULL,
+  sort_order int NOT NULL,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE TABLE IF NOT EXISTS user_workspace (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id int NOT NULL REFERENCES user (id) ON DELETE CASCADE,
+  workspace_id int NOT NULL REFERENCES workspace (id) ON DELETE CASCADE,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fk_userid ON user_workspace (user_id);
+CREATE INDEX IF NOT EXISTS fk_workspaceid ON user_workspace (workspace_id);
+CREATE TABLE IF NOT EXISTS organization (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  org_name varchar(200) NOT NULL,
+  status varchar(20) NOT NULL,
+  sort_order int NOT NULL DEFAULT 100,
+  workspace_id int NOT NULL REFERENCES workspace (id) ON DELETE CASCADE,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fk_org_workspace_id ON organization (workspace_id);
+CREATE TABLE IF NOT EXISTS ip (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  ip varchar(128) NOT NULL,
+  ip_int bigint NOT NULL,
+  org_id int DEFAULT NULL REFERENCES organization (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  location varchar(200) DEFAULT NULL,
+  status varchar(20) DEFAULT NULL,
+  workspace_id int NOT NULL REFERENCES workspace (id) ON DELETE CASCADE,
+  pin_index int NOT NULL DEFAULT 0,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS index_ip_org_id ON ip (org_id);
+CREATE INDEX IF NOT EXISTS fk_ip_workspace_id ON ip (workspace_id);
+CREATE TABLE IF NOT EXISTS ip_attr (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  r_id int NOT NULL REFERENCES ip (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  source varchar(40) DEFAULT NULL,
+  tag varchar(40) NOT NULL,
+  content varchar(4000) DEFAULT NULL,
+  hash char(32) DEFAULT NULL UNIQUE,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS index_ip_attr_ip_id ON ip_attr (r_id);
+CREATE TABLE IF NOT EXISTS ip_color_tag (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  r_id int NOT NULL UNIQUE REFERENCES ip (id) ON DELETE CASCADE,
+  color char(20) NOT NULL,
+  create_datetime datetime DEFAULT NULL,
+  update_datetime datetime DEFAULT NULL
+);
+CREATE TABLE IF NOT EXISTS ip_memo (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  r_id int NOT NULL UNIQUE REFERENCES ip (id) ON DELETE CASCADE,
+  content varchar(10000) DEFAULT NULL,
+  create_datetime datetime DEFAULT NULL,
+  update_datetime datetime DEFAULT NULL
+);
+CREATE TABLE IF NOT EXISTS port (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  ip_id int NOT NULL REFERENCES ip (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  port int NOT NULL,
+  status varchar(20) NOT NULL,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL,
+  UNIQUE (ip_id, port)
+);
+CREATE TABLE IF NOT EXISTS port_attr (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  r_id int NOT NULL REFERENCES port (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  source varchar(40) DEFAULT NULL,
+  tag varchar(40) NOT NULL,
+  content varchar(4000) DEFAULT NULL,
+  hash char(32) DEFAULT NULL UNIQUE,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fk_port_attr_r_id ON port_attr (r_id);
+CREATE TABLE IF NOT EXISTS ip_http (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  r_id int NOT NULL REFERENCES port (id) ON DELETE CASCADE,
+  source varchar(40) NOT NULL,
+  tag varchar(40) NOT NULL,
+  content varchar(16000) NOT NULL,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fk_ip_http_rid ON ip_http (r_id);
+CREATE TABLE IF NOT EXISTS domain (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  domain varchar(100) NOT NULL,
+  org_id int DEFAULT NULL REFERENCES organization (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  workspace_id int NOT NULL REFERENCES workspace (id) ON DELETE CASCADE,
+  pin_index int NOT NULL DEFAULT 0,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fk_domain_org_id ON domain (org_id);
+CREATE INDEX IF NOT EXISTS fk_domain_workspace_id ON domain (workspace_id);
+CREATE TABLE IF NOT EXISTS domain_attr (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  r_id int NOT NULL REFERENCES domain (id) ON DELETE CASCADE ON UPDATE CASCADE,
+  source varchar(40) DEFAULT NULL,
+  tag varchar(40) NOT NULL,
+  content varchar(4000) DEFAULT NULL,
+  hash char(32) DEFAULT NULL UNIQUE,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS index_domain_attr_ip_id ON domain_attr (r_id);
+CREATE TABLE IF NOT EXISTS domain_color_tag (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  r_id int NOT NULL UNIQUE REFERENCES domain (id) ON DELETE CASCADE,
+  color char(20) NOT NULL,
+  create_datetime datetime DEFAULT NULL,
+  update_datetime datetime DEFAULT NULL
+);
+CREATE TABLE IF NOT EXISTS domain_memo (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  r_id int NOT NULL UNIQUE REFERENCES domain (id) ON DELETE CASCADE,
+  content varchar(10000) DEFAULT NULL,
+  create_datetime datetime DEFAULT NULL,
+  update_datetime datetime DEFAULT NULL
+);
+CREATE TABLE IF NOT EXISTS domain_http (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  r_id int NOT NULL REFERENCES domain (id) ON DELETE CASCADE,
+  port int NOT NULL,
+  source varchar(40) NOT NULL,
+  tag varchar(40) NOT NULL,
+  content varchar(16000) NOT NULL,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fk_domain_http_rid ON domain_http (r_id);
+CREATE TABLE IF NOT EXISTS key_word (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  org_id int NOT NULL,
+  key_word varchar(511) NOT NULL,
+  engine varchar(40) NOT NULL DEFAULT '',
+  search_time varchar(63) DEFAULT NULL,
+  exclude_words varchar(2047) DEFAULT NULL,
+  check_mod varchar(255) DEFAULT NULL,
+  is_delete tinyint NOT NULL DEFAULT 0,
+  count int DEFAULT NULL,
+  workspace_id int NOT NULL REFERENCES workspace (id) ON DELETE CASCADE,
+  create_datetime datetime DEFAULT NULL,
+  update_datetime datetime DEFAULT NULL
+);
+CREATE INDEX IF NOT EXISTS fk_key_word_workspace_id ON key_word (workspace_id);
+CREATE TABLE IF NOT EXISTS vulnerability (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  target varchar(100) NOT NULL,
+  url varchar(200) NOT NULL,
+  poc_file varchar(200) NOT NULL,
+  source varchar(40) NOT NULL,
+  extra varchar(4000) DEFAULT NULL,
+  hash char(32) NOT NULL,
+  workspace_id int NOT NULL REFERENCES workspace (id) ON DELETE CASCADE,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fk_vul_workspace_id ON vulnerability (workspace_id);
+CREATE TABLE IF NOT EXISTS task_cron (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id char(36) NOT NULL,
+  task_name varchar(100) NOT NULL,
+  kwargs varchar(8000) DEFAULT NULL,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL,
+  cron_rule varchar(200) NOT NULL,
+  lastrun_datetime datetime DEFAULT NULL,
+  status varchar(10) NOT NULL,
+  run_count int DEFAULT NULL,
+  comment varchar(200) DEFAULT NULL,
+  workspace_id int NOT NULL REFERENCES workspace (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS fk_task_cron_workspace_id ON task_cron (workspace_id);
+CREATE TABLE IF NOT EXISTS task_main (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id char(36) NOT NULL,
+  task_name varchar(100) NOT NULL,
+  kwargs varchar(8000) DEFAULT NULL,
+  state varchar(40) NOT NULL,
+  result varchar(4000) DEFAULT NULL,
+  received datetime NOT NULL,
+  started datetime DEFAULT NULL,
+  succeeded datetime DEFAULT NULL,
+  progress_message varchar(100) DEFAULT NULL,
+  cron_id char(36) DEFAULT NULL,
+  workspace_id int NOT NULL REFERENCES workspace (id) ON DELETE CASCADE,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS fk_task_main_workspace_id ON task_main (workspace_id);
+CREATE TABLE IF NOT EXISTS task_run (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id char(36) NOT NULL,
+  task_name varchar(100) NOT NULL,
+  kwargs varchar(8000) DEFAULT NULL,
+  worker varchar(100) DEFAULT NULL,
+  state varchar(40) NOT NULL,
+  result varchar(4000) DEFAULT NULL,
+  received datetime DEFAULT NULL,
+  retried datetime DEFAULT NULL,
+  revoked datetime DEFAULT NULL,
+  started datetime DEFAULT NULL,
+  succeeded datetime DEFAULT NULL,
+  failed datetime DEFAULT NULL,
+  progress_message varchar(100) DEFAULT NULL,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL,
+  main_id char(36) DEFAULT NULL,
+  last_run_id char(36) DEFAULT NULL,
+  workspace_id int NOT NULL REFERENCES workspace (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS fk_task_run_workspace_id ON task_run (workspace_id);
+CREATE TABLE IF NOT EXISTS runtimelog (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  source varchar(40) NOT NULL,
+  file varchar(80) DEFAULT NULL,
+  func varchar(80) DEFAULT NULL,
+  level varchar(20) NOT NULL,
+  level_int int NOT NULL,
+  message varchar(1000) NOT NULL,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE TABLE IF NOT EXISTS worker_cert (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  worker_name varchar(100) NOT NULL,
+  serial_number varchar(100) NOT NULL UNIQUE,
+  fingerprint char(64) NOT NULL,
+  not_after datetime NOT NULL,
+  is_revoked tinyint NOT NULL DEFAULT 0,
+  revoke_datetime datetime DEFAULT NULL,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL
+);
+CREATE TABLE IF NOT EXISTS worker_secret (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  secret_name varchar(50) NOT NULL,
+  scope_type varchar(20) NOT NULL,
+  scope_value varchar(100) NOT NULL DEFAULT '',
+  secret_value text NOT NULL,
+  description varchar(200) DEFAULT NULL,
+  create_datetime datetime NOT NULL,
+  update_datetime datetime NOT NULL,
+  UNIQUE (secret_name, scope_type, scope_value)
+);
+INSERT OR IGNORE INTO workspace VALUES (1,'默认','b0c79065-7ff7-32ae-cc18-864ccd8f7717','默认工作空间','enable',100,'2023-02-26 11:40:00','2023-02-26 11:40:05');
+INSERT OR IGNORE INTO user VALUES (1,'nemo','648ce596dba3b408b523d3d1189b15070123456789abcdef','默认超级管理员','superadmin','enable',100,'2023-02-26 11:43:20','2023-03-02 15:40:23');
+INSERT OR IGNORE INTO user_workspace VALUES (1,1,1,'2023-03-01 23:05:39','2023-03-01 23:05:39');
+`
+
+// getSqliteDB 打开内置的SQLite数据库，数据库文件不存在时创建并初始化表结构
+func getSqliteDB(database conf.Database) (*gorm.DB, error) {
+	dbFile := filepath.Join(conf.GetRootPath(), fmt.Sprintf("%s.db", database.Dbname))
+	// 启用外键以级联删除关联的记录，写入冲突时等待
+	dsn := fmt.Sprintf("%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)", dbFile)
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Exec(sqliteSchema).Error; err != nil {
+		return nil, err
+	}
+	logging.RuntimeLog.Infof("use sqlite database:%s", dbFile)
+	return db, nil
+}
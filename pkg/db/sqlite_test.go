@@ -0,0 +1,49 @@
+package db
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetSqliteDB(t *testing.T) {
+	database := conf.Database{Type: conf.DatabaseSqlite, Dbname: "nemo_test"}
+	dbFile := filepath.Join(conf.GetRootPath(), "nemo_test.db")
+	defer func() {
+		for _, f := range []string{dbFile, dbFile + "-wal", dbFile + "-shm"} {
+			os.Remove(f)
+		}
+	}()
+
+	db, err := getSqliteDB(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var user User
+	if result := db.Where("user_name", "nemo").First(&user); result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	t.Log(user.Id, user.UserName, user.UserRole, user.CreateDatetime)
+	// 再次打开时不重复初始化
+	if db, err = getSqliteDB(database); err != nil {
+		t.Fatal(err)
+	}
+	globalDB = db
+	defer func() { globalDB = nil }()
+
+	ip := Ip{IpName: "192.168.1.1", WorkspaceId: 1, Location: "内网"}
+	if !ip.Add() || !ip.GetByIp() {
+		t.Fatal("add ip fail")
+	}
+	ipAttr := IpAttr{RelatedId: ip.Id, Source: "test", Tag: "title", Content: "nemo"}
+	if !ipAttr.Add() {
+		t.Fatal("add ip attr fail")
+	}
+	t.Log(ip.Id, ip.IpName, ip.Location, ip.CreateDatetime)
+	// 删除IP时级联删除属性
+	ip.Delete()
+	if len(ipAttr.GetsByRelatedId()) != 0 {
+		t.Error("ip attr not deleted")
+	}
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"time"
+)
+
+type WorkerCert struct {
+	Id             int        `gorm:"primaryKey"`
+	WorkerName     string     `gorm:"column:worker_name"`
+	SerialNumber   string     `gorm:"column:serial_number"`
+	Fingerprint    string     `gorm:"column:fingerprint"`
+	NotAfter       time.Time  `gorm:"column:not_after"`
+	IsRevoked      bool       `gorm:"column:is_revoked"`
+	RevokeDatetime *time.Time `gorm:"column:revoke_datetime"`
+	CreateDatetime time.Time  `gorm:"column:create_datetime"`
+	UpdateDatetime time.Time  `gorm:"column:update_datetime"`
+}
+
+func (*WorkerCert) TableName() string {
+	return "worker_cert"
+}
+
+// Get 根据ID查询记录
+func (w *WorkerCert) Get() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+
+	if result := db.First(w, w.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// GetBySerialNumber 根据证书序列号查询记录
+func (w *WorkerCert) GetBySerialNumber() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+
+	if result := db.Where("serial_number", w.SerialNumber).First(w); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Gets 根据指定的条件，查询满足要求的记录
+func (w *WorkerCert) Gets(searchMap map[string]interface{}, page, rowsPerPage int) (results []WorkerCert, count int) {
+	orderBy := "is_revoked,create_datetime desc"
+
+	db := GetDB()
+	defer CloseDB(db)
+	for column, value := range searchMap {
+		db = db.Where(column, value)
+	}
+	db = db.Model(w)
+	//统计满足条件的总记录数
+	var total int64
+	db.Count(&total)
+	//获取分页查询结果
+	if rowsPerPage > 0 && page > 0 {
+		db = db.Offset((page - 1) * rowsPerPage).Limit(rowsPerPage)
+	}
+	db.Order(orderBy).Find(&results)
+	return results, int(total)
+}
+
+// Add 插入一条新的记录，返回主键ID及成功标志
+func (w *WorkerCert) Add() (success bool) {
+	w.CreateDatetime = time.Now()
+	w.UpdateDatetime = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Create(w); result.RowsAffected == 1 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Revoke 吊销指定ID的证书
+func (w *WorkerCert) Revoke() (success bool) {
+	now := time.Now()
+	updatedMap := map[string]interface{}{
+		"is_revoked":      true,
+		"revoke_datetime": now,
+		"update_datetime": now,
+	}
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Model(w).Updates(updatedMap); result.RowsAffected == 1 {
+		return true
+	} else {
+		return false
+	}
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"time"
+)
+
+type WorkerSecret struct {
+	Id             int       `gorm:"primaryKey"`
+	SecretName     string    `gorm:"column:secret_name"`
+	ScopeType      string    `gorm:"column:scope_type"`
+	ScopeValue     string    `gorm:"column:scope_value"`
+	SecretValue    string    `gorm:"column:secret_value"`
+	Description    string    `gorm:"column:description"`
+	CreateDatetime time.Time `gorm:"column:create_datetime"`
+	UpdateDatetime time.Time `gorm:"column:update_datetime"`
+}
+
+func (*WorkerSecret) TableName() string {
+	return "worker_secret"
+}
+
+// Get 根据ID查询记录
+func (w *WorkerSecret) Get() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+
+	if result := db.First(w, w.Id); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// GetByScope 根据密钥名称及作用范围查询记录
+func (w *WorkerSecret) GetByScope() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+
+	if result := db.Where("secret_name", w.SecretName).Where("scope_type", w.ScopeType).Where("scope_value", w.ScopeValue).First(w); result.RowsAffected > 0 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Gets 根据指定的条件，查询满足要求的记录
+func (w *WorkerSecret) Gets(searchMap map[string]interface{}, page, rowsPerPage int) (results []WorkerSecret, count int) {
+	orderBy := "secret_name,scope_type,scope_value"
+
+	db := GetDB()
+	defer CloseDB(db)
+	for column, value := range searchMap {
+		db = db.Where(column, value)
+	}
+	db = db.Model(w)
+	//统计满足条件的总记录数
+	var total int64
+	db.Count(&total)
+	//获取分页查询结果
+	if rowsPerPage > 0 && page > 0 {
+		db = db.Offset((page - 1) * rowsPerPage).Limit(rowsPerPage)
+	}
+	db.Order(orderBy).Find(&results)
+	return results, int(total)
+}
+
+// Add 插入一条新的记录，返回主键ID及成功标志
+func (w *WorkerSecret) Add() (success bool) {
+	w.CreateDatetime = time.Now()
+	w.UpdateDatetime = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Create(w); result.RowsAffected == 1 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Update 更新指定ID的一条记录，列名和内容位于map中
+func (w *WorkerSecret) Update(updatedMap map[string]interface{}) (success bool) {
+	updatedMap["update_datetime"] = time.Now()
+
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Model(w).Updates(updatedMap); result.RowsAffected == 1 {
+		return true
+	} else {
+		return false
+	}
+}
+
+// Delete 删除指定主键ID的一条记录
+func (w *WorkerSecret) Delete() (success bool) {
+	db := GetDB()
+	defer CloseDB(db)
+	if result := db.Delete(w, w.Id); result.RowsAffected == 1 {
+		return true
+	} else {
+		return false
+	}
+}
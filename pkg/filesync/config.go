@@ -1,6 +1,8 @@
 package filesync
 
 import (
+	"crypto/x509"
+	"github.com/hanc00l/nemo_go/pkg/cert"
 	"strings"
 )
 
@@ -10,11 +12,30 @@ var syncFileList = []string{"worker_linux_amd64", "version.txt", "conf", "thirdp
 // syncFileBlackList 不需要、禁止同步的文件黑名单
 var syncFileBlackList = []string{"conf/server.yml", "conf/app.conf"}
 
+const (
+	// syncParallelNumber 并行同步文件的连接数
+	syncParallelNumber = 4
+	// syncRetryNumber 同步一个文件失败后的重试次数
+	syncRetryNumber = 3
+	// syncPartFileSuffix 传输中断时保留的已接收数据的文件后缀
+	syncPartFileSuffix = ".nemosync"
+	// StagedFileSuffix 需要验证发布清单后才能替换的文件，同步时先保存为暂存文件
+	StagedFileSuffix = ".staged"
+)
+
+// StagedFileList 由daemon验证发布清单签名后再替换的文件（正在运行的worker）
+var StagedFileList = []string{"worker_linux_amd64"}
+
 var (
 	// TLSEnabled 是否启用TLS加密
 	TLSEnabled  bool
 	TLSCertFile string
 	TLSKeyFile  string
+	// WorkerCA 验证worker证书的CA，CheckWorkerCert检查worker证书是否已吊销
+	WorkerCA        *cert.CA
+	CheckWorkerCert func(*x509.Certificate) error
+	// WorkerCertRequired worker必须使用证书进行文件同步
+	WorkerCertRequired bool
 )
 
 // checkFileIsSyncWhileList 同步文件的白名单校验
@@ -44,3 +65,13 @@ func checkFileIsMonitorWhileList(filePathName string) bool {
 	}
 	return checkFileIsSyncWhileList(filePathName)
 }
+
+// getStagedFileName 文件同步时实际写入的文件：需要验证的文件写入暂存文件
+func getStagedFileName(filePathName string) string {
+	for _, f := range StagedFileList {
+		if filePathName == f {
+			return filePathName + StagedFileSuffix
+		}
+	}
+	return filePathName
+}
@@ -0,0 +1,289 @@
+package filesync
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+)
+
+const (
+	// deltaBlockSize 差量同步的数据块大小
+	deltaBlockSize = 32 * 1024
+	// deltaLiteralMaxSize 一个消息中新增数据的最大长度
+	deltaLiteralMaxSize = 512 * 1024
+	// deltaWindowSize 计算差量时每次读取文件的窗口大小
+	deltaWindowSize = 8 * deltaBlockSize
+	// rollingModulus 弱校验和的模
+	rollingModulus = 1 << 16
+)
+
+// BlockSignature worker本地数据块的签名：弱校验和用于滚动匹配，强校验（SHA256）用于确认块的内容
+type BlockSignature struct {
+	Weak   uint32
+	Strong [sha256.Size]byte
+}
+
+// DeltaOp 差量同步的操作：BlockIndex>=0时复制worker本地的数据块，否则写入Data（可能经过压缩）
+type DeltaOp struct {
+	BlockIndex int
+	Data       []byte
+	Compressed bool
+}
+
+// rollingChecksum rsync的滚动校验和
+type rollingChecksum struct {
+	a, b   uint32
+	length uint32
+}
+
+// newRollingChecksum 计算一个数据块的校验和
+func newRollingChecksum(block []byte) *rollingChecksum {
+	r := &rollingChecksum{length: uint32(len(block))}
+	for i, c := range block {
+		r.a += uint32(c)
+		r.b += uint32(len(block)-i) * uint32(c)
+	}
+	r.a %= rollingModulus
+	r.b %= rollingModulus
+	return r
+}
+
+// roll 窗口向后移动一个字节
+func (r *rollingChecksum) roll(out, in byte) {
+	r.a = (r.a + rollingModulus - uint32(out) + uint32(in)) % rollingModulus
+	r.b = (r.b + rollingModulus*r.length - r.length*uint32(out) + r.a) % rollingModulus
+}
+
+func (r *rollingChecksum) sum() uint32 {
+	return r.b<<16 | r.a
+}
+
+// blockSource worker本地可复用的数据块的位置
+type blockSource struct {
+	file   string
+	offset int64
+	length int
+}
+
+// basisBlocks worker本地可复用的数据块（原文件及上次中断的临时文件），按内容寻址
+type basisBlocks struct {
+	signatures []BlockSignature
+	sources    []blockSource
+	seen       map[[sha256.Size]byte]struct{}
+}
+
+// addFile 将文件的全部数据块加入到可复用的数据块中
+func (b *basisBlocks) addFile(fileName string) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if b.seen == nil {
+		b.seen = make(map[[sha256.Size]byte]struct{})
+	}
+	buf := make([]byte, deltaBlockSize)
+	var offset int64
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			strong := sha256.Sum256(buf[:n])
+			// 相同内容的块只需要一个
+			if _, ok := b.seen[strong]; !ok {
+				b.seen[strong] = struct{}{}
+				b.signatures = append(b.signatures, BlockSignature{Weak: newRollingChecksum(buf[:n]).sum(), Strong: strong})
+				b.sources = append(b.sources, blockSource{file: fileName, offset: offset, length: n})
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readBlock 读取一个可复用的数据块
+func (b *basisBlocks) readBlock(index int) ([]byte, error) {
+	if index < 0 || index >= len(b.sources) {
+		return nil, errors.New("invalid block index")
+	}
+	src := b.sources[index]
+	f, err := os.Open(src.file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, src.length)
+	if _, err = f.ReadAt(buf, src.offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// deltaWindow 流式读取文件的滑动窗口，计算差量时不需要将整个文件读入内存
+type deltaWindow struct {
+	r   io.Reader
+	buf []byte
+	pos int
+	eof bool
+}
+
+// fill 窗口中剩余的数据不超过一个块时继续读取，保证至少有一个块加一个字节（文件结束时除外）
+func (w *deltaWindow) fill() error {
+	if w.eof || len(w.buf)-w.pos > deltaBlockSize {
+		return nil
+	}
+	if w.buf == nil {
+		w.buf = make([]byte, 0, deltaWindowSize)
+	}
+	n := copy(w.buf[:cap(w.buf)], w.buf[w.pos:])
+	w.buf = w.buf[:n]
+	w.pos = 0
+	for len(w.buf) < cap(w.buf) {
+		n, err := w.r.Read(w.buf[len(w.buf):cap(w.buf)])
+		w.buf = w.buf[:len(w.buf)+n]
+		if err == io.EOF {
+			w.eof = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// computeDelta 根据worker的数据块签名生成差量操作，emit每次发送一批操作
+func computeDelta(r io.Reader, signatures []BlockSignature, emit func(ops []DeltaOp) error) error {
+	weakMap := make(map[uint32][]int)
+	for i, s := range signatures {
+		weakMap[s.Weak] = append(weakMap[s.Weak], i)
+	}
+	var ops []DeltaOp
+	var literal []byte
+	var opsDataSize int
+	flushLiteral := func() {
+		if len(literal) > 0 {
+			op := newLiteralOp(literal)
+			ops = append(ops, op)
+			opsDataSize += len(op.Data)
+			literal = nil
+		}
+	}
+	// 累积一定数量的操作或数据后发送
+	flush := func(force bool) error {
+		if len(literal) >= deltaLiteralMaxSize {
+			flushLiteral()
+		}
+		if len(ops) > 0 && (force || len(ops) >= 1024 || opsDataSize >= deltaLiteralMaxSize) {
+			err := emit(ops)
+			ops = nil
+			opsDataSize = 0
+			return err
+		}
+		return nil
+	}
+
+	window := &deltaWindow{r: r}
+	var rc *rollingChecksum
+	for {
+		if err := window.fill(); err != nil {
+			return err
+		}
+		// data为窗口中未处理的数据，除文件结束外长度大于一个块
+		data := window.buf[window.pos:]
+		if len(data) == 0 {
+			break
+		}
+		end := deltaBlockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if rc == nil {
+			rc = newRollingChecksum(data[:end])
+		}
+		matched := -1
+		if candidates, ok := weakMap[rc.sum()]; ok {
+			strong := sha256.Sum256(data[:end])
+			for _, i := range candidates {
+				if signatures[i].Strong == strong {
+					matched = i
+					break
+				}
+			}
+		}
+		if matched >= 0 {
+			flushLiteral()
+			ops = append(ops, DeltaOp{BlockIndex: matched})
+			window.pos += end
+			rc = nil
+		} else if end < deltaBlockSize {
+			// 剩余数据不足一个块且没有匹配时，全部作为新增数据
+			literal = append(literal, data...)
+			window.pos += len(data)
+		} else {
+			literal = append(literal, data[0])
+			if end < len(data) {
+				rc.roll(data[0], data[end])
+			} else {
+				rc = nil
+			}
+			window.pos++
+		}
+		if err := flush(false); err != nil {
+			return err
+		}
+	}
+	flushLiteral()
+	return flush(true)
+}
+
+// newLiteralOp 生成写入数据的操作，压缩后更小时使用压缩的数据
+func newLiteralOp(literal []byte) DeltaOp {
+	op := DeltaOp{BlockIndex: -1, Data: literal}
+	var buf bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&buf, zlib.BestSpeed)
+	if _, err := w.Write(literal); err == nil && w.Close() == nil && buf.Len() < len(literal) {
+		op.Data = buf.Bytes()
+		op.Compressed = true
+	}
+	return op
+}
+
+// applyDelta 将差量操作写入文件，返回写入的数据及实际传输的数据大小
+func applyDelta(w io.Writer, basis *basisBlocks, ops []DeltaOp) (written, transferred int64, err error) {
+	for _, op := range ops {
+		var data []byte
+		if op.BlockIndex >= 0 {
+			if data, err = basis.readBlock(op.BlockIndex); err != nil {
+				return
+			}
+		} else if op.Compressed {
+			var r io.ReadCloser
+			if r, err = zlib.NewReader(bytes.NewReader(op.Data)); err != nil {
+				return
+			}
+			data, err = io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				return
+			}
+			transferred += int64(len(op.Data))
+		} else {
+			data = op.Data
+			transferred += int64(len(op.Data))
+		}
+		if _, err = w.Write(data); err != nil {
+			return
+		}
+		written += int64(len(data))
+	}
+	return
+}
@@ -0,0 +1,79 @@
+package filesync
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+// syncByDelta 模拟一次差量同步，返回同步后的数据及实际传输的数据大小
+func syncByDelta(t *testing.T, newData []byte, basisFiles ...string) ([]byte, int64) {
+	basis := &basisBlocks{}
+	for _, f := range basisFiles {
+		if err := basis.addFile(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	var transferred int64
+	// 每次只读取部分数据，模拟流式读取文件
+	err := computeDelta(iotest.HalfReader(bytes.NewReader(newData)), basis.signatures, func(ops []DeltaOp) error {
+		_, n, err := applyDelta(&out, basis, ops)
+		transferred += n
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes(), transferred
+}
+
+func TestDeltaSync(t *testing.T) {
+	dir := t.TempDir()
+	oldData := make([]byte, 1024*1024)
+	rand.New(rand.NewSource(1)).Read(oldData)
+	oldFile := filepath.Join(dir, "worker")
+	os.WriteFile(oldFile, oldData, 0644)
+
+	// 在中间插入数据后，只需要传输插入的部分
+	newData := append(append(append([]byte{}, oldData[:300000]...), []byte("nemo inserted data")...), oldData[300000:]...)
+	result, transferred := syncByDelta(t, newData, oldFile)
+	if !bytes.Equal(result, newData) {
+		t.Fatal("delta sync result mismatch")
+	}
+	t.Log(len(newData), transferred)
+	if transferred > 2*deltaBlockSize {
+		t.Errorf("transferred too much:%d", transferred)
+	}
+	// 没有本地文件时全部传输（可压缩的数据会被压缩）
+	text := bytes.Repeat([]byte("id: nuclei-template\n"), 10000)
+	result, transferred = syncByDelta(t, text)
+	if !bytes.Equal(result, text) {
+		t.Fatal("full sync result mismatch")
+	}
+	t.Log(len(text), transferred)
+	if transferred >= int64(len(text)) {
+		t.Errorf("literal not compressed:%d", transferred)
+	}
+}
+
+func TestDeltaSyncResume(t *testing.T) {
+	dir := t.TempDir()
+	newData := make([]byte, 512*1024)
+	rand.New(rand.NewSource(2)).Read(newData)
+	// 上次中断时已接收了一部分数据
+	partFile := filepath.Join(dir, "worker"+syncPartFileSuffix)
+	os.WriteFile(partFile, newData[:200*1024], 0600)
+
+	result, transferred := syncByDelta(t, newData, partFile)
+	if !bytes.Equal(result, newData) {
+		t.Fatal("resume result mismatch")
+	}
+	t.Log(len(newData), transferred)
+	if transferred > int64(len(newData)-200*1024+deltaBlockSize) {
+		t.Errorf("resume transferred too much:%d", transferred)
+	}
+}
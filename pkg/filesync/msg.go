@@ -14,6 +14,10 @@ type Message struct {
 	MgFileMode os.FileMode
 	Del        bool // whether should the not exist files in src be deleted.
 	Overwrite  bool // whether the conflicted files be
+	// 差量同步：协议版本、worker本地数据块的签名及server返回的差量操作
+	MgVersion    int
+	MgSignatures []BlockSignature
+	MgOps        []DeltaOp
 }
 
 const (
@@ -23,4 +27,11 @@ const (
 	MsgTranData = "TRAN-DATA"
 	MsgEnd      = "END"
 	MsgError    = "ERROR"
+	// 差量同步的请求、数据及结束消息
+	MsgDelta     = "DELTA"
+	MsgDeltaData = "DELTA-DATA"
+	MsgDeltaEnd  = "DELTA-END"
 )
+
+// syncProtocolVersion 文件同步的协议版本：2开始支持差量、压缩及断点续传
+const syncProtocolVersion = 2
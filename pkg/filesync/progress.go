@@ -0,0 +1,87 @@
+package filesync
+
+import (
+	"sync"
+	"time"
+)
+
+// progressReportInterval 同步进度的报告间隔
+const progressReportInterval = 2 * time.Second
+
+// SyncProgress worker文件同步的进度
+type SyncProgress struct {
+	WorkerHost       string    `json:"worker_host"`
+	TotalFiles       int       `json:"total_files"`
+	DoneFiles        int       `json:"done_files"`
+	FailedFiles      int       `json:"failed_files"`
+	ReceivedBytes    int64     `json:"received_bytes"`
+	TransferredBytes int64     `json:"transferred_bytes"`
+	CurrentFile      string    `json:"current_file"`
+	IsFinished       bool      `json:"is_finished"`
+	StartTime        time.Time `json:"start_time"`
+	UpdateTime       time.Time `json:"update_time"`
+}
+
+// ProgressReporter 报告文件同步的进度（由worker的daemon设置，上报到server）
+var ProgressReporter func(progress SyncProgress)
+
+type syncProgress struct {
+	sync.Mutex
+	SyncProgress
+	lastReportTime time.Time
+}
+
+// newSyncProgress 开始一次同步
+func newSyncProgress(totalFiles int) *syncProgress {
+	p := &syncProgress{SyncProgress: SyncProgress{TotalFiles: totalFiles, StartTime: time.Now()}}
+	p.report(true)
+	return p
+}
+
+// add 累计接收的数据：received为写入文件的数据，transferred为实际传输的数据（不含复用的数据块，压缩后）
+func (p *syncProgress) add(received, transferred int64) {
+	p.Lock()
+	p.ReceivedBytes += received
+	p.TransferredBytes += transferred
+	p.Unlock()
+	p.report(false)
+}
+
+// fileDone 完成一个文件的同步
+func (p *syncProgress) fileDone(file string, status bool) {
+	p.Lock()
+	if status {
+		p.DoneFiles++
+	} else {
+		p.FailedFiles++
+	}
+	p.CurrentFile = file
+	p.Unlock()
+	p.report(false)
+}
+
+// finish 结束同步
+func (p *syncProgress) finish() {
+	p.Lock()
+	p.IsFinished = true
+	p.Unlock()
+	p.report(true)
+}
+
+// report 报告同步进度，非强制时按时间间隔报告
+func (p *syncProgress) report(force bool) {
+	if ProgressReporter == nil {
+		return
+	}
+	p.Lock()
+	if !force && time.Since(p.lastReportTime) < progressReportInterval {
+		p.Unlock()
+		return
+	}
+	p.lastReportTime = time.Now()
+	p.UpdateTime = time.Now()
+	progress := p.SyncProgress
+	p.Unlock()
+
+	ProgressReporter(progress)
+}
@@ -4,11 +4,14 @@ package filesync
 
 import (
 	"bufio"
+	"crypto/md5"
 	"crypto/tls"
 	"encoding/gob"
 	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/cert"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
@@ -59,13 +62,12 @@ func StartFileSyncServer(host, port, authKey string) {
 	var err error
 
 	if TLSEnabled {
-		cert, errTls := tls.LoadX509KeyPair(TLSCertFile, TLSKeyFile)
+		configs, errTls := cert.NewServerTLSConfig(TLSCertFile, TLSKeyFile, WorkerCA, CheckWorkerCert)
 		if errTls != nil {
 			logging.RuntimeLog.Infof("load tls cert fail:%s", errTls)
 			logging.CLILog.Infof("load tls cert fail:%s", errTls)
 			return
 		}
-		configs := &tls.Config{Certificates: []tls.Certificate{cert}}
 		srv, err = tls.Listen("tcp", serverAddr, configs)
 	} else {
 		srv, err = net.Listen("tcp", serverAddr)
@@ -90,6 +92,21 @@ func StartFileSyncServer(host, port, authKey string) {
 func handleSync(conn net.Conn, authKey string) {
 	defer conn.Close()
 
+	// 使用已注册证书的worker不再需要authKey
+	hasWorkerCert := false
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.Handshake(); err != nil {
+			logging.RuntimeLog.Warningf("tls handshake from %s fail:%v", conn.RemoteAddr().String(), err)
+			logging.CLILog.Warningf("tls handshake from %s fail:%v", conn.RemoteAddr().String(), err)
+			return
+		}
+		hasWorkerCert = len(tlsConn.ConnectionState().PeerCertificates) > 0
+	}
+	if WorkerCertRequired && !hasWorkerCert {
+		logging.RuntimeLog.Warningf("no worker cert from %s", conn.RemoteAddr().String())
+		logging.CLILog.Warningf("no worker cert from %s", conn.RemoteAddr().String())
+		return
+	}
 	gbc := initGobConn(conn)
 	for {
 		mg := Message{}
@@ -100,7 +117,7 @@ func handleSync(conn net.Conn, authKey string) {
 			return
 		}
 		// 检查authKey，如果不通过直接返回
-		if success := checkSyncAuthKey(authKey, mg.MgAuthKey, gbc); success == false {
+		if success := hasWorkerCert || checkSyncAuthKey(authKey, mg.MgAuthKey, gbc); success == false {
 			logging.RuntimeLog.Warningf("invalid auth from %s", conn.RemoteAddr().String())
 			logging.CLILog.Warningf("invalid auth from %s", conn.RemoteAddr().String())
 			return
@@ -114,6 +131,9 @@ func handleSync(conn net.Conn, authKey string) {
 		// 请求文件传输
 		case MsgTran:
 			hdTranFile(&mg, gbc)
+		// 请求差量传输
+		case MsgDelta:
+			hdDeltaFile(&mg, gbc)
 		// 结束
 		case MsgEnd:
 			return
@@ -150,6 +170,7 @@ func hdSync(gbc *GobConn) {
 		MgStrings: fileMd5List,
 		MgType:    MsgMd5List,
 		Overwrite: true,
+		MgVersion: syncProtocolVersion,
 	}
 	err = gbc.gobConnWt(cr)
 	if err != nil {
@@ -199,6 +220,52 @@ func hdTranFile(mg *Message, gbc *GobConn) {
 	}
 }
 
+// hdDeltaFile 根据worker本地数据块的签名，分批发送文件的差量操作，最后发送文件属性及md5
+func hdDeltaFile(mg *Message, gbc *GobConn) {
+	if len(mg.MgString) <= 0 {
+		writeErrorMg("no file to transfer", gbc)
+		return
+	}
+	if checkFileIsSyncWhileList(mg.MgString) == false {
+		writeErrorMg("invalid file or path to sync", gbc)
+		return
+	}
+	srcPath, err := filepath.Abs(conf.GetRootPath())
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+		writeErrorMg(err.Error(), gbc)
+		return
+	}
+	srcPathFileName := filepath.Join(srcPath, mg.MgString)
+	st, err := os.Stat(srcPathFileName)
+	if err != nil {
+		writeErrorMg(fmt.Sprintf("read sync file:%s error", err), gbc)
+		return
+	}
+	f, err := os.Open(srcPathFileName)
+	if err != nil {
+		writeErrorMg(fmt.Sprintf("read sync file:%s error", srcPathFileName), gbc)
+		return
+	}
+	defer f.Close()
+	// 流式读取文件，同时计算md5
+	hash := md5.New()
+	err = computeDelta(io.TeeReader(f, hash), mg.MgSignatures, func(ops []DeltaOp) error {
+		return gbc.gobConnWt(Message{MgType: MsgDeltaData, MgOps: ops})
+	})
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+		return
+	}
+	err = gbc.gobConnWt(Message{MgType: MsgDeltaEnd, MgFileMode: st.Mode(), MgString: fmt.Sprintf("%x", hash.Sum(nil))})
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+	}
+}
+
 // hdNoType 处理消息类型错误
 func hdNoType(gbc *GobConn) {
 	writeErrorMg("error, not a recognizable message.", gbc)
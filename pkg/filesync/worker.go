@@ -3,28 +3,27 @@
 package filesync
 
 import (
+	"bufio"
 	"crypto/tls"
 	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/cert"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/utils"
 	"net"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 // WorkerStartupSync worker在启动时进行文件同步
 func WorkerStartupSync(host, port, authKey string) {
 	serverAddr := fmt.Sprintf("%s:%s", host, port)
 	// 1 连接到server
-	var conn net.Conn
-	var err error
-	if TLSEnabled {
-		conn, err = tls.Dial("tcp", serverAddr, &tls.Config{InsecureSkipVerify: true})
-	} else {
-		conn, err = net.Dial("tcp", serverAddr)
-	}
+	conn, err := dialSyncServer(serverAddr)
 	if err != nil {
 		logging.CLILog.Error(err)
 		logging.RuntimeLog.Error(err)
@@ -57,12 +56,17 @@ func WorkerStartupSync(host, port, authKey string) {
 	// 4 获取服务器所有文件及md5值,并预处理本地的路径和文件
 	transFiles, err := doFileMd5List(&hostMessage)
 	if err == nil {
+		md5Map := getFileMd5Map(hostMessage.MgStrings)
+		transFiles = filterStagedFiles(transFiles, md5Map)
 		logging.CLILog.Infof("file needed sync: %d", len(transFiles))
-		// 5 同步文件
-		for i, file := range transFiles {
-			status := doTranFile(file, authKey, gbc)
-			logging.CLILog.Infof("%d %s %v", i+1, file, status)
-
+		// 5 同步文件：server支持时使用差量、并行的方式
+		if hostMessage.MgVersion >= syncProtocolVersion {
+			doParallelDeltaSync(serverAddr, authKey, transFiles, md5Map, gbc)
+		} else {
+			for i, file := range transFiles {
+				status := doTranFile(file, authKey, gbc)
+				logging.CLILog.Infof("%d %s %v", i+1, file, status)
+			}
 		}
 		logging.RuntimeLog.Info("finish file sync")
 		logging.CLILog.Info("finish file sync")
@@ -76,6 +80,219 @@ func WorkerStartupSync(host, port, authKey string) {
 	}
 }
 
+// dialSyncServer 连接到文件同步的server
+func dialSyncServer(serverAddr string) (net.Conn, error) {
+	if TLSEnabled {
+		return tls.Dial("tcp", serverAddr, cert.NewWorkerTLSConfig())
+	}
+	return net.Dial("tcp", serverAddr)
+}
+
+// getFileMd5Map server文件列表中文件的md5值
+func getFileMd5Map(fileMd5List []string) map[string]string {
+	md5Map := make(map[string]string)
+	for _, v := range fileMd5List {
+		arr := strings.Split(v, ",,")
+		if len(arr) == 2 {
+			md5Map[arr[0]] = arr[1]
+		}
+	}
+	return md5Map
+}
+
+// filterStagedFiles 暂存文件已经是最新的则不需要再同步
+func filterStagedFiles(transFiles []string, md5Map map[string]string) (files []string) {
+	srcPath, err := filepath.Abs(conf.GetRootPath())
+	if err != nil {
+		return transFiles
+	}
+	for _, file := range transFiles {
+		if stagedFile := getStagedFileName(file); stagedFile != file {
+			if md5Str, err := Md5OfAFile(filepath.Join(srcPath, stagedFile)); err == nil && md5Str == md5Map[file] {
+				continue
+			}
+		}
+		files = append(files, file)
+	}
+	return
+}
+
+// doParallelDeltaSync 使用多个连接并行差量同步文件，连接中断后重新连接并从中断处继续
+func doParallelDeltaSync(serverAddr, authKey string, transFiles []string, md5Map map[string]string, gbc *GobConn) {
+	progress := newSyncProgress(len(transFiles))
+	defer progress.finish()
+
+	fileChan := make(chan string, len(transFiles))
+	for _, file := range transFiles {
+		fileChan <- file
+	}
+	close(fileChan)
+
+	var wg sync.WaitGroup
+	for i := 0; i < syncParallelNumber && i < len(transFiles); i++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			// 第一个使用已建立的连接，其它的建立新连接
+			var workerGbc *GobConn
+			var conn net.Conn
+			if index == 0 {
+				workerGbc = gbc
+			}
+			defer func() {
+				if conn != nil {
+					workerGbc.gobConnWt(Message{MgType: MsgEnd, MgAuthKey: authKey})
+					conn.Close()
+				}
+			}()
+			for file := range fileChan {
+				status := false
+				for retry := 0; retry < syncRetryNumber && !status; retry++ {
+					if workerGbc == nil {
+						var err error
+						if conn, err = dialSyncServer(serverAddr); err != nil {
+							logging.CLILog.Error(err)
+							logging.RuntimeLog.Error(err)
+							time.Sleep(time.Duration(retry+1) * time.Second)
+							continue
+						}
+						workerGbc = initGobConn(conn)
+					}
+					var connErr error
+					status, connErr = doDeltaTranFile(file, md5Map[file], authKey, workerGbc, progress)
+					// 连接中断时重新连接，已接收的数据保留在临时文件中
+					if connErr != nil {
+						if conn != nil {
+							conn.Close()
+							conn = nil
+						}
+						workerGbc = nil
+					}
+				}
+				progress.fileDone(file, status)
+				logging.CLILog.Infof("%s %v", file, status)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// doDeltaTranFile worker向server请求差量同步一个文件；返回的connErr不为空时表示连接已不可用
+func doDeltaTranFile(filePathName, fileMd5, authKey string, gbc *GobConn, progress *syncProgress) (status bool, connErr error) {
+	srcPath, err := filepath.Abs(conf.GetRootPath())
+	if err != nil {
+		logging.CLILog.Error(err)
+		logging.RuntimeLog.Error(err)
+		return false, nil
+	}
+	dstFilePathName := filepath.Join(srcPath, getStagedFileName(filePathName))
+	partFile := dstFilePathName + syncPartFileSuffix
+	tmpFile := partFile + ".tmp"
+	// 本地原文件、暂存文件及上次中断时已接收的数据都可以复用
+	basis := &basisBlocks{}
+	for _, f := range utils.RemoveDuplicationElement([]string{filepath.Join(srcPath, filePathName), dstFilePathName, partFile}) {
+		if fi, err := os.Stat(f); err == nil && fi.Mode().IsRegular() {
+			if err = basis.addFile(f); err != nil {
+				logging.RuntimeLog.Warning(err)
+			}
+		}
+	}
+	if connErr = gbc.gobConnWt(Message{MgAuthKey: authKey, MgType: MsgDelta, MgString: filePathName, MgSignatures: basis.signatures}); connErr != nil {
+		logging.CLILog.Error(connErr)
+		logging.RuntimeLog.Error(connErr)
+		return false, connErr
+	}
+	out, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		logging.CLILog.Error(err)
+		logging.RuntimeLog.Error(err)
+		// 接收并丢弃server的数据，保持连接可用
+		return false, discardDelta(gbc)
+	}
+	w := bufio.NewWriter(out)
+	defer func() {
+		out.Close()
+		if !status {
+			keepPartFile(tmpFile, partFile)
+		}
+	}()
+	for {
+		var hostMessage Message
+		if connErr = gbc.Dec.Decode(&hostMessage); connErr != nil {
+			logging.CLILog.Error(connErr)
+			logging.RuntimeLog.Error(connErr)
+			w.Flush()
+			return false, connErr
+		}
+		switch hostMessage.MgType {
+		case MsgDeltaData:
+			written, transferred, err := applyDelta(w, basis, hostMessage.MgOps)
+			progress.add(written, transferred)
+			if err != nil {
+				logging.CLILog.Error(err)
+				logging.RuntimeLog.Error(err)
+				// 本地数据异常时，剩余的数据不再写入
+				return false, discardDelta(gbc)
+			}
+		case MsgDeltaEnd:
+			if err = w.Flush(); err != nil {
+				logging.CLILog.Error(err)
+				logging.RuntimeLog.Error(err)
+				return false, nil
+			}
+			out.Close()
+			if md5Str, err := Md5OfAFile(tmpFile); err != nil || md5Str != hostMessage.MgString || (fileMd5 != "" && md5Str != fileMd5) {
+				logging.CLILog.Errorf("file %s md5 mismatch", filePathName)
+				logging.RuntimeLog.Errorf("file %s md5 mismatch", filePathName)
+				os.Remove(tmpFile)
+				os.Remove(partFile)
+				return false, nil
+			}
+			if err = os.Chmod(tmpFile, hostMessage.MgFileMode); err != nil {
+				logging.RuntimeLog.Warning(err)
+			}
+			// 使用rename替换文件，运行中的程序（如worker）也可以被更新
+			if err = os.Rename(tmpFile, dstFilePathName); err != nil {
+				logging.CLILog.Error(err)
+				logging.RuntimeLog.Error(err)
+				return false, nil
+			}
+			os.Remove(partFile)
+			return true, nil
+		default:
+			logging.CLILog.Error(hostMessage.MgString)
+			logging.RuntimeLog.Error(hostMessage.MgString)
+			return false, nil
+		}
+	}
+}
+
+// discardDelta 丢弃server发送的差量数据直到结束消息
+func discardDelta(gbc *GobConn) error {
+	for {
+		var hostMessage Message
+		if err := gbc.Dec.Decode(&hostMessage); err != nil {
+			return err
+		}
+		if hostMessage.MgType != MsgDeltaData {
+			return nil
+		}
+	}
+}
+
+// keepPartFile 传输中断时保留已接收的数据（两者都是目标文件的前缀，保留较长的一个），用于断点续传
+func keepPartFile(tmpFile, partFile string) {
+	tmpInfo, err := os.Stat(tmpFile)
+	if err != nil {
+		return
+	}
+	if partInfo, err := os.Stat(partFile); err == nil && partInfo.Size() >= tmpInfo.Size() {
+		os.Remove(tmpFile)
+		return
+	}
+	os.Rename(tmpFile, partFile)
+}
+
 // doFileMd5List 读取worker本地文件列表及md5值，并与服务端进行对比，确定需要同步的文件列表
 func doFileMd5List(mg *Message) (transFiles []string, err error) {
 	//srcPath := "/tmp/test/dst"
@@ -151,7 +368,7 @@ func doTranFile(filePathName, authKey string, gbc *GobConn) bool {
 		return false
 	}
 	if hostMessage.MgType == MsgTranData {
-		dstFilePathName := filepath.Join(srcPath, filePathName)
+		dstFilePathName := filepath.Join(srcPath, getStagedFileName(filePathName))
 		err = os.WriteFile(dstFilePathName, hostMessage.MgByte, hostMessage.MgFileMode)
 		if err != nil {
 			logging.CLILog.Error(err)
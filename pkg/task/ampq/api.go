@@ -1,14 +1,18 @@
 package ampq
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/RichardKnop/machinery/v2"
 	amqpbackend "github.com/RichardKnop/machinery/v2/backends/amqp"
+	redisbackend "github.com/RichardKnop/machinery/v2/backends/redis"
 	amqpbroker "github.com/RichardKnop/machinery/v2/brokers/amqp"
+	redisbroker "github.com/RichardKnop/machinery/v2/brokers/redis"
 	"github.com/RichardKnop/machinery/v2/config"
 	eagerlock "github.com/RichardKnop/machinery/v2/locks/eager"
 	"github.com/RichardKnop/machinery/v2/tasks"
 	"github.com/hanc00l/nemo_go/pkg/conf"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -29,10 +33,28 @@ const (
 	TopicPassive = "passive"
 	TopicPocscan = "pocscan"
 	TopicCustom  = "custom"
+	TopicLabel   = "label"
 
 	TopicMQPrefix = "nemo_mq"
 )
 
+const (
+	CapabilityNmap         = "nmap"
+	CapabilityMasscan      = "masscan"
+	CapabilityHttpx        = "httpx"
+	CapabilitySubfinder    = "subfinder"
+	CapabilityNuclei       = "nuclei"
+	CapabilityXray         = "xray"
+	CapabilityObserverWard = "observer_ward"
+	CapabilityChrome       = "chrome"
+	CapabilityRawSocket    = "rawsocket"
+	CapabilityFofa         = "fofa"
+	CapabilityQuake        = "quake"
+	CapabilityHunter       = "hunter"
+	CapabilityICP          = "icp"
+	CapabilityGoby         = "goby"
+)
+
 type TaskResult struct {
 	Status string `json:"status"`
 	Msg    string `json:"msg"`
@@ -40,14 +62,42 @@ type TaskResult struct {
 
 type WorkerStatus struct {
 	sync.Mutex             `json:"-"`
-	WorkerName             string    `json:"worker_name"`
-	WorkerTopics           string    `json:"worker_topic"`
-	CreateTime             time.Time `json:"create_time"`
-	UpdateTime             time.Time `json:"update_time"`
-	TaskExecutedNumber     int       `json:"task_number"`
-	ManualReloadFlag       bool      `json:"manual_reload_flag"`
-	ManualFileSyncFlag     bool      `json:"manual_file_sync_flag"`
-	WorkerDaemonUpdateTime time.Time `json:"worker_daemon_update_time"`
+	WorkerName             string           `json:"worker_name"`
+	WorkerTopics           string           `json:"worker_topic"`
+	CreateTime             time.Time        `json:"create_time"`
+	UpdateTime             time.Time        `json:"update_time"`
+	TaskExecutedNumber     int              `json:"task_number"`
+	ManualReloadFlag       bool             `json:"manual_reload_flag"`
+	ManualFileSyncFlag     bool             `json:"manual_file_sync_flag"`
+	WorkerDaemonUpdateTime time.Time        `json:"worker_daemon_update_time"`
+	Capability             WorkerCapability `json:"capability"`
+	Resource               WorkerResource   `json:"resource"`
+}
+
+// WorkerResource worker的资源使用情况
+type WorkerResource struct {
+	CPUNumber      int     `json:"cpu_number"`
+	CPUPercent     float64 `json:"cpu_percent"`
+	MemTotal       uint64  `json:"mem_total"`
+	MemAvailable   uint64  `json:"mem_available"`
+	MemUsedPercent float64 `json:"mem_used_percent"`
+	Load1          float64 `json:"load1"`
+	Load5          float64 `json:"load5"`
+	Load15         float64 `json:"load15"`
+	// NetSentRate、NetRecvRate 网络发送及接收的速率（字节/秒）
+	NetSentRate uint64 `json:"net_sent_rate"`
+	NetRecvRate uint64 `json:"net_recv_rate"`
+	// LowResource 资源不足时降低任务的并发数
+	LowResource    bool `json:"low_resource"`
+	RunningTask    int  `json:"running_task"`
+	MaxRunningTask int  `json:"max_running_task"`
+}
+
+// WorkerCapability worker的执行能力
+type WorkerCapability struct {
+	// Items 具备的能力：能力名称->版本或说明，包括已安装的工具、chrome、raw socket权限及已配置的API
+	Items    map[string]string `json:"items"`
+	EgressIP string            `json:"egress_ip"`
 }
 
 type WorkerRunTaskMode int
@@ -80,6 +130,8 @@ var taskTopicDefineMap = map[string]string{
 	"icpquery":          TopicPassive,
 	"whoisquery":        TopicPassive,
 	"fingerprint":       TopicFinger,
+	"vhost":             TopicActive,
+	"cdnorigin":         TopicActive,
 	"xportscan":         TopicActive,
 	"xonlineapi":        TopicPassive,
 	"xfofa":             TopicPassive,
@@ -98,30 +150,83 @@ var taskTopicDefineMap = map[string]string{
 	"test": TopicCustom,
 }
 
+// taskCapabilityDefineMap 每个task执行需要worker具备的能力，以便worker只接收能执行的任务；
+// 多个能力之间为“与”的关系，用"|"分隔的能力之间为“或”的关系；未定义的task不需要特定能力；
+// 在线API（fofa、quake、hunter、icp）的key可以由server集中管理，执行任务时才获取，因此不作为接收任务的条件
+var taskCapabilityDefineMap = map[string][]string{
+	"portscan":          {CapabilityNmap + "|" + CapabilityMasscan},
+	"batchscan":         {CapabilityNmap + "|" + CapabilityMasscan},
+	"subfinder":         {CapabilitySubfinder},
+	"subdomaincrawler":  {CapabilityChrome},
+	"xray":              {CapabilityXray},
+	"nuclei":            {CapabilityNuclei},
+	"goby":              {CapabilityGoby},
+	"xportscan":         {CapabilityNmap + "|" + CapabilityMasscan},
+	"xsubfinder":        {CapabilitySubfinder},
+	"xsubdomaincralwer": {CapabilityChrome},
+	"xxray":             {CapabilityXray},
+	"xnuclei":           {CapabilityNuclei},
+	"xgoby":             {CapabilityGoby},
+}
+
+// taskConfigCapabilityDefineMap 任务参数中开启的功能需要worker具备的能力：参数（json字段）为true时才需要该能力；
+// xfingerprint的功能由worker的配置文件决定，不在此定义
+var taskConfigCapabilityDefineMap = map[string]map[string]string{
+	"fingerprint": {
+		"IsHttpx":          CapabilityHttpx,
+		"IsFingerprintHub": CapabilityObserverWard,
+		"IsScreenshot":     CapabilityChrome,
+	},
+}
+
 // taskServer 复用的全局AMQP连接
-var taskServerConn = make(map[string]*machinery.Server)
+var (
+	taskServerConn      = make(map[string]*machinery.Server)
+	taskServerConnMutex sync.Mutex
+)
+
+// workerLabelRegex worker标签的格式（标签用于队列的routingKey，不能包含“.”）
+var workerLabelRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
 
 // CustomTaskWorkspaceMap 自定义任务关联的工作空间GUID
 var CustomTaskWorkspaceMap = make(map[string]struct{})
 
 // GetServerTaskAMPQServer 根据server配置文件，获取到消息中心的连接
 func GetServerTaskAMPQServer(topicName string) *machinery.Server {
+	taskServerConnMutex.Lock()
+	defer taskServerConnMutex.Unlock()
+
 	if _, ok := taskServerConn[topicName]; !ok {
-		rabbitmq := conf.GlobalServerConfig().Rabbitmq
-		taskServerConn[topicName] = startAMQPServer(rabbitmq.Username, rabbitmq.Password, rabbitmq.Host, rabbitmq.Port, topicName, 3)
+		config := conf.GlobalServerConfig()
+		taskServerConn[topicName] = startTaskServer(config.Broker, config.Rabbitmq, config.Redis, topicName, 3)
 	}
 	return taskServerConn[topicName]
 }
 
 // GetWorkerAMPQServer 根据worker配置文件，获取到消息中心的连接
 func GetWorkerAMPQServer(topicName string, prefetchCount int) *machinery.Server {
+	taskServerConnMutex.Lock()
+	defer taskServerConnMutex.Unlock()
+
 	if _, ok := taskServerConn[topicName]; !ok {
-		rabbitmq := conf.GlobalWorkerConfig().Rabbitmq
-		taskServerConn[topicName] = startAMQPServer(rabbitmq.Username, rabbitmq.Password, rabbitmq.Host, rabbitmq.Port, topicName, prefetchCount)
+		config := conf.GlobalWorkerConfig()
+		taskServerConn[topicName] = startTaskServer(config.Broker, config.Rabbitmq, config.Redis, topicName, prefetchCount)
 	}
 	return taskServerConn[topicName]
 }
 
+// startTaskServer 根据配置的消息中间件类型，连接到RabbitMQ或Redis；未配置时默认使用RabbitMQ；
+// all-in-one模式下使用进程内的任务队列
+func startTaskServer(brokerType string, rabbitmq conf.Rabbitmq, redis conf.Redis, topicName string, prefetchCount int) *machinery.Server {
+	if conf.AllInOneMode {
+		return startMemoryServer(topicName)
+	}
+	if brokerType == conf.BrokerRedis {
+		return startRedisServer(redis.Host, redis.Password, redis.Port, redis.DB, topicName)
+	}
+	return startAMQPServer(rabbitmq.Username, rabbitmq.Password, rabbitmq.Host, rabbitmq.Port, topicName, prefetchCount)
+}
+
 // startAMQPServer 连接到AMQP消息队列服务器
 func startAMQPServer(username, password, host string, port int, topicName string, prefetchCount int) *machinery.Server {
 	amqpConfig := fmt.Sprintf("amqp://%s:%s@%s:%d/", username, password, host, port)
@@ -147,7 +252,85 @@ func startAMQPServer(username, password, host string, port int, topicName string
 	return server
 }
 
-func GetTopicByTaskName(taskName string, workspaceGUID string) string {
+// startRedisServer 连接到Redis消息队列服务器
+// 每个topic对应一个独立的Redis list（与AMQP的routingKey一致），延迟任务也按topic分开存放，
+// 保证任务只会被订阅了该topic的worker取出
+func startRedisServer(host, password string, port, db int, topicName string) *machinery.Server {
+	redisConfig := fmt.Sprintf("redis://%s@%s:%d/%d", password, host, port, db)
+	routingKey := GetRoutingKeyByTopic(topicName)
+	cnf := &config.Config{
+		Broker:          redisConfig,
+		DefaultQueue:    routingKey,
+		ResultBackend:   redisConfig,
+		ResultsExpireIn: 300,
+		Redis: &config.RedisConfig{
+			MaxIdle:                3,
+			IdleTimeout:            240,
+			ReadTimeout:            15,
+			WriteTimeout:           15,
+			ConnectTimeout:         15,
+			NormalTasksPollPeriod:  1000,
+			DelayedTasksPollPeriod: 500,
+			DelayedTasksKey:        fmt.Sprintf("%s.delayed_tasks", routingKey),
+		},
+	}
+	address := fmt.Sprintf("%s:%d", host, port)
+	broker := redisbroker.New(cnf, address, password, "", db)
+	backend := redisbackend.New(cnf, address, password, "", db)
+	lock := eagerlock.New()
+	server := machinery.NewServer(cnf, broker, backend, lock)
+
+	return server
+}
+
+// CheckTaskCapability 检查worker的能力是否能执行指定的任务
+func CheckTaskCapability(taskName string, capability WorkerCapability) bool {
+	for _, require := range taskCapabilityDefineMap[taskName] {
+		satisfied := false
+		for _, c := range strings.Split(require, "|") {
+			if _, ok := capability.Items[c]; ok {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckTaskConfigCapability 根据任务及任务参数，检查worker是否具备执行任务的能力
+func CheckTaskConfigCapability(taskName, configJSON string, capability WorkerCapability) bool {
+	if !CheckTaskCapability(taskName, capability) {
+		return false
+	}
+	configDefine, ok := taskConfigCapabilityDefineMap[taskName]
+	if !ok {
+		return true
+	}
+	config := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(configJSON), &config); err != nil {
+		return true
+	}
+	for field, require := range configDefine {
+		if enabled, _ := config[field].(bool); !enabled {
+			continue
+		}
+		if _, ok = capability.Items[require]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// GetTopicByTaskName 获取任务分发的队列：指定了worker标签的任务只分发到具有该标签的worker，
+// 其次是自定义任务工作空间，最后是任务对应的默认队列
+func GetTopicByTaskName(taskName string, workspaceGUID string, workerLabel string) string {
+	if workerLabel != "" {
+		// label.intranet
+		return GetTopicByWorkerLabel(workerLabel)
+	}
 	if _, ok := CustomTaskWorkspaceMap[workspaceGUID]; ok {
 		// custom.1a0ca919-7960-4067-9981-9abcb4eaa735
 		return fmt.Sprintf("%s.%s", TopicCustom, workspaceGUID)
@@ -159,6 +342,16 @@ func GetTopicByTaskName(taskName string, workspaceGUID string) string {
 	return ""
 }
 
+// GetTopicByWorkerLabel 获取worker标签对应的队列
+func GetTopicByWorkerLabel(workerLabel string) string {
+	return fmt.Sprintf("%s.%s", TopicLabel, workerLabel)
+}
+
+// CheckWorkerLabel 检查worker标签是否有效：只能包含字母、数字、“_”及“-”
+func CheckWorkerLabel(workerLabel string) bool {
+	return workerLabelRegex.MatchString(workerLabel)
+}
+
 func GetTopicByMQRoutingKey(routingKey string) string {
 	keys := strings.Split(routingKey, ".")
 	if len(keys) == 3 {
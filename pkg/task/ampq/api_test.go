@@ -0,0 +1,70 @@
+package ampq
+
+import "testing"
+
+func TestCheckTaskCapability(t *testing.T) {
+	capability := WorkerCapability{Items: map[string]string{
+		CapabilityMasscan: "1.3.2",
+		CapabilityHttpx:   "v1.3.4",
+		CapabilityFofa:    "configured",
+	}}
+	taskExpected := map[string]bool{
+		"portscan":    true,
+		"xonlineapi":  true,
+		"fofa":        true,
+		"quake":       true,
+		"fingerprint": true,
+		"nuclei":      false,
+		"iplocation":  true,
+	}
+	for taskName, expected := range taskExpected {
+		if r := CheckTaskCapability(taskName, capability); r != expected {
+			t.Errorf("%s: expected %v, got %v", taskName, expected, r)
+		}
+	}
+}
+
+func TestCheckTaskConfigCapability(t *testing.T) {
+	capability := WorkerCapability{Items: map[string]string{
+		CapabilityHttpx: "v1.3.4",
+	}}
+	configExpected := map[string]bool{
+		`{"IsHttpx":true}`:                         true,
+		`{"IsHttpx":true,"IsFingerprintHub":true}`: false,
+		`{"IsHttpx":true,"IsScreenshot":false}`:    true,
+		`{"IsHttpx":true,"IsScreenshot":true}`:     false,
+	}
+	for configJSON, expected := range configExpected {
+		if r := CheckTaskConfigCapability("fingerprint", configJSON, capability); r != expected {
+			t.Errorf("%s: expected %v, got %v", configJSON, expected, r)
+		}
+	}
+	if CheckTaskConfigCapability("nuclei", "{}", capability) {
+		t.Error("nuclei should not be executed")
+	}
+}
+
+func TestGetTopicByTaskName(t *testing.T) {
+	CustomTaskWorkspaceMap["1a0ca919-7960-4067-9981-9abcb4eaa735"] = struct{}{}
+	defer delete(CustomTaskWorkspaceMap, "1a0ca919-7960-4067-9981-9abcb4eaa735")
+
+	topics := map[string]string{
+		GetTopicByTaskName("portscan", "", ""):                                             TopicActive,
+		GetTopicByTaskName("portscan", "1a0ca919-7960-4067-9981-9abcb4eaa735", ""):         "custom.1a0ca919-7960-4067-9981-9abcb4eaa735",
+		GetTopicByTaskName("portscan", "1a0ca919-7960-4067-9981-9abcb4eaa735", "intranet"): "label.intranet",
+		GetTopicByTaskName("fofa", "", "cn-egress"):                                        "label.cn-egress",
+	}
+	for topic, expected := range topics {
+		if topic != expected {
+			t.Errorf("expected %s, got %s", expected, topic)
+		}
+	}
+	if GetTopicByMQRoutingKey(GetRoutingKeyByTopic("label.intranet")) != "label.intranet" {
+		t.Error("routing key error")
+	}
+	for label, expected := range map[string]bool{"intranet": true, "cn-egress": true, "a.b": false, "": false} {
+		if CheckWorkerLabel(label) != expected {
+			t.Errorf("%s: expected %v", label, expected)
+		}
+	}
+}
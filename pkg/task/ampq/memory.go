@@ -0,0 +1,203 @@
+package ampq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/RichardKnop/machinery/v2"
+	eagerbackend "github.com/RichardKnop/machinery/v2/backends/eager"
+	"github.com/RichardKnop/machinery/v2/brokers/iface"
+	"github.com/RichardKnop/machinery/v2/common"
+	"github.com/RichardKnop/machinery/v2/config"
+	eagerlock "github.com/RichardKnop/machinery/v2/locks/eager"
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"sync"
+	"time"
+)
+
+// memoryQueue 进程内的任务队列，每个routingKey对应一个队列
+type memoryQueue struct {
+	sync.Mutex
+	pending []*tasks.Signature
+	delayed map[string]*tasks.Signature
+}
+
+// memoryBroker 进程内的消息中间件，实现machinery的Broker接口，用于单机all-in-one运行模式；
+// 与AMQP一样按routingKey分发任务，并支持任务的ETA延迟执行
+type memoryBroker struct {
+	common.Broker
+	queue        *memoryQueue
+	processingWG sync.WaitGroup
+}
+
+// memoryQueues 全局的进程内队列
+var (
+	memoryQueues      = make(map[string]*memoryQueue)
+	memoryQueuesMutex sync.Mutex
+)
+
+const memoryQueuePollPeriod = 500 * time.Millisecond
+
+// getMemoryQueue 获取routingKey对应的进程内队列
+func getMemoryQueue(routingKey string) *memoryQueue {
+	memoryQueuesMutex.Lock()
+	defer memoryQueuesMutex.Unlock()
+
+	if _, ok := memoryQueues[routingKey]; !ok {
+		memoryQueues[routingKey] = &memoryQueue{delayed: make(map[string]*tasks.Signature)}
+	}
+	return memoryQueues[routingKey]
+}
+
+// startMemoryServer 创建进程内的任务队列
+func startMemoryServer(topicName string) *machinery.Server {
+	routingKey := GetRoutingKeyByTopic(topicName)
+	cnf := &config.Config{
+		Broker:          fmt.Sprintf("memory://%s", routingKey),
+		DefaultQueue:    routingKey,
+		ResultBackend:   "eager",
+		ResultsExpireIn: 300,
+	}
+	broker := &memoryBroker{
+		Broker: common.NewBroker(cnf),
+		queue:  getMemoryQueue(routingKey),
+	}
+	backend := eagerbackend.New()
+	lock := eagerlock.New()
+	server := machinery.NewServer(cnf, broker, backend, lock)
+
+	return server
+}
+
+// StartConsuming 从队列中取出任务并执行，直到StopConsuming
+func (b *memoryBroker) StartConsuming(consumerTag string, concurrency int, taskProcessor iface.TaskProcessor) (bool, error) {
+	b.Broker.StartConsuming(consumerTag, concurrency, taskProcessor)
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	pool := make(chan struct{}, concurrency)
+	for {
+		select {
+		case <-b.GetStopChan():
+			b.processingWG.Wait()
+			return b.GetRetry(), nil
+		case pool <- struct{}{}:
+			signature := b.queue.pop()
+			if signature == nil {
+				<-pool
+				time.Sleep(memoryQueuePollPeriod)
+				continue
+			}
+			// 与其它broker一样，未注册的任务重新放回队列
+			if !b.IsTaskRegistered(signature.Name) {
+				b.queue.push(signature)
+				<-pool
+				time.Sleep(memoryQueuePollPeriod)
+				continue
+			}
+			b.processingWG.Add(1)
+			go func() {
+				defer func() {
+					<-pool
+					b.processingWG.Done()
+				}()
+				if err := taskProcessor.Process(signature); err != nil {
+					logging.RuntimeLog.Errorf("process task %s fail:%v", signature.UUID, err)
+				}
+			}()
+		}
+	}
+}
+
+// StopConsuming 停止取出任务
+func (b *memoryBroker) StopConsuming() {
+	b.Broker.StopConsuming()
+	b.processingWG.Wait()
+}
+
+// Publish 将任务发布到routingKey对应的队列，ETA未到的任务延迟后再放入队列
+func (b *memoryBroker) Publish(ctx context.Context, signature *tasks.Signature) error {
+	b.Broker.AdjustRoutingKey(signature)
+	// 与其它broker一样对任务进行序列化，避免任务参数被发布者和执行者共享
+	message, err := json.Marshal(signature)
+	if err != nil {
+		return fmt.Errorf("JSON marshal error: %s", err)
+	}
+	s := new(tasks.Signature)
+	decoder := json.NewDecoder(bytes.NewReader(message))
+	decoder.UseNumber()
+	if err = decoder.Decode(s); err != nil {
+		return fmt.Errorf("JSON unmarshal error: %s", err)
+	}
+	queue := getMemoryQueue(s.RoutingKey)
+	if s.ETA != nil && s.ETA.After(time.Now()) {
+		queue.delay(s, s.ETA.Sub(time.Now()))
+		return nil
+	}
+	queue.push(s)
+	return nil
+}
+
+// GetPendingTasks 获取队列中等待执行的任务
+func (b *memoryBroker) GetPendingTasks(queue string) ([]*tasks.Signature, error) {
+	if queue == "" {
+		queue = b.GetConfig().DefaultQueue
+	}
+	q := getMemoryQueue(queue)
+	q.Lock()
+	defer q.Unlock()
+
+	signatures := make([]*tasks.Signature, len(q.pending))
+	copy(signatures, q.pending)
+	return signatures, nil
+}
+
+// GetDelayedTasks 获取ETA还未到的任务
+func (b *memoryBroker) GetDelayedTasks() ([]*tasks.Signature, error) {
+	b.queue.Lock()
+	defer b.queue.Unlock()
+
+	var signatures []*tasks.Signature
+	for _, s := range b.queue.delayed {
+		signatures = append(signatures, s)
+	}
+	return signatures, nil
+}
+
+// push 任务放入队列
+func (q *memoryQueue) push(signature *tasks.Signature) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.pending = append(q.pending, signature)
+}
+
+// pop 从队列头取出一个任务，队列为空时返回nil
+func (q *memoryQueue) pop() *tasks.Signature {
+	q.Lock()
+	defer q.Unlock()
+
+	if len(q.pending) == 0 {
+		return nil
+	}
+	signature := q.pending[0]
+	q.pending[0] = nil
+	q.pending = q.pending[1:]
+	return signature
+}
+
+// delay 延迟一段时间后将任务放入队列
+func (q *memoryQueue) delay(signature *tasks.Signature, d time.Duration) {
+	q.Lock()
+	q.delayed[signature.UUID] = signature
+	q.Unlock()
+
+	time.AfterFunc(d, func() {
+		q.Lock()
+		delete(q.delayed, signature.UUID)
+		q.pending = append(q.pending, signature)
+		q.Unlock()
+	})
+}
@@ -0,0 +1,48 @@
+package ampq
+
+import (
+	"github.com/RichardKnop/machinery/v2/tasks"
+	"testing"
+	"time"
+)
+
+func TestMemoryBroker_SendTask(t *testing.T) {
+	server := startMemoryServer(TopicActive)
+	done := make(chan string, 1)
+	err := server.RegisterTasks(map[string]interface{}{
+		"test": func(taskId, r string) (string, error) {
+			done <- taskId
+			return r, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker := server.NewWorker("test", 1)
+	go worker.Launch()
+	defer worker.Quit()
+
+	eta := time.Now().Add(time.Second)
+	signature := tasks.Signature{
+		Name: "test",
+		UUID: "task-1",
+		ETA:  &eta,
+		Args: []tasks.Arg{
+			{Name: "taskId", Type: "string", Value: "task-1"},
+			{Name: "r", Type: "string", Value: "ok"},
+		},
+		RoutingKey: GetRoutingKeyByTopic(TopicActive),
+	}
+	if _, err = server.SendTask(&signature); err != nil {
+		t.Fatal(err)
+	}
+	delayed, _ := server.GetBroker().GetDelayedTasks()
+	t.Log(len(delayed))
+
+	select {
+	case taskId := <-done:
+		t.Log(taskId)
+	case <-time.After(10 * time.Second):
+		t.Error("task not executed")
+	}
+}
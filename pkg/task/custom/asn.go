@@ -0,0 +1,225 @@
+package custom
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/oschwald/maxminddb-golang"
+	"math/bits"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ASNInfo IP的ASN信息
+type ASNInfo struct {
+	ASN    uint
+	Name   string
+	Prefix string
+}
+
+// asnRange iptoasn数据的IP段
+type asnRange struct {
+	Start uint32
+	End   uint32
+	ASN   uint
+	Name  string
+}
+
+// asnRecord GeoLite2-ASN.mmdb的记录
+type asnRecord struct {
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// ASNQuery 使用离线数据查询IP的ASN、AS名称及宣告的网段：优先使用GeoLite2-ASN.mmdb，其次为iptoasn的TSV数据
+type ASNQuery struct {
+	mmdb   *maxminddb.Reader
+	ranges []asnRange
+}
+
+var (
+	asnQueryCache *ASNQuery
+	asnQueryMutex sync.Mutex
+)
+
+// GetASNQuery 获取ASN查询对象，离线数据只在第一次使用时加载，之后共用（查询是只读的，可以并发使用）
+func GetASNQuery() *ASNQuery {
+	asnQueryMutex.Lock()
+	defer asnQueryMutex.Unlock()
+
+	if asnQueryCache == nil {
+		asnQueryCache = newASNQuery()
+	}
+	return asnQueryCache
+}
+
+// newASNQuery 创建ASN查询对象，加载离线数据
+func newASNQuery() *ASNQuery {
+	a := &ASNQuery{}
+	mmdbFile := filepath.Join(conf.GetRootPath(), "thirdparty/geolite2/GeoLite2-ASN.mmdb")
+	if utils.CheckFileExist(mmdbFile) {
+		db, err := maxminddb.Open(mmdbFile)
+		if err != nil {
+			logging.RuntimeLog.Error(err)
+		} else {
+			a.mmdb = db
+		}
+	}
+	tsvFile := filepath.Join(conf.GetRootPath(), "thirdparty/ip2asn/ip2asn-v4.tsv")
+	if utils.CheckFileExist(tsvFile) {
+		ranges, err := LoadIPToASN(tsvFile)
+		if err != nil {
+			logging.RuntimeLog.Error(err)
+		} else {
+			a.ranges = ranges
+		}
+	}
+	if a.mmdb == nil && len(a.ranges) == 0 {
+		logging.RuntimeLog.Warning("no asn data found,skip asn query")
+	}
+	return a
+}
+
+// LoadIPToASN 加载iptoasn的TSV数据，每行为：起始IP、结束IP、ASN、国家代码、AS名称
+func LoadIPToASN(tsvFile string) (ranges []asnRange, err error) {
+	file, err := os.Open(tsvFile)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), "\t")
+		if len(fields) < 5 || !utils.CheckIPV4(fields[0]) || !utils.CheckIPV4(fields[1]) {
+			continue
+		}
+		asn, err := strconv.ParseUint(fields[2], 10, 32)
+		// ASN为0的为未宣告的IP段
+		if err != nil || asn == 0 {
+			continue
+		}
+		ranges = append(ranges, asnRange{
+			Start: utils.IPToUInt32(fields[0]),
+			End:   utils.IPToUInt32(fields[1]),
+			ASN:   uint(asn),
+			Name:  fields[4],
+		})
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+	return ranges, scanner.Err()
+}
+
+// Find 查询IP的ASN信息
+func (a *ASNQuery) Find(ip string) (info ASNInfo, ok bool) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil || parsedIP.IsPrivate() || parsedIP.IsLoopback() {
+		return
+	}
+	if a.mmdb != nil {
+		var record asnRecord
+		network, found, err := a.mmdb.LookupNetwork(parsedIP, &record)
+		if err == nil && found && record.AutonomousSystemNumber > 0 {
+			return ASNInfo{ASN: record.AutonomousSystemNumber, Name: record.AutonomousSystemOrganization, Prefix: network.String()}, true
+		}
+	}
+	if parsedIP.To4() == nil || len(a.ranges) == 0 {
+		return
+	}
+	ipInt := utils.IPToUInt32(parsedIP.To4().String())
+	i := sort.Search(len(a.ranges), func(i int) bool {
+		return a.ranges[i].Start > ipInt
+	}) - 1
+	if i < 0 || a.ranges[i].End < ipInt {
+		return
+	}
+	r := a.ranges[i]
+	for _, cidr := range rangeToCIDR(r.Start, r.End) {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil && ipNet.Contains(parsedIP) {
+			return ASNInfo{ASN: r.ASN, Name: r.Name, Prefix: cidr}, true
+		}
+	}
+	return
+}
+
+// FindPrefix 查询ASN宣告的全部IPv4网段
+func (a *ASNQuery) FindPrefix(asn uint) (prefixes []string) {
+	prefixMap := make(map[string]struct{})
+	add := func(prefix string) {
+		if _, ok := prefixMap[prefix]; !ok {
+			prefixMap[prefix] = struct{}{}
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if a.mmdb != nil {
+		networks := a.mmdb.Networks(maxminddb.SkipAliasedNetworks)
+		for networks.Next() {
+			var record asnRecord
+			network, err := networks.Network(&record)
+			if err != nil || record.AutonomousSystemNumber != asn || network.IP.To4() == nil {
+				continue
+			}
+			add(network.String())
+		}
+		if err := networks.Err(); err != nil {
+			logging.RuntimeLog.Error(err)
+		}
+	}
+	if len(prefixes) == 0 {
+		for _, r := range a.ranges {
+			if r.ASN != asn {
+				continue
+			}
+			for _, cidr := range rangeToCIDR(r.Start, r.End) {
+				add(cidr)
+			}
+		}
+	}
+	return
+}
+
+// FormatASN ASN的格式化显示，如AS4134
+func FormatASN(asn uint) string {
+	return fmt.Sprintf("AS%d", asn)
+}
+
+// ParseASN 解析ASN（AS4134或4134）
+func ParseASN(s string) (asn uint, ok bool) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	n, err := strconv.ParseUint(strings.TrimPrefix(s, "AS"), 10, 32)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return uint(n), true
+}
+
+// rangeToCIDR 将起始、结束IP的范围转换为最少的CIDR网段
+func rangeToCIDR(start, end uint32) (cidrs []string) {
+	for start <= end {
+		// 起始IP对齐的最大网段
+		size := bits.TrailingZeros32(start)
+		if start == 0 {
+			size = 32
+		}
+		// 不超出结束IP
+		for size > 0 && uint64(start)+(uint64(1)<<size)-1 > uint64(end) {
+			size--
+		}
+		cidrs = append(cidrs, fmt.Sprintf("%s/%d", utils.UInt32ToIP(start), 32-size))
+		next := uint64(start) + (uint64(1) << size)
+		if next > uint64(end) {
+			break
+		}
+		start = uint32(next)
+	}
+	return
+}
@@ -0,0 +1,73 @@
+package custom
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestASNQuery_Find(t *testing.T) {
+	tsvFile := filepath.Join(t.TempDir(), "ip2asn-v4.tsv")
+	content := "1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n" +
+		"1.0.1.0\t1.0.3.255\t0\tNone\tNot routed\n" +
+		"1.0.4.0\t1.0.7.255\t38803\tAU\tGTELECOM-AUSTRALIA Gtelecom Pty Ltd\n" +
+		"114.80.0.0\t114.80.127.255\t4812\tCN\tCHINANET-SH-AP China Telecom (Group)\n" +
+		"114.80.160.0\t114.80.191.255\t4812\tCN\tCHINANET-SH-AP China Telecom (Group)\n"
+	if err := os.WriteFile(tsvFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ranges, err := LoadIPToASN(tsvFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &ASNQuery{ranges: ranges}
+	for ip, expected := range map[string]ASNInfo{
+		"1.0.0.1":      {ASN: 13335, Name: "CLOUDFLARENET", Prefix: "1.0.0.0/24"},
+		"1.0.6.8":      {ASN: 38803, Name: "GTELECOM-AUSTRALIA Gtelecom Pty Ltd", Prefix: "1.0.4.0/22"},
+		"114.80.170.1": {ASN: 4812, Name: "CHINANET-SH-AP China Telecom (Group)", Prefix: "114.80.160.0/19"},
+	} {
+		info, ok := a.Find(ip)
+		t.Log(ip, info)
+		if !ok || info != expected {
+			t.Errorf("%s expect %v,got %v", ip, expected, info)
+		}
+	}
+	for _, ip := range []string{"1.0.2.1", "114.80.150.1", "192.168.1.1"} {
+		if info, ok := a.Find(ip); ok {
+			t.Errorf("%s should not have asn,got %v", ip, info)
+		}
+	}
+	prefixes := a.FindPrefix(4812)
+	t.Log(prefixes)
+	if !reflect.DeepEqual(prefixes, []string{"114.80.0.0/17", "114.80.160.0/19"}) {
+		t.Errorf("unexpected prefixes:%v", prefixes)
+	}
+}
+
+func TestRangeToCIDR(t *testing.T) {
+	for _, c := range []struct {
+		start, end string
+		expected   []string
+	}{
+		{"10.0.0.0", "10.0.0.255", []string{"10.0.0.0/24"}},
+		{"10.0.0.1", "10.0.0.6", []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32"}},
+		{"0.0.0.0", "255.255.255.255", []string{"0.0.0.0/0"}},
+		{"255.255.255.255", "255.255.255.255", []string{"255.255.255.255/32"}},
+	} {
+		cidrs := rangeToCIDR(utils.IPToUInt32(c.start), utils.IPToUInt32(c.end))
+		if !reflect.DeepEqual(cidrs, c.expected) {
+			t.Errorf("%s-%s expect %v,got %v", c.start, c.end, c.expected, cidrs)
+		}
+	}
+}
+
+func TestParseASN(t *testing.T) {
+	for s, expected := range map[string]uint{"AS4134": 4134, "as4134": 4134, " 4134 ": 4134, "ASX": 0, "0": 0} {
+		asn, _ := ParseASN(s)
+		if asn != expected {
+			t.Errorf("%s expect %d,got %d", s, expected, asn)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package custom
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/oschwald/geoip2-golang"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	GeoProviderQQwry     = "qqwry"
+	GeoProviderIp2region = "ip2region"
+	GeoProviderMMDB      = "mmdb"
+)
+
+// DefaultGeoProviders 默认的IP归属地数据源及优先级
+var DefaultGeoProviders = []string{GeoProviderQQwry, GeoProviderIp2region, GeoProviderMMDB}
+
+// GeoLocation IP归属地信息：Location为显示的归属地，Country、Region、City为结构化的国家、省份（州）及城市
+type GeoLocation struct {
+	Location string
+	Country  string
+	Region   string
+	City     string
+	ISP      string
+}
+
+// GeoProvider IP归属地数据源
+type GeoProvider interface {
+	Name() string
+	Find(ip string) (GeoLocation, bool)
+}
+
+var (
+	geoProviderCache = make(map[string]GeoProvider)
+	geoProviderMutex sync.Mutex
+)
+
+// getGeoProvider 获取数据源，数据文件只在第一次使用时加载；数据文件不存在或加载失败时返回nil
+func getGeoProvider(name string) GeoProvider {
+	geoProviderMutex.Lock()
+	defer geoProviderMutex.Unlock()
+
+	if p, ok := geoProviderCache[name]; ok {
+		return p
+	}
+	var p GeoProvider
+	switch name {
+	case GeoProviderQQwry:
+		p = newQQwryProvider(filepath.Join(conf.GetRootPath(), "thirdparty/qqwry/qqwry.dat"))
+	case GeoProviderIp2region:
+		p = newIp2regionProvider(filepath.Join(conf.GetRootPath(), "thirdparty/ip2region/ip2region.xdb"))
+	case GeoProviderMMDB:
+		p = newMMDBProvider(filepath.Join(conf.GetRootPath(), "thirdparty/geolite2/GeoLite2-City.mmdb"))
+	default:
+		logging.RuntimeLog.Warningf("unknown iplocation provider:%s", name)
+		return nil
+	}
+	// 加载失败的也缓存，避免重复加载
+	geoProviderCache[name] = p
+	return p
+}
+
+// qqwryProvider 纯真IP库，只支持IPv4且没有结构化的归属地
+type qqwryProvider struct{}
+
+func newQQwryProvider(datFile string) GeoProvider {
+	if !utils.CheckFileExist(datFile) {
+		logging.RuntimeLog.Warningf("qqwry file %s not exist", datFile)
+		return nil
+	}
+	IPData.FilePath = datFile
+	if v, ok := IPData.InitIPData().(error); ok {
+		logging.RuntimeLog.Error(v)
+		logging.CLILog.Error(v)
+		return nil
+	}
+	return &qqwryProvider{}
+}
+
+func (p *qqwryProvider) Name() string {
+	return GeoProviderQQwry
+}
+
+func (p *qqwryProvider) Find(ip string) (geo GeoLocation, ok bool) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil || parsedIP.To4() == nil {
+		return
+	}
+	qqWry := NewQQwry()
+	result := qqWry.Find(parsedIP.To4().String())
+	geo.Location = strings.TrimSpace(result.Country)
+	geo.ISP = strings.TrimSpace(result.Area)
+	return geo, geo.Location != ""
+}
+
+// mmdbProvider MaxMind GeoLite2/GeoIP2 City数据库，支持IPv4及IPv6
+type mmdbProvider struct {
+	db *geoip2.Reader
+}
+
+func newMMDBProvider(mmdbFile string) GeoProvider {
+	if !utils.CheckFileExist(mmdbFile) {
+		logging.RuntimeLog.Warningf("mmdb file %s not exist", mmdbFile)
+		return nil
+	}
+	db, err := geoip2.Open(mmdbFile)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+		return nil
+	}
+	return &mmdbProvider{db: db}
+}
+
+func (p *mmdbProvider) Name() string {
+	return GeoProviderMMDB
+}
+
+func (p *mmdbProvider) Find(ip string) (geo GeoLocation, ok bool) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return
+	}
+	record, err := p.db.City(parsedIP)
+	if err != nil {
+		return
+	}
+	geo.Country = mmdbName(record.Country.Names)
+	if len(record.Subdivisions) > 0 {
+		geo.Region = mmdbName(record.Subdivisions[0].Names)
+	}
+	geo.City = mmdbName(record.City.Names)
+	geo.Location = formatGeoLocation(geo.Country, geo.Region, geo.City)
+	return geo, geo.Location != ""
+}
+
+// mmdbName 优先使用中文名称
+func mmdbName(names map[string]string) string {
+	if name, ok := names["zh-CN"]; ok {
+		return name
+	}
+	return names["en"]
+}
+
+// formatGeoLocation 生成与纯真IP库一致的显示格式：国内的IP不显示国家，如“浙江省杭州市”
+func formatGeoLocation(country, region, city string) string {
+	location := ""
+	if country != "中国" {
+		location = country
+	}
+	if region != "" && region != country {
+		location += region
+	}
+	if city != "" && city != region && city != country {
+		location += city
+	}
+	if location == "" {
+		return country
+	}
+	return location
+}
@@ -0,0 +1,116 @@
+package custom
+
+import (
+	"encoding/binary"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeGeoProvider struct {
+	name   string
+	result map[string]GeoLocation
+}
+
+func (p *fakeGeoProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeGeoProvider) Find(ip string) (geo GeoLocation, ok bool) {
+	geo, ok = p.result[ip]
+	return
+}
+
+// makeXdb 生成测试用的ip2region xdb文件
+func makeXdb(t *testing.T, segments [][3]string) string {
+	data := make([]byte, xdbHeaderLength+xdbVectorIndexLength)
+	var regionData []byte
+	regionPtr := make([]int, len(segments))
+	segmentStart := len(data)
+	for i, seg := range segments {
+		regionPtr[i] = segmentStart + len(segments)*xdbSegmentIndexSize + len(regionData)
+		regionData = append(regionData, seg[2]...)
+	}
+	for i, seg := range segments {
+		sip, eip := utils.IPToUInt32(seg[0]), utils.IPToUInt32(seg[1])
+		buf := make([]byte, xdbSegmentIndexSize)
+		binary.LittleEndian.PutUint32(buf, sip)
+		binary.LittleEndian.PutUint32(buf[4:], eip)
+		binary.LittleEndian.PutUint16(buf[8:], uint16(len(seg[2])))
+		binary.LittleEndian.PutUint32(buf[10:], uint32(regionPtr[i]))
+		p := segmentStart + i*xdbSegmentIndexSize
+		// 测试数据的每个段都在同一个/16内
+		idx := xdbHeaderLength + int(sip>>24)*xdbVectorIndexCols*xdbVectorIndexSize + int((sip>>16)&0xFF)*xdbVectorIndexSize
+		if binary.LittleEndian.Uint32(data[idx:]) == 0 {
+			binary.LittleEndian.PutUint32(data[idx:], uint32(p))
+		}
+		binary.LittleEndian.PutUint32(data[idx+4:], uint32(p))
+		data = append(data, buf...)
+	}
+	data = append(data, regionData...)
+
+	xdbFile := filepath.Join(t.TempDir(), "ip2region.xdb")
+	if err := os.WriteFile(xdbFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return xdbFile
+}
+
+func TestIp2regionProvider_Find(t *testing.T) {
+	xdbFile := makeXdb(t, [][3]string{
+		{"47.98.0.0", "47.98.127.255", "中国|0|浙江省|杭州市|阿里云"},
+		{"47.98.128.0", "47.98.255.255", "中国|0|0|0|阿里云"},
+		{"8.8.8.0", "8.8.8.255", "美国|0|0|0|Level3"},
+	})
+	p := newIp2regionProvider(xdbFile)
+	if p == nil {
+		t.Fatal("load xdb fail")
+	}
+	for ip, expected := range map[string]GeoLocation{
+		"47.98.65.38":  {Location: "浙江省杭州市", Country: "中国", Region: "浙江省", City: "杭州市", ISP: "阿里云"},
+		"47.98.200.1":  {Location: "中国", Country: "中国", ISP: "阿里云"},
+		"8.8.8.8":      {Location: "美国", Country: "美国", ISP: "Level3"},
+		"47.99.1.1":    {},
+		"2400:3200::1": {},
+	} {
+		geo, _ := p.Find(ip)
+		t.Log(ip, geo)
+		if geo != expected {
+			t.Errorf("%s expect %v,got %v", ip, expected, geo)
+		}
+	}
+}
+
+func TestIpLocation_FindGeoLocation(t *testing.T) {
+	ipl := &IpLocation{providers: []GeoProvider{
+		&fakeGeoProvider{name: GeoProviderQQwry, result: map[string]GeoLocation{
+			"47.98.65.38": {Location: "浙江省杭州市", ISP: "阿里云"},
+		}},
+		&fakeGeoProvider{name: GeoProviderMMDB, result: map[string]GeoLocation{
+			"47.98.65.38":  {Location: "浙江杭州", Country: "中国", Region: "浙江", City: "杭州"},
+			"2400:3200::1": {Location: "中国", Country: "中国"},
+		}},
+	}}
+	geo := ipl.FindGeoLocation("47.98.65.38")
+	t.Log(geo)
+	if geo != (GeoLocation{Location: "浙江省杭州市", Country: "中国", Region: "浙江", City: "杭州", ISP: "阿里云"}) {
+		t.Errorf("unexpected geo:%v", geo)
+	}
+	if location := ipl.FindPublicIP("2400:3200::1"); location != "中国" {
+		t.Errorf("unexpected location:%s", location)
+	}
+}
+
+func TestFormatGeoLocation(t *testing.T) {
+	for expected, fields := range map[string][3]string{
+		"北京市":         {"中国", "北京市", "北京市"},
+		"中国":          {"中国", "", ""},
+		"美国加利福尼亚州山景城": {"美国", "加利福尼亚州", "山景城"},
+		"新加坡":         {"新加坡", "", "新加坡"},
+	} {
+		if location := formatGeoLocation(fields[0], fields[1], fields[2]); location != expected {
+			t.Errorf("expect %s,got %s", expected, location)
+		}
+	}
+}
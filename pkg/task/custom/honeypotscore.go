@@ -0,0 +1,218 @@
+package custom
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// HoneyPotScoreThreshold 蜜罐评分不小于该值的IP认为是蜜罐
+	HoneyPotScoreThreshold = 60
+
+	honeypotScoreMax = 100
+	// honeypotProductScore 匹配到蜜罐产品的指纹
+	honeypotProductScore = 70
+	// honeypotManyPortScore 开放端口过多
+	honeypotManyPortScore  = 30
+	honeypotManyPortNumber = 20
+	// honeypotSameBannerScore 不同服务返回相同的banner
+	honeypotSameBannerScore = 30
+	honeypotSameBannerPorts = 3
+	// honeypotImpossibleScore 每一种不可能同时出现的服务组合
+	honeypotImpossibleScore = 20
+	honeypotMaxDatabase     = 4
+)
+
+// HoneyPotPortInfo 用于蜜罐评分的端口信息：Service为端口扫描识别的服务，Banner为服务的banner，Fingerprints为banner、title、server及指纹等
+type HoneyPotPortInfo struct {
+	Port         int
+	Service      string
+	Banner       string
+	Fingerprints []string
+}
+
+// HoneyPotScore 蜜罐评分及原因
+type HoneyPotScore struct {
+	Score   int
+	Reasons []string
+}
+
+// honeypotProducts 常见蜜罐产品的指纹
+var honeypotProducts = []struct {
+	Name  string
+	Regex *regexp.Regexp
+}{
+	{Name: "HFish", Regex: regexp.MustCompile(`(?i)\bhfish\b`)},
+	{Name: "T-Pot", Regex: regexp.MustCompile(`(?i)\bt-pot\b|tpotce`)},
+	{Name: "Conpot", Regex: regexp.MustCompile(`(?i)\bconpot\b|Technodrome|88111222`)},
+	{Name: "Cowrie", Regex: regexp.MustCompile(`SSH-2\.0-OpenSSH_6\.0p1 Debian-4\+deb7u2|(?i)\bcowrie\b`)},
+	{Name: "Kippo", Regex: regexp.MustCompile(`SSH-2\.0-OpenSSH_5\.1p1 Debian-5|(?i)\bkippo\b`)},
+	{Name: "Dionaea", Regex: regexp.MustCompile(`(?i)\bdionaea\b`)},
+	{Name: "Glastopf", Regex: regexp.MustCompile(`(?i)\bglastopf\b`)},
+	{Name: "OpenCanary", Regex: regexp.MustCompile(`(?i)\bopencanary\b`)},
+}
+
+// honeypotLinuxRegex banner中的Linux发行版
+var honeypotLinuxRegex = regexp.MustCompile(`(?i)ubuntu|debian|centos|red hat|fedora|alpine`)
+
+// honeypotWindowsRegex banner中的Windows服务；Samba、xrdp等在Linux上也会开放445、3389端口，不作为Windows的依据
+var honeypotWindowsRegex = regexp.MustCompile(`(?i)microsoft windows|microsoft-iis`)
+
+// honeypotServiceCategory 服务的类别：windows为Windows特有的服务，ics为工控协议，database为数据库
+var honeypotServiceCategory = map[string]string{
+	"msrpc":         "windows",
+	"modbus":        "ics",
+	"iso-tsap":      "ics",
+	"s7":            "ics",
+	"bacnet":        "ics",
+	"dnp3":          "ics",
+	"iec-104":       "ics",
+	"enip":          "ics",
+	"mysql":         "database",
+	"ms-sql-s":      "database",
+	"oracle-tns":    "database",
+	"postgresql":    "database",
+	"redis":         "database",
+	"mongodb":       "database",
+	"memcached":     "database",
+	"elasticsearch": "database",
+}
+
+// honeypotPortService 未识别服务时按端口确定服务
+var honeypotPortService = map[int]string{
+	135:   "msrpc",
+	102:   "iso-tsap",
+	502:   "modbus",
+	2404:  "iec-104",
+	20000: "dnp3",
+	44818: "enip",
+	47808: "bacnet",
+	1433:  "ms-sql-s",
+	1521:  "oracle-tns",
+	3306:  "mysql",
+	5432:  "postgresql",
+	6379:  "redis",
+	9200:  "elasticsearch",
+	11211: "memcached",
+	27017: "mongodb",
+}
+
+// ScoreHoneyPot 根据IP已开放端口的信息进行蜜罐评分：蜜罐产品指纹、开放端口过多、不同服务返回相同banner、不可能同时出现的服务组合
+func ScoreHoneyPot(ports []HoneyPotPortInfo) (s HoneyPotScore) {
+	// 蜜罐产品指纹
+	products := make(map[string]struct{})
+	for _, p := range ports {
+		for _, content := range append([]string{p.Banner}, p.Fingerprints...) {
+			for _, product := range honeypotProducts {
+				if _, ok := products[product.Name]; !ok && content != "" && product.Regex.MatchString(content) {
+					products[product.Name] = struct{}{}
+					s.add(honeypotProductScore, fmt.Sprintf("product:%s on %d", product.Name, p.Port))
+				}
+			}
+		}
+	}
+	// 开放端口过多
+	if len(ports) > honeypotManyPortNumber {
+		s.add(honeypotManyPortScore, fmt.Sprintf("open ports:%d", len(ports)))
+	}
+	// 不同服务返回相同的banner
+	bannerPorts := make(map[string][]HoneyPotPortInfo)
+	for _, p := range ports {
+		banner := strings.TrimSpace(p.Banner)
+		if banner == "" || banner == "unknown" {
+			continue
+		}
+		bannerPorts[banner] = append(bannerPorts[banner], p)
+	}
+	var banners []string
+	for banner := range bannerPorts {
+		banners = append(banners, banner)
+	}
+	sort.Strings(banners)
+	for _, banner := range banners {
+		services := make(map[string]struct{})
+		for _, p := range bannerPorts[banner] {
+			services[normalizeHoneyPotService(p.Service, p.Port)] = struct{}{}
+		}
+		if len(bannerPorts[banner]) >= honeypotSameBannerPorts && len(services) >= 2 {
+			s.add(honeypotSameBannerScore, fmt.Sprintf("same banner on %d ports", len(bannerPorts[banner])))
+			break
+		}
+	}
+	// 不可能同时出现的服务组合
+	categories := make(map[string]map[string]struct{})
+	hasLinux, hasWindows := false, false
+	for _, p := range ports {
+		service := normalizeHoneyPotService(p.Service, p.Port)
+		category := honeypotServiceCategory[service]
+		if _, ok := categories[category]; !ok {
+			categories[category] = make(map[string]struct{})
+		}
+		categories[category][service] = struct{}{}
+		if honeypotLinuxRegex.MatchString(p.Banner) {
+			hasLinux = true
+		}
+		if category == "windows" || honeypotWindowsRegex.MatchString(p.Banner) {
+			hasWindows = true
+		}
+	}
+	if hasWindows && hasLinux {
+		s.add(honeypotImpossibleScore, "windows and linux services")
+	}
+	if len(categories["ics"]) >= 2 && len(ports)-len(categories["ics"]) >= 3 {
+		s.add(honeypotImpossibleScore, fmt.Sprintf("ics protocols:%d with other services", len(categories["ics"])))
+	}
+	if len(categories["database"]) >= honeypotMaxDatabase {
+		s.add(honeypotImpossibleScore, fmt.Sprintf("database services:%d", len(categories["database"])))
+	}
+	return
+}
+
+// add 增加评分，最高为100
+func (s *HoneyPotScore) add(score int, reason string) {
+	s.Score += score
+	if s.Score > honeypotScoreMax {
+		s.Score = honeypotScoreMax
+	}
+	s.Reasons = append(s.Reasons, reason)
+}
+
+// IsHoneyPot 评分是否达到蜜罐的阈值
+func (s HoneyPotScore) IsHoneyPot() bool {
+	return s.Score >= HoneyPotScoreThreshold
+}
+
+// String 保存为IP属性的内容，格式为“评分|原因1;原因2”
+func (s HoneyPotScore) String() string {
+	return fmt.Sprintf("%d|%s", s.Score, strings.Join(s.Reasons, ";"))
+}
+
+// ParseHoneyPotScore 解析保存的IP属性内容
+func ParseHoneyPotScore(content string) (s HoneyPotScore, ok bool) {
+	arrays := strings.SplitN(content, "|", 2)
+	score, err := strconv.Atoi(arrays[0])
+	if err != nil {
+		return
+	}
+	s.Score = score
+	if len(arrays) == 2 && arrays[1] != "" {
+		s.Reasons = strings.Split(arrays[1], ";")
+	}
+	return s, true
+}
+
+// normalizeHoneyPotService 统一服务名称，未识别的服务按端口确定
+func normalizeHoneyPotService(service string, port int) string {
+	service = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(service)), "ssl/")
+	service = strings.TrimSuffix(service, "?")
+	if service == "" || service == "unknown" || service == "tcpwrapped" {
+		if s, ok := honeypotPortService[port]; ok {
+			return s
+		}
+		return fmt.Sprintf("%d", port)
+	}
+	return service
+}
@@ -0,0 +1,64 @@
+package custom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScoreHoneyPot(t *testing.T) {
+	// 正常的web服务器
+	s := ScoreHoneyPot([]HoneyPotPortInfo{
+		{Port: 22, Service: "ssh", Banner: "SSH-2.0-OpenSSH_8.2p1 Ubuntu-4ubuntu0.5"},
+		{Port: 80, Service: "http", Fingerprints: []string{"nginx", "Example"}},
+		{Port: 443, Service: "ssl/http", Fingerprints: []string{"nginx", "Example"}},
+		{Port: 445, Service: "netbios-ssn", Banner: "Samba smbd 4.6.2"},
+		{Port: 3306, Service: "mysql"},
+	})
+	t.Log(s)
+	if s.Score != 0 {
+		t.Errorf("unexpected score:%v", s)
+	}
+	// 蜜罐产品的指纹
+	s = ScoreHoneyPot([]HoneyPotPortInfo{
+		{Port: 22, Service: "ssh", Banner: "SSH-2.0-OpenSSH_6.0p1 Debian-4+deb7u2"},
+		{Port: 8080, Service: "http", Fingerprints: []string{"HFish - 扩展企业安全测试主动诱导型开源蜜罐框架系统"}},
+	})
+	t.Log(s)
+	if !s.IsHoneyPot() || !reflect.DeepEqual(s.Reasons, []string{"product:Cowrie on 22", "product:HFish on 8080"}) {
+		t.Errorf("unexpected score:%v", s)
+	}
+	// 工控协议与其它服务、Windows与Linux服务同时出现，不同服务返回相同的banner
+	s = ScoreHoneyPot([]HoneyPotPortInfo{
+		{Port: 21, Service: "ftp", Banner: "Ubuntu"},
+		{Port: 23, Service: "telnet", Banner: "Ubuntu"},
+		{Port: 25, Service: "smtp", Banner: "Ubuntu"},
+		{Port: 102, Service: "unknown"},
+		{Port: 135, Service: "tcpwrapped"},
+		{Port: 502, Service: "modbus"},
+	})
+	t.Log(s)
+	if s.Score != 70 || !s.IsHoneyPot() {
+		t.Errorf("unexpected score:%v", s)
+	}
+	// 开放端口过多
+	var ports []HoneyPotPortInfo
+	for i := 0; i < 30; i++ {
+		ports = append(ports, HoneyPotPortInfo{Port: 8000 + i, Service: "http"})
+	}
+	s = ScoreHoneyPot(ports)
+	t.Log(s)
+	if s.Score != honeypotManyPortScore || s.IsHoneyPot() {
+		t.Errorf("unexpected score:%v", s)
+	}
+}
+
+func TestParseHoneyPotScore(t *testing.T) {
+	s := HoneyPotScore{Score: 90, Reasons: []string{"product:HFish on 8080", "open ports:30"}}
+	parsed, ok := ParseHoneyPotScore(s.String())
+	if !ok || !reflect.DeepEqual(parsed, s) {
+		t.Errorf("unexpected score:%v", parsed)
+	}
+	if _, ok = ParseHoneyPotScore("HFish"); ok {
+		t.Errorf("invalid content should not be parsed")
+	}
+}
@@ -0,0 +1,111 @@
+package custom
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"net"
+	"os"
+	"strings"
+)
+
+// ip2region xdb格式：https://github.com/lionsoul2014/ip2region
+const (
+	xdbHeaderLength      = 256
+	xdbVectorIndexCols   = 256
+	xdbVectorIndexSize   = 8
+	xdbSegmentIndexSize  = 14
+	xdbVectorIndexLength = xdbVectorIndexCols * xdbVectorIndexCols * xdbVectorIndexSize
+)
+
+// Ip2region ip2region的xdb数据，全部加载到内存中查询
+type Ip2region struct {
+	data []byte
+}
+
+// NewIp2region 加载ip2region的xdb文件
+func NewIp2region(xdbFile string) (*Ip2region, error) {
+	data, err := os.ReadFile(xdbFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < xdbHeaderLength+xdbVectorIndexLength {
+		return nil, fmt.Errorf("invalid xdb file:%s", xdbFile)
+	}
+	return &Ip2region{data: data}, nil
+}
+
+// Search 查询IPv4的归属地，格式为：国家|区域|省份|城市|ISP
+func (x *Ip2region) Search(ip uint32) (region string) {
+	il0, il1 := (ip>>24)&0xFF, (ip>>16)&0xFF
+	idx := xdbHeaderLength + il0*xdbVectorIndexCols*xdbVectorIndexSize + il1*xdbVectorIndexSize
+	sPtr := binary.LittleEndian.Uint32(x.data[idx:])
+	ePtr := binary.LittleEndian.Uint32(x.data[idx+4:])
+	if ePtr < sPtr || int(ePtr)+xdbSegmentIndexSize > len(x.data) {
+		return
+	}
+	l, h := 0, int((ePtr-sPtr)/xdbSegmentIndexSize)
+	for l <= h {
+		m := (l + h) >> 1
+		p := int(sPtr) + m*xdbSegmentIndexSize
+		sip := binary.LittleEndian.Uint32(x.data[p:])
+		eip := binary.LittleEndian.Uint32(x.data[p+4:])
+		if ip < sip {
+			h = m - 1
+		} else if ip > eip {
+			l = m + 1
+		} else {
+			dataLen := int(binary.LittleEndian.Uint16(x.data[p+8:]))
+			dataPtr := int(binary.LittleEndian.Uint32(x.data[p+10:]))
+			if dataPtr+dataLen > len(x.data) {
+				return
+			}
+			return string(x.data[dataPtr : dataPtr+dataLen])
+		}
+	}
+	return
+}
+
+// ip2regionProvider ip2region的数据源，只支持IPv4
+type ip2regionProvider struct {
+	xdb *Ip2region
+}
+
+func newIp2regionProvider(xdbFile string) GeoProvider {
+	if !utils.CheckFileExist(xdbFile) {
+		logging.RuntimeLog.Warningf("ip2region file %s not exist", xdbFile)
+		return nil
+	}
+	xdb, err := NewIp2region(xdbFile)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+		return nil
+	}
+	return &ip2regionProvider{xdb: xdb}
+}
+
+func (p *ip2regionProvider) Name() string {
+	return GeoProviderIp2region
+}
+
+func (p *ip2regionProvider) Find(ip string) (geo GeoLocation, ok bool) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil || parsedIP.To4() == nil {
+		return
+	}
+	fields := strings.Split(p.xdb.Search(binary.BigEndian.Uint32(parsedIP.To4())), "|")
+	if len(fields) < 5 {
+		return
+	}
+	// 未知的字段为0
+	for i := range fields {
+		if fields[i] == "0" {
+			fields[i] = ""
+		}
+	}
+	geo.Country, geo.Region, geo.City, geo.ISP = fields[0], fields[2], fields[3], fields[4]
+	geo.Location = formatGeoLocation(geo.Country, geo.Region, geo.City)
+	return geo, geo.Location != ""
+}
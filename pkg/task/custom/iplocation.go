@@ -18,26 +18,52 @@ type IpLocation struct {
 	customMap  map[string]string
 	customBMap map[string]string
 	customCMap map[string]string
+	providers  []GeoProvider
 }
 
-// NewIPLocation 创建iplocation对象
-func NewIPLocation() *IpLocation {
+// NewIPLocation 创建iplocation对象，providers为按优先级排列的归属地数据源，为空时使用默认的数据源
+func NewIPLocation(providers ...string) *IpLocation {
 	ipl := &IpLocation{
 		customMap:  make(map[string]string),
 		customBMap: make(map[string]string),
 		customCMap: make(map[string]string),
 	}
 	ipl.loadCustomIP()
-	ipl.loadQQwry()
+	if len(providers) == 0 {
+		providers = DefaultGeoProviders
+	}
+	for _, name := range providers {
+		if p := getGeoProvider(strings.TrimSpace(name)); p != nil {
+			ipl.providers = append(ipl.providers, p)
+		}
+	}
 	return ipl
 }
 
-// FindPublicIP 查询纯真数据库获取公网IP归属地
+// FindPublicIP 查询公网IP归属地
 func (ipl *IpLocation) FindPublicIP(ip string) string {
-	qqWry := NewQQwry()
-	result := qqWry.Find(ip)
+	return ipl.FindGeoLocation(ip).Location
+}
 
-	return result.Country
+// FindGeoLocation 按数据源的优先级查询公网IP归属地：归属地使用第一个有结果的数据源，结构化的国家、省份及城市使用第一个有国家信息的数据源
+func (ipl *IpLocation) FindGeoLocation(ip string) (geo GeoLocation) {
+	for _, p := range ipl.providers {
+		result, ok := p.Find(ip)
+		if !ok {
+			continue
+		}
+		if geo.Location == "" {
+			geo.Location = result.Location
+			geo.ISP = result.ISP
+		}
+		if geo.Country == "" && result.Country != "" {
+			geo.Country, geo.Region, geo.City = result.Country, result.Region, result.City
+		}
+		if geo.Location != "" && geo.Country != "" {
+			break
+		}
+	}
+	return
 }
 
 // FindCustomIP 查询自定义IP归属地
@@ -62,19 +88,6 @@ func (ipl *IpLocation) FindCustomIP(ip string) string {
 	return ""
 }
 
-// loadQQwry 加载纯真IP数据库
-func (ipl *IpLocation) loadQQwry() {
-	IPData.FilePath = filepath.Join(conf.GetRootPath(), "thirdparty/qqwry/qqwry.dat")
-	res := IPData.InitIPData()
-
-	if v, ok := res.(error); ok {
-		logging.RuntimeLog.Error(v)
-		logging.CLILog.Error(v)
-	} else {
-		//logging.RuntimeLog.Infof("纯真IP库加载完成,共加载:%d 条 Domain 记录", IPData.IPNum)
-	}
-}
-
 // loadCustomIP 加载自定义IP归属地库
 func (ipl *IpLocation) loadCustomIP() {
 	content, err := os.ReadFile(filepath.Join(conf.GetRootPath(), "thirdparty/custom/iplocation-custom-B.txt"))
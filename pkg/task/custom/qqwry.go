@@ -3,10 +3,7 @@ package custom
 // forked from https://github.com/freshcn/qqwry
 
 import (
-	"bytes"
-	"compress/zlib"
 	"encoding/binary"
-	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"io"
 	"net"
@@ -55,96 +52,27 @@ type Response struct {
 // IPData IP库的数据
 var IPData fileData
 
-// @ref https://zhangzifan.com/update-qqwry-dat.html
+// InitIPData 初始化ip库数据到内存中
+func (f *fileData) InitIPData() (rs interface{}) {
+	var tmpData []byte
+	var err error
 
-func getKey() (uint32, error) {
-	resp, err := http.Get("http://update.cz88.net/ip/copywrite.rar")
+	// 打开文件句柄：不再从网络下载，离线环境下请手工更新数据文件
+	f.Path, err = os.OpenFile(f.FilePath, os.O_RDONLY, 0400)
 	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-
-	if body, err := io.ReadAll(resp.Body); err != nil {
-		return 0, err
-	} else {
-		// @see https://stackoverflow.com/questions/34078427/how-to-read-packed-binary-data-in-go
-		return binary.LittleEndian.Uint32(body[5*4:]), nil
+		logging.RuntimeLog.Info(err)
+		logging.CLILog.Info(err)
+		rs = err
+		return
 	}
-}
+	defer f.Path.Close()
 
-func GetOnline() ([]byte, error) {
-	resp, err := http.Get("http://update.cz88.net/ip/qqwry.rar")
+	tmpData, err = io.ReadAll(f.Path)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if body, err := io.ReadAll(resp.Body); err != nil {
-		return nil, err
-	} else {
-		if key, err := getKey(); err != nil {
-			return nil, err
-		} else {
-			for i := 0; i < 0x200; i++ {
-				key = key * 0x805
-				key++
-				key = key & 0xff
-
-				body[i] = byte(uint32(body[i]) ^ key)
-			}
-
-			reader, err := zlib.NewReader(bytes.NewReader(body))
-			if err != nil {
-				return nil, err
-			}
-
-			return io.ReadAll(reader)
-		}
-	}
-}
-
-// InitIPData 初始化ip库数据到内存中
-func (f *fileData) InitIPData() (rs interface{}) {
-	var tmpData []byte
-
-	// 判断文件是否存在
-	_, err := os.Stat(f.FilePath)
-	if err != nil && os.IsNotExist(err) {
-		msg := "文件不存在，尝试从网络获取最新纯真 Domain 库"
-		logging.RuntimeLog.Info(msg)
-		logging.CLILog.Info(msg)
-		tmpData, err = GetOnline()
-		if err != nil {
-			logging.RuntimeLog.Info(err)
-			logging.CLILog.Info(err)
-			rs = err
-			return
-		} else {
-			if err := os.WriteFile(f.FilePath, tmpData, 0644); err == nil {
-				msg = fmt.Sprintf("已将最新的纯真 Domain 库保存到本地 %s ", f.FilePath)
-				logging.RuntimeLog.Info(msg)
-				logging.CLILog.Info(msg)
-			}
-		}
-	} else {
-		// 打开文件句柄
-		// logging.RuntimeLog.Infof("从本地数据库文件 %s 打开\n", f.FilePath)
-		f.Path, err = os.OpenFile(f.FilePath, os.O_RDONLY, 0400)
-		if err != nil {
-			logging.RuntimeLog.Info(err)
-			logging.CLILog.Info(err)
-			rs = err
-			return
-		}
-		defer f.Path.Close()
-
-		tmpData, err = io.ReadAll(f.Path)
-		if err != nil {
-			logging.RuntimeLog.Info(err)
-			logging.CLILog.Info(err)
-			rs = err
-			return
-		}
+		logging.RuntimeLog.Info(err)
+		logging.CLILog.Info(err)
+		rs = err
+		return
 	}
 
 	f.Data = tmpData
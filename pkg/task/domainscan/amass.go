@@ -0,0 +1,65 @@
+package domainscan
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Amass 导入amass的子域名结果
+type Amass struct {
+}
+
+type amassJSONResult struct {
+	Name      string `json:"name"`
+	Addresses []struct {
+		IP string `json:"ip"`
+	} `json:"addresses"`
+}
+
+// amassGraphRegex amass v4的输出：www.example.com (FQDN) --> a_record --> 1.2.3.4 (IPAddress)
+var amassGraphRegex = regexp.MustCompile(`^(\S+) \(FQDN\) --> (\w+) --> (\S+) \((\w+)\)$`)
+
+// ParseContentResult 解析amass的结果，支持-json、-o的文本输出（“域名”或“域名 IP,IP”）及v4的关系图输出
+func (a *Amass) ParseContentResult(content []byte) (result Result) {
+	result.DomainResult = make(map[string]*DomainResult)
+
+	for _, l := range strings.Split(string(content), "\n") {
+		line := strings.TrimSpace(strings.Trim(l, "\r"))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			var r amassJSONResult
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				continue
+			}
+			var ips []string
+			for _, addr := range r.Addresses {
+				ips = append(ips, addr.IP)
+			}
+			addImportDomain(&result, r.Name, "amass", ips, nil)
+		} else if m := amassGraphRegex.FindStringSubmatch(line); len(m) == 5 {
+			switch m[2] {
+			case "a_record":
+				addImportDomain(&result, m[1], "amass", []string{m[3]}, nil)
+			case "cname_record":
+				addImportDomain(&result, m[1], "amass", nil, []string{m[3]})
+			default:
+				addImportDomain(&result, m[1], "amass", nil, nil)
+			}
+			// 关系的另一端也可能为子域名
+			if m[4] == "FQDN" && m[2] != "cname_record" {
+				addImportDomain(&result, m[3], "amass", nil, nil)
+			}
+		} else {
+			fields := strings.Fields(line)
+			if len(fields) > 1 {
+				addImportDomain(&result, fields[0], "amass", strings.Split(fields[1], ","), nil)
+			} else {
+				addImportDomain(&result, fields[0], "amass", nil, nil)
+			}
+		}
+	}
+	return
+}
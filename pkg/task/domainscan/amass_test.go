@@ -0,0 +1,27 @@
+package domainscan
+
+import "testing"
+
+func TestAmass_ParseContentResult(t *testing.T) {
+	content := `{"name":"www.example.com","domain":"example.com","addresses":[{"ip":"93.184.216.34","cidr":"93.184.216.0/24","asn":15133,"desc":"EDGECAST"}],"tag":"dns","sources":["DNS"]}
+mail.example.com
+vpn.example.com 192.168.1.1,192.168.1.2
+api.example.com (FQDN) --> a_record --> 192.168.1.3 (IPAddress)
+cdn.example.com (FQDN) --> cname_record --> cdn.example.net (FQDN)
+example.com (FQDN) --> node --> dev.example.com (FQDN)
+`
+	a := Amass{}
+	result := a.ParseContentResult([]byte(content))
+	for domain, r := range result.DomainResult {
+		t.Log(domain, r.DomainAttrs)
+	}
+	if len(result.DomainResult) != 7 {
+		t.Errorf("expected 7 domains,got %d", len(result.DomainResult))
+	}
+	if attrs := result.DomainResult["vpn.example.com"].DomainAttrs; len(attrs) != 2 || attrs[0].Tag != "A" {
+		t.Errorf("invalid attrs:%v", attrs)
+	}
+	if attrs := result.DomainResult["cdn.example.com"].DomainAttrs; len(attrs) != 1 || attrs[0].Tag != "CNAME" {
+		t.Errorf("invalid attrs:%v", attrs)
+	}
+}
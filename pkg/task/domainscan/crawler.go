@@ -44,7 +44,7 @@ func NewCrawler(config Config) *Crawler {
 // Do 执行爬虫获取子域名
 func (c *Crawler) Do() {
 	c.Result.DomainResult = make(map[string]*DomainResult)
-	swg := sizedwaitgroup.New(crawlerThreadNumber[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(crawlerThreadNumber[conf.GetWorkerPerformanceMode()])
 	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
 
 	for _, line := range strings.Split(c.Config.Target, ",") {
@@ -105,6 +105,25 @@ func (c *Crawler) RunCrawler(domainUrl string) {
 		len(result.ReqList), len(result.AllReqList), len(result.SubDomainList), len(result.AllDomainList)))
 	// 结果解析
 	c.parseResult(result.SubDomainList)
+	c.parseRequest(result.AllReqList)
+}
+
+// parseRequest 保存爬虫获取的请求，供JS分析等使用
+func (c *Crawler) parseRequest(reqList []*model2.Request) {
+	c.Result.Lock()
+	defer c.Result.Unlock()
+
+	for _, req := range reqList {
+		if req.URL == nil {
+			continue
+		}
+		c.Result.ReqResponseList = append(c.Result.ReqResponseList, UrlResponse{
+			URL:      req.URL.String(),
+			Method:   req.Method,
+			Headers:  req.Headers,
+			PostData: req.PostData,
+		})
+	}
 }
 
 // parseResult 解析子域名枚举结果文件
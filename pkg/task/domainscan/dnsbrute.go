@@ -0,0 +1,203 @@
+package domainscan
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/remeh/sizedwaitgroup"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// wildcardProbeNumber 检测泛解析时查询的随机子域名数量
+const wildcardProbeNumber = 5
+
+// DNSBrute 子域名爆破：使用resolver.txt中的DNS服务器查询字典中的子域名，并过滤泛解析的结果
+type DNSBrute struct {
+	Config Config
+	Result Result
+}
+
+// Wildcard 一个域名的泛解析检测结果，记录随机子域名解析得到的IP及CNAME作为泛解析的特征
+type Wildcard struct {
+	sync.RWMutex
+	Zone       string
+	IsWildcard bool
+	ips        map[string]struct{}
+	cnames     map[string]struct{}
+}
+
+// NewDNSBrute 创建子域名爆破对象
+func NewDNSBrute(config Config) *DNSBrute {
+	return &DNSBrute{Config: config}
+}
+
+// Do 执行子域名爆破
+func (b *DNSBrute) Do() {
+	b.Result.DomainResult = make(map[string]*DomainResult)
+
+	pool, err := newBruteResolverPool()
+	if err != nil {
+		logging.RuntimeLog.Errorf("create resolver pool fail:%v", err)
+		logging.CLILog.Errorf("create resolver pool fail:%v", err)
+		return
+	}
+	defer pool.Close()
+	words, err := loadBruteWordlist(filepath.Join(conf.GetRootPath(), "thirdparty/dict", conf.GlobalWorkerConfig().Domainscan.Wordlist))
+	if err != nil {
+		logging.RuntimeLog.Errorf("load wordlist fail:%v", err)
+		logging.CLILog.Errorf("load wordlist fail:%v", err)
+		return
+	}
+
+	swg := sizedwaitgroup.New(dnsBruteThreadNumber[conf.GetWorkerPerformanceMode()])
+	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
+	for _, line := range strings.Split(b.Config.Target, ",") {
+		domain := strings.TrimSpace(line)
+		if domain == "" || utils.CheckIPV4(domain) || utils.CheckIPV4Subnet(domain) {
+			continue
+		}
+		if blackDomain.CheckBlack(domain) {
+			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+			continue
+		}
+		swg.Add()
+		go func(d string) {
+			defer swg.Done()
+			b.RunDNSBrute(pool, d, words)
+		}(domain)
+	}
+	swg.Wait()
+}
+
+// RunDNSBrute 对一个域名进行子域名爆破
+func (b *DNSBrute) RunDNSBrute(pool *ResolverPool, domain string, words []string) {
+	var subdomains []string
+	for _, word := range words {
+		subdomains = append(subdomains, fmt.Sprintf("%s.%s", word, domain))
+	}
+	resolveSubdomains(pool, domain, subdomains, &b.Result)
+}
+
+// newBruteResolverPool 使用worker配置的DNS服务器及限速创建解析池
+func newBruteResolverPool() (*ResolverPool, error) {
+	conf.GlobalWorkerConfig().ReloadConfig()
+	domainConfig := conf.GlobalWorkerConfig().Domainscan
+	rate := domainConfig.BruteRate
+	if rate <= 0 {
+		rate = dnsBruteRate[conf.GetWorkerPerformanceMode()]
+	}
+	return NewResolverPool(filepath.Join(conf.GetRootPath(), "thirdparty/dict", domainConfig.Resolver), domainConfig.ResolverCheckDomain, rate)
+}
+
+// resolveSubdomains 解析一个域名下的子域名，过滤泛解析的结果后将存在的子域名加入到结果中
+func resolveSubdomains(pool *ResolverPool, zone string, subdomains []string, result *Result) {
+	wildcard := DetectWildcard(pool, zone)
+	if wildcard.IsWildcard {
+		logging.RuntimeLog.Infof("%s has wildcard dns record,filter results by answer", zone)
+	}
+	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
+	swg := sizedwaitgroup.New(dnsBruteRunnerThreads[conf.GetWorkerPerformanceMode()])
+	for _, subdomain := range subdomains {
+		swg.Add()
+		go func(subdomain string) {
+			defer swg.Done()
+			answer, err := pool.Resolve(subdomain)
+			if err != nil || !answer.Exist() {
+				return
+			}
+			if wildcard.IsWildcard && wildcard.Match(pool, answer) {
+				return
+			}
+			if blackDomain.CheckBlack(subdomain) {
+				logging.RuntimeLog.Warningf("%s is in blacklist,skip...", subdomain)
+				return
+			}
+			if !result.HasDomain(subdomain) {
+				result.SetDomain(subdomain)
+			}
+		}(subdomain)
+	}
+	swg.Wait()
+}
+
+// loadBruteWordlist 加载子域名字典，去除空行及重复的子域名
+func loadBruteWordlist(wordlistFile string) (words []string, err error) {
+	content, err := os.ReadFile(wordlistFile)
+	if err != nil {
+		return
+	}
+	wordsMap := make(map[string]struct{})
+	for _, line := range strings.Split(string(content), "\n") {
+		word := strings.ToLower(strings.Trim(strings.TrimSpace(line), "."))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if _, ok := wordsMap[word]; !ok {
+			wordsMap[word] = struct{}{}
+			words = append(words, word)
+		}
+	}
+	return
+}
+
+// DetectWildcard 查询多个随机子域名，检测域名是否存在泛解析
+func DetectWildcard(pool *ResolverPool, zone string) *Wildcard {
+	w := &Wildcard{Zone: zone, ips: make(map[string]struct{}), cnames: make(map[string]struct{})}
+	for i := 0; i < wildcardProbeNumber; i++ {
+		w.probe(pool)
+	}
+	return w
+}
+
+// probe 查询一个随机子域名，将解析结果加入泛解析的特征
+func (w *Wildcard) probe(pool *ResolverPool) {
+	answer, err := pool.Resolve(fmt.Sprintf("%s.%s", utils.GetRandomString2(16), w.Zone))
+	if err != nil || !answer.Exist() {
+		return
+	}
+	w.Lock()
+	defer w.Unlock()
+
+	w.IsWildcard = true
+	for _, ip := range answer.A {
+		w.ips[ip] = struct{}{}
+	}
+	for _, cname := range answer.CNAME {
+		w.cnames[cname] = struct{}{}
+	}
+}
+
+// matchAnswer 解析结果是否与泛解析的特征相同：CNAME相同，或者A记录全部为泛解析的IP
+func (w *Wildcard) matchAnswer(answer DNSAnswer) bool {
+	w.RLock()
+	defer w.RUnlock()
+
+	for _, cname := range answer.CNAME {
+		if _, ok := w.cnames[cname]; ok {
+			return true
+		}
+	}
+	if len(answer.A) == 0 {
+		return false
+	}
+	for _, ip := range answer.A {
+		if _, ok := w.ips[ip]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Match 解析结果是否为泛解析；泛解析的IP可能轮换，不匹配时再查询随机子域名补充特征后重新检查
+func (w *Wildcard) Match(pool *ResolverPool, answer DNSAnswer) bool {
+	if w.matchAnswer(answer) {
+		return true
+	}
+	w.probe(pool)
+	return w.matchAnswer(answer)
+}
@@ -0,0 +1,180 @@
+package domainscan
+
+import (
+	"github.com/miekg/dns"
+	"net"
+	"strings"
+	"testing"
+)
+
+// startTestDNSServer 启动本地的测试DNS服务器：wildcard.test为泛解析，normal.test只有www和mail，
+// takeover.test的子域名CNAME指向云服务，zone.test允许域传送，nsec.test可被NSEC遍历，
+// blacklies.test返回最小化的NSEC记录
+func startTestDNSServer(t *testing.T) (addr string, shutdown func()) {
+	records := map[string]string{
+		defaultResolverCheckDomain + ".": "10.0.0.1",
+		"www.normal.test.":               "10.0.1.1",
+		"mail.normal.test.":              "10.0.1.2",
+		"www.wildcard.test.":             "10.0.2.100",
+		"nemo-test.github.io.":           "127.0.0.1",
+		"claimed.cloudapp.net.":          "10.0.3.1",
+		"ns1.zone.test.":                 "127.0.0.1",
+		"www.zone.test.":                 "10.0.4.1",
+		"oa.zone.test.":                  "10.0.4.2",
+		"ns1.nsec.test.":                 "127.0.0.1",
+		"ns1.blacklies.test.":            "127.0.0.1",
+	}
+	nsRecords := map[string]string{
+		"zone.test.":      "ns1.zone.test.",
+		"nsec.test.":      "ns1.nsec.test.",
+		"blacklies.test.": "ns1.blacklies.test.",
+	}
+	nsecChain := map[string]string{
+		"nsec.test.":           "*.nsec.test.",
+		"*.nsec.test.":         "admin.nsec.test.",
+		"admin.nsec.test.":     "vpn.nsec.test.",
+		"vpn.nsec.test.":       "nsec.test.",
+		"blacklies.test.":      `\000.blacklies.test.`,
+		`\000.blacklies.test.`: `\000.\000.blacklies.test.`,
+	}
+	cnames := map[string]string{
+		"dangling.takeover.test.": "gone.cloudapp.net.",
+		"pages.takeover.test.":    "nemo-test.github.io.",
+		"claimed.takeover.test.":  "claimed.cloudapp.net.",
+	}
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		name := strings.ToLower(r.Question[0].Name)
+		header := dns.RR_Header{Name: name, Class: dns.ClassINET, Ttl: 60}
+		switch r.Question[0].Qtype {
+		case dns.TypeNS:
+			if ns, ok := nsRecords[name]; ok {
+				header.Rrtype = dns.TypeNS
+				m.Answer = append(m.Answer, &dns.NS{Hdr: header, Ns: ns})
+			}
+			w.WriteMsg(m)
+			return
+		case dns.TypeAXFR:
+			if name != "zone.test." {
+				m.Rcode = dns.RcodeRefused
+				w.WriteMsg(m)
+				return
+			}
+			header.Rrtype = dns.TypeSOA
+			soa := &dns.SOA{Hdr: header, Ns: "ns1.zone.test.", Mbox: "admin.zone.test.", Serial: 1}
+			m.Answer = append(m.Answer, soa)
+			for _, d := range []string{"www.zone.test.", "oa.zone.test.", "ns1.zone.test."} {
+				m.Answer = append(m.Answer, &dns.A{Hdr: dns.RR_Header{Name: d, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP(records[d])})
+			}
+			m.Answer = append(m.Answer, soa)
+			w.WriteMsg(m)
+			return
+		case dns.TypeNSEC:
+			if next, ok := nsecChain[name]; ok {
+				header.Rrtype = dns.TypeNSEC
+				m.Answer = append(m.Answer, &dns.NSEC{Hdr: header, NextDomain: next, TypeBitMap: []uint16{dns.TypeA, dns.TypeNSEC}})
+			}
+			w.WriteMsg(m)
+			return
+		}
+		for {
+			target, ok := cnames[name]
+			if !ok {
+				break
+			}
+			m.Answer = append(m.Answer, &dns.CNAME{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 60}, Target: target})
+			name = target
+		}
+		if ip, ok := records[name]; ok {
+			m.Answer = append(m.Answer, &dns.A{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP(ip)})
+		} else if strings.HasSuffix(name, ".wildcard.test.") {
+			m.Answer = append(m.Answer, &dns.A{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60}, A: net.ParseIP("10.0.2.1")})
+		} else {
+			m.Rcode = dns.RcodeNameError
+		}
+		w.WriteMsg(m)
+	})
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 域传送使用TCP，监听相同的端口
+	listener, err := net.Listen("tcp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{}, 2)
+	server := &dns.Server{PacketConn: conn, Handler: handler, NotifyStartedFunc: func() { started <- struct{}{} }}
+	tcpServer := &dns.Server{Listener: listener, Handler: handler, NotifyStartedFunc: func() { started <- struct{}{} }}
+	go server.ActivateAndServe()
+	go tcpServer.ActivateAndServe()
+	<-started
+	<-started
+	return conn.LocalAddr().String(), func() {
+		server.Shutdown()
+		tcpServer.Shutdown()
+	}
+}
+
+func TestResolverPool_Resolve(t *testing.T) {
+	addr, shutdown := startTestDNSServer(t)
+	defer shutdown()
+
+	// 127.0.0.1:1不可用，应被排除
+	pool, err := newResolverPoolByAddrs([]string{addr, "127.0.0.1:1"}, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if pool.Size() != 1 {
+		t.Errorf("expect 1 resolver,got %d", pool.Size())
+	}
+	answer, err := pool.Resolve("www.normal.test")
+	t.Log(answer, err)
+	if err != nil || !answer.Exist() || answer.A[0] != "10.0.1.1" {
+		t.Errorf("resolve www.normal.test fail")
+	}
+	answer, err = pool.Resolve("none.normal.test")
+	t.Log(answer, err)
+	if err != nil || answer.Exist() {
+		t.Errorf("none.normal.test should not exist")
+	}
+	// 内网DNS服务器无法解析检查的域名时仍可使用
+	intranetPool, err := newResolverPoolByAddrs([]string{addr}, "intranet.test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer intranetPool.Close()
+	if intranetPool.Size() != 1 {
+		t.Errorf("expect 1 resolver,got %d", intranetPool.Size())
+	}
+}
+
+func TestDNSBrute_RunDNSBrute(t *testing.T) {
+	addr, shutdown := startTestDNSServer(t)
+	defer shutdown()
+
+	pool, err := newResolverPoolByAddrs([]string{addr}, "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	words := []string{"www", "mail", "vpn", "test"}
+	b := NewDNSBrute(Config{})
+	b.Result.DomainResult = make(map[string]*DomainResult)
+	b.RunDNSBrute(pool, "normal.test", words)
+	b.RunDNSBrute(pool, "wildcard.test", words)
+	for domain := range b.Result.DomainResult {
+		t.Log(domain)
+	}
+	for _, d := range []string{"www.normal.test", "mail.normal.test", "www.wildcard.test"} {
+		if !b.Result.HasDomain(d) {
+			t.Errorf("%s not found", d)
+		}
+	}
+	if len(b.Result.DomainResult) != 3 {
+		t.Errorf("wildcard results not filtered:%d", len(b.Result.DomainResult))
+	}
+}
@@ -0,0 +1,360 @@
+package domainscan
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/remeh/sizedwaitgroup"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// JSCategoryEndpoint API路径
+	JSCategoryEndpoint = "endpoint"
+	// JSCategorySecret 密钥、令牌
+	JSCategorySecret = "secret"
+	// JSCategoryBucket 云存储桶
+	JSCategoryBucket = "bucket"
+	// JSCategoryIP 内网IP
+	JSCategoryIP = "ip"
+	// JSCategorySourceMap 暴露的source map
+	JSCategorySourceMap = "sourcemap"
+
+	// jsHttpTimeout 获取页面及JS的超时时间
+	jsHttpTimeout = 10 * time.Second
+	// jsMaxBodySize 读取JS文件的最大长度
+	jsMaxBodySize = 5 * 1024 * 1024
+	// jsMaxPages 每个域名获取页面的最大数量
+	jsMaxPages = 50
+	// jsMaxScripts 每个域名分析JS文件的最大数量
+	jsMaxScripts = 200
+)
+
+var (
+	jsScriptSrcRegex = regexp.MustCompile(`(?i)<script[^>]+src\s*=\s*["']([^"']+)["']`)
+	jsSourceMapRegex = regexp.MustCompile(`//[#@]\s*sourceMappingURL=(\S+)`)
+)
+
+// JSRule JS分析的规则：正则匹配的内容（或指定的分组），可设置最小的信息熵以过滤误报；finding为true的结果保存为漏洞
+type JSRule struct {
+	Name       string  `json:"name"`
+	Category   string  `json:"category"`
+	Regex      string  `json:"regex"`
+	Group      int     `json:"group"`
+	MinEntropy float64 `json:"entropy"`
+	IsFinding  bool    `json:"finding"`
+
+	regex *regexp.Regexp
+}
+
+// JSFinding JS分析的结果
+type JSFinding struct {
+	Domain    string
+	Url       string
+	Category  string
+	Name      string
+	Content   string
+	IsFinding bool
+}
+
+// sourceMap source map中的源文件
+type sourceMap struct {
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent"`
+}
+
+// JSAnalysis JS分析：获取爬虫页面引用的JS文件（及暴露的source map），提取API路径、子域名、云存储桶、密钥及内网IP
+type JSAnalysis struct {
+	Config Config
+	Result Result
+	// ReqResponseList 爬虫获取的请求
+	ReqResponseList []UrlResponse
+	Findings        []JSFinding
+
+	rules  []JSRule
+	client *http.Client
+	mutex  sync.Mutex
+}
+
+// NewJSAnalysis 创建JS分析对象
+func NewJSAnalysis(config Config) *JSAnalysis {
+	j := &JSAnalysis{
+		Config: config,
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+			Timeout:   jsHttpTimeout,
+		},
+	}
+	rules, err := LoadJSRule(filepath.Join(conf.GetRootPath(), "thirdparty/custom/js_rule.json"))
+	if err != nil {
+		logging.RuntimeLog.Errorf("load js rule fail:%v", err)
+	}
+	j.rules = rules
+	return j
+}
+
+// LoadJSRule 加载JS分析的规则
+func LoadJSRule(ruleFile string) (rules []JSRule, err error) {
+	content, err := os.ReadFile(ruleFile)
+	if err != nil {
+		return
+	}
+	var allRules []JSRule
+	if err = json.Unmarshal(content, &allRules); err != nil {
+		return
+	}
+	for _, r := range allRules {
+		if r.regex, err = regexp.Compile(r.Regex); err != nil {
+			logging.RuntimeLog.Errorf("compile js rule %s fail:%v", r.Name, err)
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Do 对每个目标域名执行JS分析
+func (j *JSAnalysis) Do() {
+	j.Result.DomainResult = make(map[string]*DomainResult)
+	swg := sizedwaitgroup.New(jsAnalysisThreadNumber[conf.GetWorkerPerformanceMode()])
+	for _, line := range strings.Split(j.Config.Target, ",") {
+		domain := strings.ToLower(strings.TrimSpace(line))
+		if domain == "" {
+			continue
+		}
+		var pages []string
+		for _, r := range j.ReqResponseList {
+			if r.Method == http.MethodGet && isInScopeUrl(r.URL, domain) {
+				pages = append(pages, r.URL)
+			}
+		}
+		swg.Add()
+		go func(d string, pages []string) {
+			defer swg.Done()
+			j.RunJSAnalysis(d, pages)
+		}(domain, pages)
+	}
+	swg.Wait()
+}
+
+// RunJSAnalysis 获取页面引用的JS文件并分析；pages为爬虫获取的页面，为空时使用域名的首页
+func (j *JSAnalysis) RunJSAnalysis(domain string, pages []string) {
+	if len(pages) == 0 {
+		pages = []string{fmt.Sprintf("http://%s/", domain), fmt.Sprintf("https://%s/", domain)}
+	}
+	scripts := make(map[string]struct{})
+	var scriptList []string
+	addScript := func(u string) {
+		if _, ok := scripts[u]; !ok && len(scriptList) < jsMaxScripts {
+			scripts[u] = struct{}{}
+			scriptList = append(scriptList, u)
+		}
+	}
+	for i, page := range pages {
+		if i >= jsMaxPages {
+			break
+		}
+		if isScriptUrl(page) {
+			addScript(page)
+			continue
+		}
+		body, _, err := j.fetch(page)
+		if err != nil {
+			continue
+		}
+		for _, src := range jsScriptSrcRegex.FindAllStringSubmatch(string(body), -1) {
+			// 只分析与页面同一主机或目标域名下的JS
+			if scriptUrl := resolveUrl(page, src[1]); scriptUrl != "" && (isSameHost(page, scriptUrl) || isInScopeUrl(scriptUrl, domain)) {
+				addScript(scriptUrl)
+			}
+		}
+	}
+	for _, scriptUrl := range scriptList {
+		body, header, err := j.fetch(scriptUrl)
+		if err != nil {
+			continue
+		}
+		j.analyze(domain, scriptUrl, string(body))
+		j.analyzeSourceMap(domain, scriptUrl, string(body), header)
+	}
+}
+
+// analyzeSourceMap 获取JS文件的source map（sourceMappingURL注释、SourceMap头或.map文件），分析其中的源代码
+func (j *JSAnalysis) analyzeSourceMap(domain, scriptUrl, body string, header http.Header) {
+	var mapUrl string
+	if m := jsSourceMapRegex.FindStringSubmatch(body); len(m) > 1 && !strings.HasPrefix(m[1], "data:") {
+		mapUrl = resolveUrl(scriptUrl, m[1])
+	} else if h := header.Get("SourceMap"); h != "" {
+		mapUrl = resolveUrl(scriptUrl, h)
+	} else if h = header.Get("X-SourceMap"); h != "" {
+		mapUrl = resolveUrl(scriptUrl, h)
+	} else {
+		mapUrl = getDefaultSourceMapUrl(scriptUrl)
+	}
+	if mapUrl == "" {
+		return
+	}
+	content, _, err := j.fetch(mapUrl)
+	if err != nil {
+		return
+	}
+	var sm sourceMap
+	if err = json.Unmarshal(content, &sm); err != nil || len(sm.Sources) == 0 {
+		return
+	}
+	j.addFinding(JSFinding{Domain: domain, Url: mapUrl, Category: JSCategorySourceMap, Name: "sourcemap-exposed", Content: fmt.Sprintf("%d sources", len(sm.Sources)), IsFinding: true})
+	for _, source := range sm.SourcesContent {
+		j.analyze(domain, mapUrl, source)
+	}
+}
+
+// analyze 分析JS内容：提取目标域名的子域名，并按规则匹配
+func (j *JSAnalysis) analyze(domain, sourceUrl, content string) {
+	for _, subdomain := range extractSubdomain(content, domain) {
+		if !j.Result.HasDomain(subdomain) {
+			j.Result.SetDomain(subdomain)
+		}
+	}
+	for _, r := range j.rules {
+		for _, m := range r.regex.FindAllStringSubmatch(content, -1) {
+			if r.Group >= len(m) {
+				continue
+			}
+			value := m[r.Group]
+			if value == "" || (r.MinEntropy > 0 && ShannonEntropy(value) < r.MinEntropy) {
+				continue
+			}
+			j.addFinding(JSFinding{Domain: domain, Url: sourceUrl, Category: r.Category, Name: r.Name, Content: value, IsFinding: r.IsFinding})
+		}
+	}
+}
+
+// addFinding 保存不重复的结果
+func (j *JSAnalysis) addFinding(f JSFinding) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	for _, old := range j.Findings {
+		if old.Domain == f.Domain && old.Category == f.Category && old.Content == f.Content {
+			return
+		}
+	}
+	j.Findings = append(j.Findings, f)
+}
+
+// fetch 获取URL的内容
+func (j *JSAnalysis) fetch(u string) (body []byte, header http.Header, err error) {
+	resp, err := j.client.Get(u)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s status:%d", u, resp.StatusCode)
+	}
+	body, err = io.ReadAll(io.LimitReader(resp.Body, jsMaxBodySize))
+	return body, resp.Header, err
+}
+
+// extractSubdomain 提取内容中域名的子域名
+func extractSubdomain(content, domain string) (subdomains []string) {
+	regex, err := regexp.Compile(`(?i)(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+` + regexp.QuoteMeta(domain) + `\b`)
+	if err != nil {
+		return
+	}
+	subdomainMap := make(map[string]struct{})
+	for _, s := range regex.FindAllString(content, -1) {
+		s = strings.ToLower(s)
+		if _, ok := subdomainMap[s]; !ok {
+			subdomainMap[s] = struct{}{}
+			subdomains = append(subdomains, s)
+		}
+	}
+	return
+}
+
+// ShannonEntropy 计算字符串的香农信息熵（每个字符的比特数）
+func ShannonEntropy(s string) (entropy float64) {
+	if s == "" {
+		return 0
+	}
+	count := make(map[rune]int)
+	for _, c := range s {
+		count[c]++
+	}
+	length := float64(len([]rune(s)))
+	for _, n := range count {
+		p := float64(n) / length
+		entropy -= p * math.Log2(p)
+	}
+	return
+}
+
+// getDefaultSourceMapUrl 获取JS文件默认的source map地址：路径加上.map，去除查询参数及锚点
+func getDefaultSourceMapUrl(scriptUrl string) string {
+	u, err := url.Parse(scriptUrl)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	u.Path += ".map"
+	if u.RawPath != "" {
+		u.RawPath += ".map"
+	}
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// resolveUrl 将相对地址转换为绝对地址
+func resolveUrl(base, ref string) string {
+	baseUrl, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	refUrl, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	u := baseUrl.ResolveReference(refUrl)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+// isScriptUrl URL是否为JS文件
+func isScriptUrl(u string) bool {
+	parsed, err := url.Parse(u)
+	return err == nil && strings.EqualFold(path.Ext(parsed.Path), ".js")
+}
+
+// isSameHost 两个URL的主机是否相同
+func isSameHost(u1, u2 string) bool {
+	p1, err1 := url.Parse(u1)
+	p2, err2 := url.Parse(u2)
+	return err1 == nil && err2 == nil && strings.EqualFold(p1.Host, p2.Host)
+}
+
+// isInScopeUrl URL的主机是否为域名或其子域名
+func isInScopeUrl(u, domain string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
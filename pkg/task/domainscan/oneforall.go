@@ -0,0 +1,86 @@
+package domainscan
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"strings"
+)
+
+// OneForAll 导入OneForAll导出的子域名结果
+type OneForAll struct {
+}
+
+// oneForAllResult OneForAll结果中使用的字段，IP及CNAME为逗号分隔的多个值
+type oneForAllResult struct {
+	Subdomain string `json:"subdomain"`
+	IP        string `json:"ip"`
+	CName     string `json:"cname"`
+	Title     string `json:"title"`
+}
+
+// ParseContentResult 解析OneForAll导出的csv或json格式的结果
+func (o *OneForAll) ParseContentResult(content []byte) (result Result) {
+	result.DomainResult = make(map[string]*DomainResult)
+
+	var items []oneForAllResult
+	content = bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")))
+	if bytes.HasPrefix(content, []byte("[")) {
+		if err := json.Unmarshal(content, &items); err != nil {
+			logging.RuntimeLog.Error(err)
+			return
+		}
+	} else {
+		items = o.parseCSV(content)
+	}
+	for _, item := range items {
+		var ips, cnames []string
+		if item.IP != "" {
+			ips = strings.Split(item.IP, ",")
+		}
+		if item.CName != "" {
+			cnames = strings.Split(item.CName, ",")
+		}
+		domain := strings.ToLower(strings.TrimSpace(item.Subdomain))
+		addImportDomain(&result, domain, "oneforall", ips, cnames)
+		title := strings.TrimSpace(item.Title)
+		if title != "" && result.HasDomain(domain) {
+			result.SetDomainAttr(domain, DomainAttrResult{Source: "oneforall", Tag: "title", Content: title})
+		}
+	}
+	return
+}
+
+// parseCSV 根据csv的表头获取字段
+func (o *OneForAll) parseCSV(content []byte) (items []oneForAllResult) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+	header := make(map[string]int)
+	for i, h := range records[0] {
+		header[strings.TrimSpace(h)] = i
+	}
+	getField := func(record []string, name string) string {
+		if i, ok := header[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	for _, record := range records[1:] {
+		items = append(items, oneForAllResult{
+			Subdomain: getField(record, "subdomain"),
+			IP:        getField(record, "ip"),
+			CName:     getField(record, "cname"),
+			Title:     getField(record, "title"),
+		})
+	}
+	return
+}
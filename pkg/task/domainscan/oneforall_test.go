@@ -0,0 +1,23 @@
+package domainscan
+
+import "testing"
+
+func TestOneForAll_ParseContentResult(t *testing.T) {
+	csvContent := "\xef\xbb\xbfid,alive,request,resolve,url,subdomain,level,cname,ip,public,cdn,port,status,reason,title\n" +
+		"1,1,1,1,http://www.example.com,www.example.com,1,www.example.com.cdn.net,\"1.1.1.1,1.1.1.2\",1,1,80,200,OK,Example Domain\n" +
+		"2,0,0,1,http://mail.example.com,mail.example.com,1,,2.2.2.2,1,0,80,,,\n"
+	o := OneForAll{}
+	result := o.ParseContentResult([]byte(csvContent))
+	for domain, r := range result.DomainResult {
+		t.Log(domain, r.DomainAttrs)
+	}
+	if len(result.DomainResult) != 2 || len(result.DomainResult["www.example.com"].DomainAttrs) != 4 {
+		t.Errorf("invalid csv result:%v", result.DomainResult)
+	}
+
+	jsonContent := `[{"subdomain":"www.example.com","ip":"1.1.1.1","cname":"","title":""},{"subdomain":"invalid domain","ip":"","cname":"","title":""}]`
+	result = o.ParseContentResult([]byte(jsonContent))
+	if len(result.DomainResult) != 1 || len(result.DomainResult["www.example.com"].DomainAttrs) != 1 {
+		t.Errorf("invalid json result:%v", result.DomainResult)
+	}
+}
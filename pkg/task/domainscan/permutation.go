@@ -0,0 +1,220 @@
+package domainscan
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// permutationMaxWords 从已知子域名中提取的用于插入的单词的最大数量（按出现次数）
+	permutationMaxWords = 30
+	// permutationMaxNumberDelta 数字递增、递减的范围
+	permutationMaxNumberDelta = 3
+)
+
+var (
+	// permutationEnvWords 常见的环境、用途单词，用于插入和替换
+	permutationEnvWords = []string{"dev", "test", "uat", "sit", "qa", "pre", "stage", "staging", "prod", "beta", "demo", "old", "new", "bak", "api", "admin", "internal"}
+	// permutationEnvWordsMap 可相互替换的环境单词
+	permutationEnvWordsMap = map[string]struct{}{"dev": {}, "test": {}, "uat": {}, "sit": {}, "qa": {}, "pre": {}, "stage": {}, "staging": {}, "prod": {}, "beta": {}, "demo": {}}
+	permutationNumberRegex = regexp.MustCompile(`\d+`)
+	permutationLabelRegex  = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+)
+
+// Permutation 子域名变换：根据已发现的子域名，通过插入单词、数字递增、替换环境单词、“-”与“.”变换生成新的子域名并解析
+type Permutation struct {
+	Config Config
+	Result Result
+	// KnownDomains 已发现的子域名（当前任务及工作空间中已有的子域名）
+	KnownDomains []string
+}
+
+// NewPermutation 创建子域名变换对象
+func NewPermutation(config Config) *Permutation {
+	return &Permutation{Config: config}
+}
+
+// Do 执行子域名变换
+func (p *Permutation) Do() {
+	p.Result.DomainResult = make(map[string]*DomainResult)
+
+	pool, err := newBruteResolverPool()
+	if err != nil {
+		logging.RuntimeLog.Errorf("create resolver pool fail:%v", err)
+		logging.CLILog.Errorf("create resolver pool fail:%v", err)
+		return
+	}
+	defer pool.Close()
+
+	for _, line := range strings.Split(p.Config.Target, ",") {
+		domain := strings.ToLower(strings.TrimSpace(line))
+		if domain == "" {
+			continue
+		}
+		candidates := GeneratePermutation(domain, p.KnownDomains, permutationMaxCandidates[conf.GetWorkerPerformanceMode()])
+		logging.RuntimeLog.Infof("%s permutation generate %d subdomains", domain, len(candidates))
+		if len(candidates) > 0 {
+			resolveSubdomains(pool, domain, candidates, &p.Result)
+		}
+	}
+}
+
+// GeneratePermutation 根据已知子域名生成域名下变换的子域名（不包括已知子域名），最多生成maxCandidates个
+func GeneratePermutation(root string, knownDomains []string, maxCandidates int) (candidates []string) {
+	known := make(map[string]struct{})
+	var prefixes [][]string
+	for _, d := range knownDomains {
+		d = strings.ToLower(strings.Trim(strings.TrimSpace(d), "."))
+		if _, ok := known[d]; ok || !strings.HasSuffix(d, "."+root) {
+			continue
+		}
+		known[d] = struct{}{}
+		prefixes = append(prefixes, strings.Split(strings.TrimSuffix(d, "."+root), "."))
+	}
+	words := getPermutationWords(prefixes)
+
+	generated := make(map[string]struct{})
+	add := func(labels []string) bool {
+		for _, label := range labels {
+			if !permutationLabelRegex.MatchString(label) {
+				return true
+			}
+		}
+		domain := strings.Join(labels, ".") + "." + root
+		if _, ok := known[domain]; ok {
+			return true
+		}
+		if _, ok := generated[domain]; !ok {
+			generated[domain] = struct{}{}
+			candidates = append(candidates, domain)
+		}
+		return maxCandidates <= 0 || len(candidates) < maxCandidates
+	}
+	for _, labels := range prefixes {
+		if !permuteLabels(labels, words, add) {
+			break
+		}
+	}
+	return
+}
+
+// permuteLabels 对一个子域名的各级标签进行变换，add返回false时停止
+func permuteLabels(labels []string, words []string, add func([]string) bool) bool {
+	// 数字递增、递减：web01 -> web02、web00
+	for i, label := range labels {
+		for _, loc := range permutationNumberRegex.FindAllStringIndex(label, -1) {
+			numberString := label[loc[0]:loc[1]]
+			number, _ := strconv.Atoi(numberString)
+			for delta := -permutationMaxNumberDelta; delta <= permutationMaxNumberDelta; delta++ {
+				if delta == 0 || number+delta < 0 {
+					continue
+				}
+				n := fmt.Sprintf("%0*d", len(numberString), number+delta)
+				if !add(replaceLabel(labels, i, label[:loc[0]]+n+label[loc[1]:])) {
+					return false
+				}
+			}
+		}
+	}
+	// 环境单词替换：api.dev -> api.test、api-dev -> api-test
+	for i, label := range labels {
+		tokens := strings.Split(label, "-")
+		for j, token := range tokens {
+			if _, ok := permutationEnvWordsMap[token]; !ok {
+				continue
+			}
+			for _, env := range permutationEnvWords {
+				if _, isEnv := permutationEnvWordsMap[env]; !isEnv || env == token {
+					continue
+				}
+				newTokens := append([]string{}, tokens...)
+				newTokens[j] = env
+				if !add(replaceLabel(labels, i, strings.Join(newTokens, "-"))) {
+					return false
+				}
+			}
+		}
+	}
+	for _, word := range words {
+		// 插入单词作为新的一级：api -> dev.api、api.dev
+		for i := 0; i <= len(labels); i++ {
+			newLabels := append(append(append([]string{}, labels[:i]...), word), labels[i:]...)
+			if !add(newLabels) {
+				return false
+			}
+		}
+		// “-”连接单词：api -> dev-api、api-dev
+		if labels[0] == word {
+			continue
+		}
+		if !add(replaceLabel(labels, 0, word+"-"+labels[0])) || !add(replaceLabel(labels, 0, labels[0]+"-"+word)) {
+			return false
+		}
+	}
+	// “-”与“.”变换：api-dev -> api.dev，api.dev -> api-dev
+	for i, label := range labels {
+		if strings.Contains(label, "-") {
+			newLabels := append(append(append([]string{}, labels[:i]...), strings.Split(label, "-")...), labels[i+1:]...)
+			if !add(newLabels) {
+				return false
+			}
+		}
+		if i+1 < len(labels) {
+			newLabels := append(append(append([]string{}, labels[:i]...), label+"-"+labels[i+1]), labels[i+2:]...)
+			if !add(newLabels) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// replaceLabel 替换指定位置的标签，返回新的标签列表
+func replaceLabel(labels []string, index int, label string) []string {
+	newLabels := append([]string{}, labels...)
+	newLabels[index] = label
+	return newLabels
+}
+
+// getPermutationWords 提取已知子域名中出现次数最多的单词，与环境单词一起作为插入的单词
+func getPermutationWords(prefixes [][]string) (words []string) {
+	count := make(map[string]int)
+	for _, labels := range prefixes {
+		for _, label := range labels {
+			for _, token := range strings.Split(label, "-") {
+				token = strings.TrimRight(token, "0123456789")
+				if len(token) < 2 {
+					continue
+				}
+				count[token]++
+			}
+		}
+	}
+	var tokens []string
+	for token := range count {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if count[tokens[i]] != count[tokens[j]] {
+			return count[tokens[i]] > count[tokens[j]]
+		}
+		return tokens[i] < tokens[j]
+	})
+	wordsMap := make(map[string]struct{})
+	for _, word := range permutationEnvWords {
+		wordsMap[word] = struct{}{}
+		words = append(words, word)
+	}
+	for i := 0; i < len(tokens) && i < permutationMaxWords; i++ {
+		if _, ok := wordsMap[tokens[i]]; !ok {
+			wordsMap[tokens[i]] = struct{}{}
+			words = append(words, tokens[i])
+		}
+	}
+	return
+}
@@ -0,0 +1,43 @@
+package domainscan
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"testing"
+)
+
+func TestGeneratePermutation(t *testing.T) {
+	known := []string{"web01.example.com", "api-dev.example.com", "mail.test.example.com", "www.other.com", "example.com"}
+	candidates := GeneratePermutation("example.com", known, 0)
+	t.Log(len(candidates))
+
+	candidatesMap := make(map[string]struct{})
+	for _, c := range candidates {
+		candidatesMap[c] = struct{}{}
+	}
+	for _, expected := range []string{
+		"web02.example.com", "web00.example.com", // 数字递增、递减
+		"api-uat.example.com", "mail.prod.example.com", // 环境单词替换
+		"dev.web01.example.com", "web01.dev.example.com", "dev-web01.example.com", "web01-dev.example.com", // 插入单词
+		"api.dev.example.com", "mail-test.example.com", // “-”与“.”变换
+		"mail.web01.example.com", // 已知子域名中的单词
+	} {
+		if _, ok := candidatesMap[expected]; !ok {
+			t.Errorf("%s not generated", expected)
+		}
+	}
+	for _, c := range known {
+		if _, ok := candidatesMap[c]; ok {
+			t.Errorf("known domain %s generated", c)
+		}
+	}
+	for c := range candidatesMap {
+		if !utils.CheckDomain(c) {
+			t.Errorf("invalid candidate %s", c)
+		}
+	}
+
+	limited := GeneratePermutation("example.com", known, 10)
+	if len(limited) != 10 {
+		t.Errorf("expect 10 candidates,got %d", len(limited))
+	}
+}
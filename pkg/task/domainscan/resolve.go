@@ -22,7 +22,7 @@ func NewResolve(config Config) *Resolve {
 
 // Do 执行域名解析
 func (r *Resolve) Do() {
-	swg := sizedwaitgroup.New(resolveThreadNumber[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(resolveThreadNumber[conf.GetWorkerPerformanceMode()])
 	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
 	// 如果Result中已有map[domain]*DomainResult，则遍历并解析域名
 	if r.Result.DomainResult != nil {
@@ -0,0 +1,258 @@
+package domainscan
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/miekg/dns"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// resolverTimeout 一次DNS查询的超时时间
+	resolverTimeout = 3 * time.Second
+	// resolverRetries 查询失败时更换DNS服务器重试的次数
+	resolverRetries = 3
+	// resolverMaxFailures DNS服务器连续失败的次数，超过后暂停使用
+	resolverMaxFailures = 10
+	// resolverDisableTime DNS服务器暂停使用的时间
+	resolverDisableTime = 30 * time.Second
+)
+
+// defaultResolverCheckDomain 未配置时检查DNS服务器可用性的域名
+const defaultResolverCheckDomain = "example.com"
+
+// DNSAnswer 域名A记录的查询结果
+type DNSAnswer struct {
+	Rcode int
+	A     []string
+	CNAME []string
+}
+
+// Exist 域名是否存在解析记录
+func (a *DNSAnswer) Exist() bool {
+	return a.Rcode == dns.RcodeSuccess && (len(a.A) > 0 || len(a.CNAME) > 0)
+}
+
+// poolResolver 解析池中的一个DNS服务器
+type poolResolver struct {
+	addr          string
+	failures      int
+	disabledUntil time.Time
+}
+
+// ResolverPool DNS服务器解析池：轮流使用可用的DNS服务器，对查询进行限速，连续失败的DNS服务器暂停使用
+type ResolverPool struct {
+	sync.Mutex
+	resolvers []*poolResolver
+	index     int
+	client    *dns.Client
+	limiter   *time.Ticker
+	// checkDomain 检查DNS服务器可用性的域名：应可以解析，随机子域名必须不存在（排除劫持NXDOMAIN的DNS服务器）
+	checkDomain string
+}
+
+// NewResolverPool 从文件加载DNS服务器（每行为IP或IP:端口），使用checkDomain检查可用性后创建解析池；rate为每秒最大查询数，0为不限速
+func NewResolverPool(resolverFile, checkDomain string, rate int) (*ResolverPool, error) {
+	content, err := os.ReadFile(resolverFile)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, line := range strings.Split(string(content), "\n") {
+		addr := strings.TrimSpace(line)
+		if addr == "" || strings.HasPrefix(addr, "#") {
+			continue
+		}
+		if _, _, err = net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "53")
+		}
+		addrs = append(addrs, addr)
+	}
+	return newResolverPoolByAddrs(utils.RemoveDuplicationElement(addrs), checkDomain, rate)
+}
+
+// newResolverPoolByAddrs 检查DNS服务器的可用性，创建解析池
+func newResolverPoolByAddrs(addrs []string, checkDomain string, rate int) (*ResolverPool, error) {
+	checkDomain = strings.Trim(strings.TrimSpace(checkDomain), ".")
+	if checkDomain == "" {
+		checkDomain = defaultResolverCheckDomain
+	}
+	p := &ResolverPool{client: &dns.Client{Net: "udp", Timeout: resolverTimeout}, checkDomain: checkDomain}
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			if err := p.checkResolver(addr); err != nil {
+				logging.RuntimeLog.Warningf("resolver %s unavailable:%v", addr, err)
+				return
+			}
+			mutex.Lock()
+			p.resolvers = append(p.resolvers, &poolResolver{addr: addr})
+			mutex.Unlock()
+		}(addr)
+	}
+	wg.Wait()
+	if len(p.resolvers) == 0 {
+		return nil, errors.New("no available resolver")
+	}
+	if rate > 0 {
+		p.limiter = time.NewTicker(time.Second / time.Duration(rate))
+	}
+	return p, nil
+}
+
+// checkResolver 检查DNS服务器是否可用：内网或分离解析的DNS服务器可能无法解析检查的域名，只要未返回SERVFAIL或REFUSED即可使用
+func (p *ResolverPool) checkResolver(addr string) error {
+	answer, err := p.exchange(addr, p.checkDomain)
+	if err != nil {
+		return err
+	}
+	if answer.Rcode == dns.RcodeServerFailure || answer.Rcode == dns.RcodeRefused {
+		return fmt.Errorf("resolve %s return %s", p.checkDomain, dns.RcodeToString[answer.Rcode])
+	}
+	if !answer.Exist() {
+		logging.RuntimeLog.Infof("resolver %s can't resolve %s,accept it", addr, p.checkDomain)
+	}
+	answer, err = p.exchange(addr, fmt.Sprintf("%s.%s", utils.GetRandomString2(16), p.checkDomain))
+	if err != nil {
+		return err
+	}
+	if answer.Exist() {
+		return errors.New("nxdomain hijacked")
+	}
+	return nil
+}
+
+// Size 可用的DNS服务器数量
+func (p *ResolverPool) Size() int {
+	p.Lock()
+	defer p.Unlock()
+
+	return len(p.resolvers)
+}
+
+// Close 停止限速
+func (p *ResolverPool) Close() {
+	if p.limiter != nil {
+		p.limiter.Stop()
+	}
+}
+
+// pick 选择下一个可用的DNS服务器，全部暂停使用时选择最先恢复的
+func (p *ResolverPool) pick() *poolResolver {
+	p.Lock()
+	defer p.Unlock()
+
+	now := time.Now()
+	var earliest *poolResolver
+	for i := 0; i < len(p.resolvers); i++ {
+		r := p.resolvers[p.index]
+		p.index = (p.index + 1) % len(p.resolvers)
+		if now.After(r.disabledUntil) {
+			return r
+		}
+		if earliest == nil || r.disabledUntil.Before(earliest.disabledUntil) {
+			earliest = r
+		}
+	}
+	return earliest
+}
+
+// markResult 记录DNS服务器的查询结果
+func (p *ResolverPool) markResult(r *poolResolver, success bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	if success {
+		r.failures = 0
+		return
+	}
+	r.failures++
+	if r.failures >= resolverMaxFailures {
+		r.failures = 0
+		r.disabledUntil = time.Now().Add(resolverDisableTime)
+		logging.RuntimeLog.Warningf("resolver %s failed too many times,disabled for %s", r.addr, resolverDisableTime)
+	}
+}
+
+// exchange 向指定的DNS服务器查询域名的A记录
+func (p *ResolverPool) exchange(addr, domain string) (answer DNSAnswer, err error) {
+	in, err := p.exchangeMsg(addr, domain, dns.TypeA)
+	if err != nil {
+		return
+	}
+	return parseAnswer(in), nil
+}
+
+// exchangeMsg 向指定的DNS服务器查询域名指定类型的记录
+func (p *ResolverPool) exchangeMsg(addr, domain string, qtype uint16) (in *dns.Msg, err error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(domain), qtype)
+	in, _, err = p.client.Exchange(msg, addr)
+	return
+}
+
+// parseAnswer 从查询结果中获取A及CNAME记录
+func parseAnswer(in *dns.Msg) (answer DNSAnswer) {
+	answer.Rcode = in.Rcode
+	for _, rr := range in.Answer {
+		switch v := rr.(type) {
+		case *dns.A:
+			answer.A = append(answer.A, v.A.String())
+		case *dns.CNAME:
+			answer.CNAME = append(answer.CNAME, strings.TrimSuffix(v.Target, "."))
+		}
+	}
+	return
+}
+
+// Query 查询域名指定类型的记录，DNS服务器失败时更换服务器重试
+func (p *ResolverPool) Query(domain string, qtype uint16) (in *dns.Msg, err error) {
+	for i := 0; i < resolverRetries; i++ {
+		if p.limiter != nil {
+			<-p.limiter.C
+		}
+		r := p.pick()
+		in, err = p.exchangeMsg(r.addr, domain, qtype)
+		if err == nil && in.Rcode != dns.RcodeServerFailure && in.Rcode != dns.RcodeRefused {
+			p.markResult(r, true)
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("%s return %s", r.addr, dns.RcodeToString[in.Rcode])
+		}
+		p.markResult(r, false)
+	}
+	return
+}
+
+// Resolve 查询域名的A记录，DNS服务器失败时更换服务器重试
+func (p *ResolverPool) Resolve(domain string) (answer DNSAnswer, err error) {
+	in, err := p.Query(domain, dns.TypeA)
+	if err != nil {
+		return
+	}
+	return parseAnswer(in), nil
+}
+
+// LookupNS 查询域名的NS记录，返回DNS服务器的域名
+func (p *ResolverPool) LookupNS(domain string) (nameServers []string, err error) {
+	in, err := p.Query(domain, dns.TypeNS)
+	if err != nil {
+		return
+	}
+	for _, rr := range in.Answer {
+		if v, ok := rr.(*dns.NS); ok {
+			nameServers = append(nameServers, strings.ToLower(strings.TrimSuffix(v.Ns, ".")))
+		}
+	}
+	return
+}
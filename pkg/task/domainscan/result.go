@@ -16,11 +16,15 @@ const (
 )
 
 var (
-	resolveThreadNumber   = make(map[string]int)
-	subfinderThreadNumber = make(map[string]int)
-	massdnsThreadNumber   = make(map[string]int)
-	massdnsRunnerThreads  = make(map[string]int)
-	crawlerThreadNumber   = make(map[string]int)
+	resolveThreadNumber      = make(map[string]int)
+	subfinderThreadNumber    = make(map[string]int)
+	dnsBruteThreadNumber     = make(map[string]int)
+	dnsBruteRunnerThreads    = make(map[string]int)
+	dnsBruteRate             = make(map[string]int)
+	permutationMaxCandidates = make(map[string]int)
+	crawlerThreadNumber      = make(map[string]int)
+	takeoverThreadNumber     = make(map[string]int)
+	jsAnalysisThreadNumber   = make(map[string]int)
 )
 
 // Config 端口扫描的参数配置
@@ -30,6 +34,11 @@ type Config struct {
 	IsSubDomainFinder  bool   `json:"subfinder"`
 	IsSubDomainBrute   bool   `json:"subdomainBrute"`
 	IsCrawler          bool   `json:"crawler"`
+	IsPermutation      bool   `json:"permutation"`
+	IsZoneTransfer     bool   `json:"zonetransfer"`
+	IsJSAnalysis       bool   `json:"jsanalysis"`
+	IsTakeover         bool   `json:"takeover"`
+	IsCDNOrigin        bool   `json:"cdnorigin"`
 	IsHttpx            bool   `json:"httpx"`
 	IsIPPortScan       bool   `json:"portscan"`
 	IsIPSubnetPortScan bool   `json:"subnetPortscan"`
@@ -40,6 +49,8 @@ type Config struct {
 	IsIgnoreCDN        bool   `json:"ignorecdn"`
 	IsIgnoreOutofChina bool   `json:"ignoreoutofchina"`
 	WorkspaceId        int    `json:"workspaceId"`
+	// ProviderConfig server集中管理的subfinder provider配置内容，不序列化到任务参数中
+	ProviderConfig string `json:"-"`
 }
 
 // DomainAttrResult 域名属性结果
@@ -72,21 +83,73 @@ type Result struct {
 	ReqResponseList []UrlResponse
 }
 
+type OfflineResult interface {
+	ParseContentResult(content []byte) (result Result)
+}
+
+type ImportOfflineResult struct {
+	resultType       string
+	offlineInterface OfflineResult
+	DomainResult     Result
+}
+
+func NewImportOfflineResult(resultType string) *ImportOfflineResult {
+	i := &ImportOfflineResult{resultType: resultType}
+	switch resultType {
+	case "subfinder":
+		i.offlineInterface = new(SubFinder)
+	case "amass":
+		i.offlineInterface = new(Amass)
+	case "oneforall":
+		i.offlineInterface = new(OneForAll)
+	}
+	return i
+}
+
+func (i *ImportOfflineResult) Parse(content []byte) {
+	if i.offlineInterface == nil {
+		logging.RuntimeLog.Errorf("invalid offline result:%s", i.resultType)
+		return
+	}
+	i.DomainResult = i.offlineInterface.ParseContentResult(content)
+}
+
 func init() {
 	resolveThreadNumber[conf.HighPerformance] = 100
 	resolveThreadNumber[conf.NormalPerformance] = 50
+	resolveThreadNumber[conf.LowPerformance] = 20
 	//
 	subfinderThreadNumber[conf.HighPerformance] = 4
 	subfinderThreadNumber[conf.NormalPerformance] = 2
+	subfinderThreadNumber[conf.LowPerformance] = 1
+	//
+	dnsBruteThreadNumber[conf.HighPerformance] = 1
+	dnsBruteThreadNumber[conf.NormalPerformance] = 1
+	dnsBruteThreadNumber[conf.LowPerformance] = 1
 	//
-	massdnsThreadNumber[conf.HighPerformance] = 1
-	massdnsThreadNumber[conf.NormalPerformance] = 1
+	dnsBruteRunnerThreads[conf.HighPerformance] = 600
+	dnsBruteRunnerThreads[conf.NormalPerformance] = 300
+	dnsBruteRunnerThreads[conf.LowPerformance] = 100
 	//
-	massdnsRunnerThreads[conf.HighPerformance] = 600
-	massdnsRunnerThreads[conf.NormalPerformance] = 300
+	dnsBruteRate[conf.HighPerformance] = 2000
+	dnsBruteRate[conf.NormalPerformance] = 1000
+	dnsBruteRate[conf.LowPerformance] = 300
+	//
+	permutationMaxCandidates[conf.HighPerformance] = 300000
+	permutationMaxCandidates[conf.NormalPerformance] = 100000
+	permutationMaxCandidates[conf.LowPerformance] = 30000
 	//
 	crawlerThreadNumber[conf.HighPerformance] = 2
 	crawlerThreadNumber[conf.NormalPerformance] = 1
+	crawlerThreadNumber[conf.LowPerformance] = 1
+	//
+	takeoverThreadNumber[conf.HighPerformance] = 50
+	takeoverThreadNumber[conf.NormalPerformance] = 20
+	takeoverThreadNumber[conf.LowPerformance] = 10
+	//
+	jsAnalysisThreadNumber[conf.HighPerformance] = 4
+	jsAnalysisThreadNumber[conf.NormalPerformance] = 2
+	jsAnalysisThreadNumber[conf.LowPerformance] = 1
 
 }
 
@@ -181,6 +244,27 @@ func (r *Result) SaveResult(config Config) string {
 	return sb.String()
 }
 
+// addImportDomain 添加导入的域名及解析的A、CNAME记录
+func addImportDomain(result *Result, domain string, source string, ips []string, cnames []string) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if !utils.CheckDomain(domain) {
+		return
+	}
+	if !result.HasDomain(domain) {
+		result.SetDomain(domain)
+	}
+	for _, ip := range ips {
+		if ip = strings.TrimSpace(ip); utils.CheckIPV4(ip) {
+			result.SetDomainAttr(domain, DomainAttrResult{Source: source, Tag: "A", Content: ip})
+		}
+	}
+	for _, cname := range cnames {
+		if cname = strings.TrimSuffix(strings.TrimSpace(cname), "."); cname != "" {
+			result.SetDomainAttr(domain, DomainAttrResult{Source: source, Tag: "CNAME", Content: cname})
+		}
+	}
+}
+
 // FilterDomainHasTooMuchIP 对域名结果中同一个IP对应太多进行过滤
 func FilterDomainHasTooMuchIP(result *Result) { //result map[string]*DomainResult) {
 	ip2DomainMap := make(map[string]map[string]struct{})
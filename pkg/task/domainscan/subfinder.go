@@ -2,6 +2,7 @@ package domainscan
 
 import (
 	"bytes"
+	"encoding/json"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
@@ -16,6 +17,8 @@ import (
 type SubFinder struct {
 	Config Config
 	Result Result
+	// providerConfigFile server集中管理的provider配置的临时文件
+	providerConfigFile string
 }
 
 // NewSubFinder 创建subfinder
@@ -26,8 +29,18 @@ func NewSubFinder(config Config) *SubFinder {
 // Do 执行子域名枚举
 func (s *SubFinder) Do() {
 	s.Result.DomainResult = make(map[string]*DomainResult)
-	swg := sizedwaitgroup.New(subfinderThreadNumber[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(subfinderThreadNumber[conf.GetWorkerPerformanceMode()])
 	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
+	// provider配置中包含各接口的密钥，只在任务执行期间保存在内存文件系统中
+	if s.Config.ProviderConfig != "" {
+		s.providerConfigFile = utils.GetMemTempPathFileName()
+		if err := os.WriteFile(s.providerConfigFile, []byte(s.Config.ProviderConfig), 0600); err != nil {
+			logging.RuntimeLog.Error(err)
+			s.providerConfigFile = ""
+		} else {
+			defer os.Remove(s.providerConfigFile)
+		}
+	}
 
 	for _, line := range strings.Split(s.Config.Target, ",") {
 		domain := strings.TrimSpace(line)
@@ -52,11 +65,15 @@ func (s *SubFinder) RunSubFinder(domain string) {
 	resultTempFile := utils.GetTempPathFileName()
 	defer os.Remove(resultTempFile)
 
+	providerConfigFile := s.providerConfigFile
+	if providerConfigFile == "" {
+		providerConfigFile = filepath.Join(conf.GetRootPath(), "thirdparty/dict", conf.GlobalWorkerConfig().Domainscan.ProviderConfig)
+	}
 	var cmdArgs []string
 	cmdArgs = append(cmdArgs,
 		"-d", domain, "-all", "-o", resultTempFile, "-disable-update-check",
 		"-rlist", filepath.Join(conf.GetRootPath(), "thirdparty/dict", conf.GlobalWorkerConfig().Domainscan.Resolver),
-		"-provider-config", filepath.Join(conf.GetRootPath(), "thirdparty/dict", conf.GlobalWorkerConfig().Domainscan.ProviderConfig),
+		"-provider-config", providerConfigFile,
 		"-no-color", "-v",
 		"-active", //RemoveWildcard
 	)
@@ -110,3 +127,26 @@ func (s *SubFinder) parseResultContent(content []byte) {
 		}
 	}
 }
+
+// ParseContentResult 解析导入的subfinder结果，支持普通text（-ip时为“域名,IP”）及-json格式
+func (s *SubFinder) ParseContentResult(content []byte) (result Result) {
+	result.DomainResult = make(map[string]*DomainResult)
+
+	for _, l := range strings.Split(string(content), "\n") {
+		line := strings.TrimSpace(strings.Trim(l, "\r"))
+		if strings.HasPrefix(line, "{") {
+			var r struct {
+				Host string `json:"host"`
+				IP   string `json:"ip"`
+			}
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				continue
+			}
+			addImportDomain(&result, r.Host, "subfinder", []string{r.IP}, nil)
+		} else {
+			hostIP := strings.Split(line, ",")
+			addImportDomain(&result, hostIP[0], "subfinder", hostIP[1:], nil)
+		}
+	}
+	return
+}
@@ -19,3 +19,19 @@ func TestRun(t *testing.T) {
 	}
 	//subdomain.Result.SaveResult(config)
 }
+
+func TestSubFinder_ParseContentResult(t *testing.T) {
+	content := `www.example.com
+mail.example.com,192.168.1.1
+{"host":"api.example.com","input":"example.com","source":"crtsh"}
+{"host":"vpn.example.com","ip":"192.168.1.2","input":"example.com","source":"alienvault"}
+`
+	s := SubFinder{}
+	result := s.ParseContentResult([]byte(content))
+	for domain, r := range result.DomainResult {
+		t.Log(domain, r.DomainAttrs)
+	}
+	if len(result.DomainResult) != 4 || len(result.DomainResult["mail.example.com"].DomainAttrs) != 1 || len(result.DomainResult["vpn.example.com"].DomainAttrs) != 1 {
+		t.Errorf("invalid result:%v", result.DomainResult)
+	}
+}
@@ -0,0 +1,176 @@
+package domainscan
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/miekg/dns"
+	"github.com/remeh/sizedwaitgroup"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// takeoverHttpTimeout 获取页面内容的超时时间
+	takeoverHttpTimeout = 10 * time.Second
+	// takeoverMaxBodySize 读取页面内容的最大长度
+	takeoverMaxBodySize = 1024 * 1024
+)
+
+// TakeoverFingerprint 子域名接管的指纹，格式与can-i-take-over-xyz的fingerprints.json相同
+type TakeoverFingerprint struct {
+	Service     string   `json:"service"`
+	CName       []string `json:"cname"`
+	Fingerprint string   `json:"fingerprint"`
+	HttpStatus  *int     `json:"http_status"`
+	NXDomain    bool     `json:"nxdomain"`
+	Vulnerable  bool     `json:"vulnerable"`
+}
+
+// TakeoverResult 存在子域名接管风险的域名
+type TakeoverResult struct {
+	Domain  string
+	CName   string
+	Service string
+	Reason  string
+}
+
+// Takeover 子域名接管检测：域名的CNAME指向云服务且对应的资源未被注册（CNAME解析NXDOMAIN，或者页面内容匹配云服务的指纹）
+type Takeover struct {
+	Config Config
+	Result []TakeoverResult
+
+	fingerprints []TakeoverFingerprint
+	client       *http.Client
+	mutex        sync.Mutex
+}
+
+// NewTakeover 创建子域名接管检测对象
+func NewTakeover(config Config) *Takeover {
+	t := &Takeover{
+		Config: config,
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+			Timeout:   takeoverHttpTimeout,
+		},
+	}
+	t.loadFingerprint()
+	return t
+}
+
+// loadFingerprint 加载子域名接管的指纹，只使用可接管的指纹
+func (t *Takeover) loadFingerprint() {
+	content, err := os.ReadFile(filepath.Join(conf.GetRootPath(), "thirdparty/custom/takeover_fingerprint.json"))
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		return
+	}
+	var fingerprints []TakeoverFingerprint
+	if err = json.Unmarshal(content, &fingerprints); err != nil {
+		logging.RuntimeLog.Errorf("load takeover fingerprint fail:%v", err)
+		return
+	}
+	for _, f := range fingerprints {
+		if f.Vulnerable && len(f.CName) > 0 && (f.NXDomain || f.Fingerprint != "") {
+			t.fingerprints = append(t.fingerprints, f)
+		}
+	}
+}
+
+// Do 对域名进行子域名接管检测
+func (t *Takeover) Do(domains []string) {
+	if len(t.fingerprints) == 0 || len(domains) == 0 {
+		return
+	}
+	pool, err := newBruteResolverPool()
+	if err != nil {
+		logging.RuntimeLog.Errorf("create resolver pool fail:%v", err)
+		return
+	}
+	defer pool.Close()
+
+	t.RunTakeover(pool, domains)
+}
+
+// RunTakeover 使用指定的解析池并发检测域名
+func (t *Takeover) RunTakeover(pool *ResolverPool, domains []string) {
+	swg := sizedwaitgroup.New(takeoverThreadNumber[conf.GetWorkerPerformanceMode()])
+	for _, domain := range domains {
+		swg.Add()
+		go func(d string) {
+			defer swg.Done()
+			if r := t.CheckDomain(pool, d); r != nil {
+				logging.RuntimeLog.Infof("%s may be taken over,cname:%s,service:%s", r.Domain, r.CName, r.Service)
+				t.mutex.Lock()
+				t.Result = append(t.Result, *r)
+				t.mutex.Unlock()
+			}
+		}(domain)
+	}
+	swg.Wait()
+}
+
+// CheckDomain 检测一个域名是否存在子域名接管风险
+func (t *Takeover) CheckDomain(pool *ResolverPool, domain string) *TakeoverResult {
+	answer, err := pool.Resolve(domain)
+	if err != nil || len(answer.CNAME) == 0 {
+		return nil
+	}
+	// CNAME链的最后一个为最终指向的域名
+	cname := answer.CNAME[len(answer.CNAME)-1]
+	for _, f := range t.fingerprints {
+		if !matchTakeoverCName(answer.CNAME, f.CName) {
+			continue
+		}
+		if f.NXDomain {
+			if answer.Rcode == dns.RcodeNameError {
+				return &TakeoverResult{Domain: domain, CName: cname, Service: f.Service, Reason: "cname nxdomain"}
+			}
+			continue
+		}
+		if t.matchHttpFingerprint(domain, f) {
+			return &TakeoverResult{Domain: domain, CName: cname, Service: f.Service, Reason: fmt.Sprintf("fingerprint:%s", f.Fingerprint)}
+		}
+	}
+	return nil
+}
+
+// matchTakeoverCName CNAME链中是否有指纹中的云服务域名
+func matchTakeoverCName(cnames []string, patterns []string) bool {
+	for _, cname := range cnames {
+		cname = strings.ToLower(cname)
+		for _, p := range patterns {
+			p = strings.ToLower(strings.Trim(p, "."))
+			if cname == p || strings.HasSuffix(cname, "."+p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchHttpFingerprint 获取域名的页面（先http后https），检查页面内容是否匹配指纹
+func (t *Takeover) matchHttpFingerprint(domain string, f TakeoverFingerprint) bool {
+	for _, protocol := range []string{"http", "https"} {
+		resp, err := t.client.Get(fmt.Sprintf("%s://%s/", protocol, domain))
+		if err != nil {
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, takeoverMaxBodySize))
+		resp.Body.Close()
+		if f.HttpStatus != nil && *f.HttpStatus != resp.StatusCode {
+			continue
+		}
+		if strings.Contains(string(body), f.Fingerprint) {
+			return true
+		}
+	}
+	return false
+}
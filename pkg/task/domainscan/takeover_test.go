@@ -0,0 +1,75 @@
+package domainscan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestTakeover_RunTakeover(t *testing.T) {
+	addr, shutdown := startTestDNSServer(t)
+	defer shutdown()
+	pool, err := newResolverPoolByAddrs([]string{addr}, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	// 模拟未创建站点的GitHub Pages
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "<html><title>Site not found</title><p>There isn't a GitHub Pages site here.</p></html>")
+	}))
+	defer server.Close()
+
+	takeover := NewTakeover(Config{})
+	status := http.StatusNotFound
+	takeover.fingerprints = []TakeoverFingerprint{
+		{Service: "Microsoft Azure", CName: []string{"cloudapp.net"}, NXDomain: true, Vulnerable: true},
+		{Service: "Github Pages", CName: []string{"github.io"}, Fingerprint: "There isn't a GitHub Pages site here.", HttpStatus: &status, Vulnerable: true},
+	}
+	// 所有的HTTP请求连接到测试的HTTP服务
+	takeover.client.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+		},
+	}
+	takeover.RunTakeover(pool, []string{"dangling.takeover.test", "pages.takeover.test", "claimed.takeover.test", "www.normal.test"})
+
+	results := make(map[string]TakeoverResult)
+	for _, r := range takeover.Result {
+		t.Log(r)
+		results[r.Domain] = r
+	}
+	if len(results) != 2 {
+		t.Errorf("expect 2 results,got %d", len(results))
+	}
+	if r, ok := results["dangling.takeover.test"]; !ok || r.Service != "Microsoft Azure" || r.CName != "gone.cloudapp.net" {
+		t.Errorf("dangling.takeover.test not detected")
+	}
+	if r, ok := results["pages.takeover.test"]; !ok || r.Service != "Github Pages" {
+		t.Errorf("pages.takeover.test not detected")
+	}
+}
+
+func TestTakeoverFingerprintFile(t *testing.T) {
+	content, err := os.ReadFile("../../../thirdparty/custom/takeover_fingerprint.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fingerprints []TakeoverFingerprint
+	if err = json.Unmarshal(content, &fingerprints); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(len(fingerprints))
+	for _, f := range fingerprints {
+		if f.Vulnerable && (len(f.CName) == 0 || (!f.NXDomain && f.Fingerprint == "")) {
+			t.Errorf("invalid fingerprint:%s", f.Service)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package domainscan
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/miekg/dns"
+	"github.com/remeh/sizedwaitgroup"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ZoneTransferAXFR DNS服务器允许域传送
+	ZoneTransferAXFR = "axfr"
+	// ZoneTransferNSEC DNSSEC使用NSEC记录，可遍历获得区域内的全部域名
+	ZoneTransferNSEC = "nsec"
+
+	// zoneTransferTimeout 域传送的连接及读取超时时间
+	zoneTransferTimeout = 10 * time.Second
+	// zoneWalkMaxNames NSEC遍历获取的最大域名数量
+	zoneWalkMaxNames = 10000
+)
+
+// zoneNameServerPort DNS服务器的端口
+var zoneNameServerPort = "53"
+
+// ZoneTransferResult 域名的DNS配置缺陷
+type ZoneTransferResult struct {
+	Domain     string
+	NameServer string
+	Type       string
+	Names      []string
+}
+
+// ZoneTransfer 查询域名的NS服务器，尝试域传送（AXFR）及DNSSEC NSEC遍历获取区域内的子域名
+type ZoneTransfer struct {
+	Config Config
+	Result Result
+	// Vulnerability 允许域传送或可被NSEC遍历的DNS服务器
+	Vulnerability []ZoneTransferResult
+
+	mutex sync.Mutex
+}
+
+// NewZoneTransfer 创建域传送检测对象
+func NewZoneTransfer(config Config) *ZoneTransfer {
+	return &ZoneTransfer{Config: config}
+}
+
+// Do 执行域传送及NSEC遍历
+func (z *ZoneTransfer) Do() {
+	z.Result.DomainResult = make(map[string]*DomainResult)
+
+	pool, err := newBruteResolverPool()
+	if err != nil {
+		logging.RuntimeLog.Errorf("create resolver pool fail:%v", err)
+		logging.CLILog.Errorf("create resolver pool fail:%v", err)
+		return
+	}
+	defer pool.Close()
+
+	swg := sizedwaitgroup.New(dnsBruteThreadNumber[conf.GetWorkerPerformanceMode()])
+	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
+	for _, line := range strings.Split(z.Config.Target, ",") {
+		domain := strings.ToLower(strings.TrimSpace(line))
+		if domain == "" || utils.CheckIPV4(domain) || utils.CheckIPV4Subnet(domain) {
+			continue
+		}
+		if blackDomain.CheckBlack(domain) {
+			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", domain)
+			continue
+		}
+		swg.Add()
+		go func(d string) {
+			defer swg.Done()
+			z.RunZoneTransfer(pool, d)
+		}(domain)
+	}
+	swg.Wait()
+}
+
+// RunZoneTransfer 对域名的每个NS服务器尝试域传送；NSEC遍历只需成功一次
+func (z *ZoneTransfer) RunZoneTransfer(pool *ResolverPool, domain string) {
+	nameServers, err := pool.LookupNS(domain)
+	if err != nil || len(nameServers) == 0 {
+		return
+	}
+	isWalked := false
+	for _, ns := range nameServers {
+		answer, err := pool.Resolve(ns)
+		if err != nil {
+			continue
+		}
+		for _, ip := range answer.A {
+			addr := net.JoinHostPort(ip, zoneNameServerPort)
+			if names := TransferZone(addr, domain); len(names) > 0 {
+				logging.RuntimeLog.Infof("%s(%s) allow zone transfer of %s,get %d names", ns, ip, domain, len(names))
+				z.addResult(ZoneTransferResult{Domain: domain, NameServer: ns, Type: ZoneTransferAXFR, Names: names})
+			}
+			if isWalked {
+				continue
+			}
+			if names := WalkZone(addr, domain); len(names) > 0 {
+				logging.RuntimeLog.Infof("%s(%s) zone of %s can be walked by nsec,get %d names", ns, ip, domain, len(names))
+				z.addResult(ZoneTransferResult{Domain: domain, NameServer: ns, Type: ZoneTransferNSEC, Names: names})
+				isWalked = true
+			}
+		}
+	}
+}
+
+// addResult 保存DNS配置缺陷，获取的子域名加入到结果中
+func (z *ZoneTransfer) addResult(r ZoneTransferResult) {
+	z.mutex.Lock()
+	z.Vulnerability = append(z.Vulnerability, r)
+	z.mutex.Unlock()
+
+	blackDomain := custom.NewBlackTargetCheck(custom.CheckDomain)
+	for _, name := range r.Names {
+		if blackDomain.CheckBlack(name) {
+			logging.RuntimeLog.Warningf("%s is in blacklist,skip...", name)
+			continue
+		}
+		if !z.Result.HasDomain(name) {
+			z.Result.SetDomain(name)
+		}
+	}
+}
+
+// TransferZone 向DNS服务器请求域传送，返回区域内的子域名
+func TransferZone(addr, zone string) (names []string) {
+	msg := new(dns.Msg)
+	msg.SetAxfr(dns.Fqdn(zone))
+	t := &dns.Transfer{DialTimeout: zoneTransferTimeout, ReadTimeout: zoneTransferTimeout}
+	envelopes, err := t.In(msg, addr)
+	if err != nil {
+		return
+	}
+	namesMap := make(map[string]struct{})
+	for e := range envelopes {
+		if e.Error != nil {
+			break
+		}
+		for _, rr := range e.RR {
+			if name := getZoneSubdomain(rr.Header().Name, zone); name != "" {
+				namesMap[name] = struct{}{}
+			}
+		}
+	}
+	for name := range namesMap {
+		names = append(names, name)
+	}
+	return
+}
+
+// WalkZone 沿NSEC记录的下一个域名遍历区域（NSEC3无法遍历），返回区域内的子域名
+func WalkZone(addr, zone string) (names []string) {
+	client := &dns.Client{Net: "udp", Timeout: resolverTimeout}
+	apex := dns.Fqdn(strings.ToLower(zone))
+	visited := make(map[string]struct{})
+	for name := apex; len(names) < zoneWalkMaxNames; {
+		visited[name] = struct{}{}
+		msg := new(dns.Msg)
+		msg.SetQuestion(name, dns.TypeNSEC)
+		msg.SetEdns0(4096, true)
+		in, _, err := client.Exchange(msg, addr)
+		if err == nil && in.Truncated {
+			in, _, err = (&dns.Client{Net: "tcp", Timeout: resolverTimeout}).Exchange(msg, addr)
+		}
+		if err != nil || in.Rcode != dns.RcodeSuccess {
+			return
+		}
+		var next string
+		for _, rr := range in.Answer {
+			if v, ok := rr.(*dns.NSEC); ok && strings.EqualFold(v.Hdr.Name, name) {
+				next = strings.ToLower(v.NextDomain)
+				break
+			}
+		}
+		// 回到区域的顶点或出现循环时遍历结束
+		if next == "" || !dns.IsSubDomain(apex, next) {
+			return
+		}
+		if _, ok := visited[next]; ok {
+			return
+		}
+		// 最小化NSEC（如Cloudflare的black lies）的下一个域名为\000.加查询的域名，无法遍历
+		if strings.HasPrefix(next, `\000.`) {
+			return
+		}
+		if subdomain := getZoneSubdomain(next, zone); subdomain != "" && utils.CheckDomain(subdomain) {
+			names = append(names, subdomain)
+		}
+		name = next
+	}
+	return
+}
+
+// getZoneSubdomain 获取区域内的子域名，忽略区域顶点及泛解析记录
+func getZoneSubdomain(name, zone string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	zone = strings.ToLower(strings.Trim(zone, "."))
+	if !strings.HasSuffix(name, "."+zone) || strings.HasPrefix(name, "*.") {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,40 @@
+package domainscan
+
+import (
+	"net"
+	"testing"
+)
+
+func TestZoneTransfer_RunZoneTransfer(t *testing.T) {
+	addr, shutdown := startTestDNSServer(t)
+	defer shutdown()
+
+	pool, err := newResolverPoolByAddrs([]string{addr}, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	// NS服务器的IP为127.0.0.1，使用测试DNS服务器的端口
+	_, zoneNameServerPort, _ = net.SplitHostPort(addr)
+	defer func() { zoneNameServerPort = "53" }()
+
+	z := NewZoneTransfer(Config{})
+	z.Result.DomainResult = make(map[string]*DomainResult)
+	for _, domain := range []string{"zone.test", "nsec.test", "blacklies.test", "normal.test"} {
+		z.RunZoneTransfer(pool, domain)
+	}
+	for _, v := range z.Vulnerability {
+		t.Log(v.Domain, v.NameServer, v.Type, v.Names)
+	}
+	if len(z.Vulnerability) != 2 {
+		t.Errorf("expect 2 vulnerability,got %d", len(z.Vulnerability))
+	}
+	for _, d := range []string{"www.zone.test", "oa.zone.test", "ns1.zone.test", "admin.nsec.test", "vpn.nsec.test"} {
+		if !z.Result.HasDomain(d) {
+			t.Errorf("%s not found", d)
+		}
+	}
+	if len(z.Result.DomainResult) != 5 {
+		t.Errorf("expect 5 domains,got %d", len(z.Result.DomainResult))
+	}
+}
@@ -0,0 +1,253 @@
+package fingerprint
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/remeh/sizedwaitgroup"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// CDNOriginSourceMX 邮件服务器（MX记录）的IP
+	CDNOriginSourceMX = "mx"
+	// CDNOriginSourceSPF SPF记录中的IP
+	CDNOriginSourceSPF = "spf"
+	// cdnOriginSPFMinMask SPF中ip4网段的掩码不小于该值时展开为IP
+	cdnOriginSPFMinMask = 28
+)
+
+// CDNOriginConfig CDN源站发现的任务参数
+type CDNOriginConfig struct {
+	Target      string `json:"target"`
+	OrgId       *int   `json:"orgId"`
+	WorkspaceId int    `json:"workspaceId"`
+}
+
+// CDNOriginResult 验证为源站的IP
+type CDNOriginResult struct {
+	Domain     string
+	IP         string
+	Source     string
+	Scheme     string
+	Port       int
+	StatusCode int
+	Title      string
+}
+
+// CDNOrigin CDN源站发现：收集域名的候选源站IP，使用域名作为Host头请求候选IP，与经过CDN访问的页面相同的作为源站
+type CDNOrigin struct {
+	Config CDNOriginConfig
+	// Candidates 域名的候选源站IP及来源（由server从已保存的历史解析记录、证书、子域名中获取）
+	Candidates map[string]map[string]string
+	Result     []CDNOriginResult
+
+	vhost *Vhost
+	mutex sync.Mutex
+}
+
+// NewCDNOrigin 创建CDN源站发现对象
+func NewCDNOrigin(config CDNOriginConfig) *CDNOrigin {
+	return &CDNOrigin{
+		Config:     config,
+		Candidates: make(map[string]map[string]string),
+		vhost:      NewVhost(VhostConfig{}),
+	}
+}
+
+// Do 执行CDN源站发现
+func (o *CDNOrigin) Do() {
+	swg := sizedwaitgroup.New(fpCDNOriginThreadNumber[conf.GetWorkerPerformanceMode()])
+	for _, line := range strings.Split(o.Config.Target, ",") {
+		domain := strings.ToLower(strings.TrimSpace(line))
+		if domain == "" || !utils.CheckDomain(domain) {
+			continue
+		}
+		swg.Add()
+		go func(domain string) {
+			defer swg.Done()
+			o.RunCDNOrigin(domain)
+		}(domain)
+	}
+	swg.Wait()
+}
+
+// RunCDNOrigin 对一个域名进行源站发现
+func (o *CDNOrigin) RunCDNOrigin(domain string) {
+	scheme, port, baseline, tolerance := o.getBaseline(domain)
+	if baseline == nil {
+		logging.RuntimeLog.Warningf("%s has no available response through cdn,skip origin discovery", domain)
+		return
+	}
+	candidates := o.getCandidates(domain, cdnOriginMaxCandidates[conf.GetWorkerPerformanceMode()])
+	logging.RuntimeLog.Infof("%s origin discovery with %d candidates", domain, len(candidates))
+
+	swg := sizedwaitgroup.New(fpCDNOriginRunnerThreads[conf.GetWorkerPerformanceMode()])
+	for ip, source := range candidates {
+		swg.Add()
+		go func(ip, source string) {
+			defer swg.Done()
+			r, ok := o.verify(domain, scheme, ip, port, *baseline, tolerance)
+			if !ok {
+				return
+			}
+			logging.RuntimeLog.Infof("find origin %s of %s,source:%s", ip, domain, source)
+			o.mutex.Lock()
+			o.Result = append(o.Result, CDNOriginResult{Domain: domain, IP: ip, Source: source, Scheme: scheme, Port: port, StatusCode: r.StatusCode, Title: r.Title})
+			o.mutex.Unlock()
+		}(ip, source)
+	}
+	swg.Wait()
+}
+
+// getBaseline 经过CDN访问域名，获取用于比较的基准页面及动态页面的长度容差；CDN返回错误页面的无法验证源站
+func (o *CDNOrigin) getBaseline(domain string) (scheme string, port int, baseline *vhostResponse, tolerance int) {
+	for _, scheme = range []string{"https", "http"} {
+		port = 443
+		if scheme == "http" {
+			port = 80
+		}
+		r1, err := o.vhost.request(scheme, domain, port, domain, domain)
+		if err != nil || r1.StatusCode >= 400 {
+			continue
+		}
+		// CDN可能在页面中插入内容，容差至少为页面长度的10%
+		tolerance = vhostMinLengthDelta
+		if delta := r1.Length / 10; delta > tolerance {
+			tolerance = delta
+		}
+		if r2, err := o.vhost.request(scheme, domain, port, domain, domain); err == nil {
+			if delta := abs(r1.Length-r2.Length) * 2; delta > tolerance {
+				tolerance = delta
+			}
+		}
+		return scheme, port, &r1, tolerance
+	}
+	return "", 0, nil, 0
+}
+
+// getCandidates 合并候选源站IP，去除域名当前解析的IP、内网IP及CDN的IP
+func (o *CDNOrigin) getCandidates(domain string, maxCandidates int) (candidates map[string]string) {
+	candidates = make(map[string]string)
+	excludes := make(map[string]struct{})
+	_, hosts := domainscan.ResolveDomain(domain)
+	for _, ip := range hosts {
+		excludes[ip] = struct{}{}
+	}
+	all := make(map[string]string)
+	for ip, source := range getMailCandidates(domain) {
+		all[ip] = source
+	}
+	// server提供的来源优先
+	for ip, source := range o.Candidates[domain] {
+		all[ip] = source
+	}
+	var ips []string
+	for ip := range all {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	cdnCheck := custom.NewCDNCheck()
+	for _, ip := range ips {
+		if _, ok := excludes[ip]; ok || !utils.CheckIPV4(ip) || isInternalIP(ip) {
+			continue
+		}
+		if cdnCheck.CheckIP(ip) || cdnCheck.CheckASN(ip) {
+			continue
+		}
+		candidates[ip] = all[ip]
+		if maxCandidates > 0 && len(candidates) >= maxCandidates {
+			break
+		}
+	}
+	return
+}
+
+// verify 使用域名作为Host头及SNI请求候选IP，与基准页面比较
+func (o *CDNOrigin) verify(domain, scheme, ip string, port int, baseline vhostResponse, tolerance int) (r vhostResponse, ok bool) {
+	r, err := o.vhost.request(scheme, ip, port, domain, domain)
+	if err != nil {
+		return
+	}
+	return r, isSameResponse(r, baseline, tolerance)
+}
+
+// isSameResponse 响应与基准页面的状态码、标题、跳转地址相同，且页面长度在容差范围内
+func isSameResponse(r, baseline vhostResponse, tolerance int) bool {
+	return r.StatusCode == baseline.StatusCode && r.Title == baseline.Title && r.Location == baseline.Location && abs(r.Length-baseline.Length) <= tolerance
+}
+
+// getMailCandidates 获取主域名的邮件服务器（MX记录）及SPF记录中的IP：自建邮件服务器常与源站位于同一网络
+func getMailCandidates(domain string) (candidates map[string]string) {
+	candidates = make(map[string]string)
+	tld := domainscan.NewTldExtract()
+	fld := tld.ExtractFLD(domain)
+	if fld == "" {
+		fld = domain
+	}
+	if mxs, err := net.LookupMX(fld); err == nil {
+		for _, mx := range mxs {
+			host := strings.TrimSuffix(mx.Host, ".")
+			_, ips := domainscan.ResolveDomain(host)
+			for _, ip := range ips {
+				candidates[ip] = fmt.Sprintf("%s:%s", CDNOriginSourceMX, host)
+			}
+		}
+	}
+	if txts, err := net.LookupTXT(fld); err == nil {
+		for _, txt := range txts {
+			ips, hosts := parseSPF(txt, fld)
+			for _, host := range hosts {
+				_, hostIPs := domainscan.ResolveDomain(host)
+				ips = append(ips, hostIPs...)
+			}
+			for _, ip := range ips {
+				if _, ok := candidates[ip]; !ok {
+					candidates[ip] = CDNOriginSourceSPF
+				}
+			}
+		}
+	}
+	return
+}
+
+// parseSPF 解析SPF记录中的ip4及a机制，返回IP及需要解析的域名；include的一般为第三方邮件服务，不进行处理
+func parseSPF(record, domain string) (ips []string, hosts []string) {
+	fields := strings.Fields(record)
+	if len(fields) == 0 || strings.ToLower(fields[0]) != "v=spf1" {
+		return
+	}
+	for _, field := range fields[1:] {
+		field = strings.ToLower(field)
+		// -为拒绝的主机
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		field = strings.TrimLeft(field, "+~?")
+		switch {
+		case strings.HasPrefix(field, "ip4:"):
+			value := strings.TrimPrefix(field, "ip4:")
+			if _, ipNet, err := net.ParseCIDR(value); err == nil {
+				if ones, _ := ipNet.Mask.Size(); ones >= cdnOriginSPFMinMask {
+					ips = append(ips, utils.ParseIP(value)...)
+				}
+			} else if utils.CheckIPV4(value) {
+				ips = append(ips, value)
+			}
+		case field == "a" || strings.HasPrefix(field, "a/"):
+			hosts = append(hosts, domain)
+		case strings.HasPrefix(field, "a:"):
+			host := strings.SplitN(strings.TrimPrefix(field, "a:"), "/", 2)[0]
+			if utils.CheckDomain(host) {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	return
+}
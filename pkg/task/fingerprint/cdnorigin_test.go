@@ -0,0 +1,51 @@
+package fingerprint
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestCDNOrigin_verify(t *testing.T) {
+	page := "<html><title>Example Site</title><body>welcome to example</body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "www.example.test" {
+			fmt.Fprint(w, page)
+			return
+		}
+		fmt.Fprint(w, "<html><title>Default Site</title></html>")
+	}))
+	defer server.Close()
+
+	ip, portString, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portString)
+	o := NewCDNOrigin(CDNOriginConfig{})
+	// CDN插入了部分内容
+	baseline := vhostResponse{StatusCode: http.StatusOK, Title: "Example Site", Length: len(page) + 30}
+	r, ok := o.verify("www.example.test", "http", ip, port, baseline, vhostMinLengthDelta)
+	t.Log(r, ok)
+	if !ok {
+		t.Errorf("origin should be verified")
+	}
+	if _, ok = o.verify("admin.example.test", "http", ip, port, baseline, vhostMinLengthDelta); ok {
+		t.Errorf("default site should not be verified as origin")
+	}
+}
+
+func TestParseSPF(t *testing.T) {
+	ips, hosts := parseSPF("v=spf1 ip4:1.2.3.4 ip4:5.6.7.8/30 ip4:10.0.0.0/8 -ip4:9.9.9.9 a a:mail.example.com/24 mx include:spf.mail.qq.com ~all", "example.com")
+	t.Log(ips, hosts)
+	if !reflect.DeepEqual(ips, []string{"1.2.3.4", "5.6.7.8", "5.6.7.9", "5.6.7.10", "5.6.7.11"}) {
+		t.Errorf("unexpected ips:%v", ips)
+	}
+	if !reflect.DeepEqual(hosts, []string{"example.com", "mail.example.com"}) {
+		t.Errorf("unexpected hosts:%v", hosts)
+	}
+	if ips, hosts = parseSPF("google-site-verification=xxxx", "example.com"); len(ips) > 0 || len(hosts) > 0 {
+		t.Errorf("not spf record")
+	}
+}
@@ -39,7 +39,7 @@ func NewFingerprintHub() *FingerprintHub {
 
 // Do 调用ObserverWard，获取指纹
 func (f *FingerprintHub) Do() {
-	swg := sizedwaitgroup.New(fpObserverWardThreadNumber[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(fpObserverWardThreadNumber[conf.GetWorkerPerformanceMode()])
 	btc := custom.NewBlackTargetCheck(custom.CheckAll)
 	if f.ResultPortScan.IPResult != nil {
 		for ipName, ipResult := range f.ResultPortScan.IPResult {
@@ -88,7 +88,7 @@ func NewHttpx() *Httpx {
 
 // Do 执行httpx
 func (x *Httpx) Do() {
-	swg := sizedwaitgroup.New(fpHttpxThreadNumber[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(fpHttpxThreadNumber[conf.GetWorkerPerformanceMode()])
 	btc := custom.NewBlackTargetCheck(custom.CheckAll)
 	if x.ResultPortScan.IPResult != nil {
 		for ipName, ipResult := range x.ResultPortScan.IPResult {
@@ -20,6 +20,10 @@ type HttpxFinger struct {
 	Httpx
 	fpWebFingerprintHub []WebFingerPrint
 	fpCustom            []CustomFingerPrint
+	// Leak 敏感信息泄露检测，为nil时不检测
+	Leak *LeakDetect
+	// Waf WAF检测，为nil时不检测
+	Waf *WafDetect
 }
 
 // WebFingerPrint 匹配web_fingerprint_v3.json的指纹结构
@@ -97,6 +101,18 @@ func (h *HttpxFinger) loadCustomFingerprint() {
 	}
 }
 
+// EnableLeakDetect 对保存的响应进行敏感信息泄露检测
+func (h *HttpxFinger) EnableLeakDetect() {
+	h.Leak = NewLeakDetect()
+	h.FingerPrintFunc = append(h.FingerPrintFunc, h.fingerPrintFuncForLeak)
+}
+
+// EnableWafDetect 对web服务进行WAF检测
+func (h *HttpxFinger) EnableWafDetect() {
+	h.Waf = NewWafDetect()
+	h.FingerPrintFunc = append(h.FingerPrintFunc, h.fingerPrintFuncForWaf)
+}
+
 // DoHttpxAndFingerPrint 执行指纹识别
 func (h *HttpxFinger) DoHttpxAndFingerPrint() {
 	// 保存响应结果，用于自定义的指纹分析
@@ -228,6 +244,58 @@ func (h *HttpxFinger) fingerPrintFuncForSaveHttpHeaderAndBody(domain string, ip
 	return
 }
 
+// fingerPrintFuncForLeak 回调函数，检测响应头与响应体中的敏感信息泄露
+func (h *HttpxFinger) fingerPrintFuncForLeak(domain string, ip string, port int, url string, result []FingerAttrResult, storedResponsePathFile string) (fingers []string) {
+	body, header, _ := h.parseHttpHeaderAndBody(h.getStoredResponseContent(storedResponsePathFile))
+	if len(header) == 0 && len(body) == 0 {
+		return
+	}
+	target := domain
+	if len(target) == 0 {
+		target = ip
+	}
+	// 使用httpx获取的完整URL
+	if httpxResult := getHttpxResult(result); httpxResult != nil && httpxResult.Url != "" {
+		url = httpxResult.Url
+	}
+	for _, r := range h.Leak.Check(target, url, header, body) {
+		logging.RuntimeLog.Infof("find leak %s:%s on %s", r.Category, r.Name, r.Url)
+	}
+	return
+}
+
+// fingerPrintFuncForWaf 回调函数，检测WAF并保存为端口或域名的属性
+func (h *HttpxFinger) fingerPrintFuncForWaf(domain string, ip string, port int, url string, result []FingerAttrResult, storedResponsePathFile string) (fingers []string) {
+	httpxResult := getHttpxResult(result)
+	if httpxResult == nil || httpxResult.Url == "" {
+		return
+	}
+	body, header, _ := h.parseHttpHeaderAndBody(h.getStoredResponseContent(storedResponsePathFile))
+	for _, name := range h.Waf.Check(httpxResult.Url, httpxResult.StatusCode, header, body) {
+		logging.RuntimeLog.Infof("find waf %s on %s", name, httpxResult.Url)
+		if len(domain) > 0 {
+			h.ResultDomainScan.SetDomainAttr(domain, domainscan.DomainAttrResult{Source: "waf", Tag: "waf", Content: name})
+		} else {
+			h.ResultPortScan.SetPortAttr(ip, port, portscan.PortAttrResult{Source: "waf", Tag: "waf", Content: name})
+		}
+	}
+	return
+}
+
+// getHttpxResult 从指纹结果中获取httpx的原始结果
+func getHttpxResult(result []FingerAttrResult) *HttpxResult {
+	for _, fa := range result {
+		if fa.Tag == "httpx" {
+			var httpxResult HttpxResult
+			if err := json.Unmarshal([]byte(fa.Content), &httpxResult); err == nil {
+				return &httpxResult
+			}
+			break
+		}
+	}
+	return nil
+}
+
 // getStoredResponseContent 读取httpx保存的response内容
 func (h *HttpxFinger) getStoredResponseContent(storedResponsePathFile string) string {
 	content, err := os.ReadFile(storedResponsePathFile)
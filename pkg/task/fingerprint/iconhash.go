@@ -59,7 +59,7 @@ func NewIconHash() *IconHash {
 }
 
 func (i *IconHash) Do() {
-	swg := sizedwaitgroup.New(fpIconHashThreadNumber[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(fpIconHashThreadNumber[conf.GetWorkerPerformanceMode()])
 
 	btc := custom.NewBlackTargetCheck(custom.CheckAll)
 	if i.ResultPortScan.IPResult != nil {
@@ -0,0 +1,218 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// LeakCategoryStackTrace 异常堆栈、错误信息
+	LeakCategoryStackTrace = "stacktrace"
+	// LeakCategoryDirListing 目录浏览
+	LeakCategoryDirListing = "dirlisting"
+	// LeakCategoryDebug 调试页面
+	LeakCategoryDebug = "debug"
+	// LeakCategoryCredential 凭据、密钥
+	LeakCategoryCredential = "credential"
+	// LeakCategoryInternalIP 内网IP
+	LeakCategoryInternalIP = "internalip"
+	// LeakCategoryPII 个人信息
+	LeakCategoryPII = "pii"
+
+	// LeakPartHeader 只匹配响应头
+	LeakPartHeader = "header"
+	// LeakPartBody 只匹配响应体
+	LeakPartBody = "body"
+
+	// leakMaxSamples 每条规则保存的最大匹配数量
+	leakMaxSamples = 10
+	// leakMaxContentLength 保存的匹配内容的最大长度
+	leakMaxContentLength = 200
+)
+
+// LeakRule 敏感信息泄露的规则：part为匹配的位置（header、body，为空时都匹配），group为保存的正则分组
+type LeakRule struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Part     string `json:"part,omitempty"`
+	Regex    string `json:"regex"`
+	Group    int    `json:"group,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+// LeakResult 敏感信息泄露的结果
+type LeakResult struct {
+	Target   string
+	Url      string
+	Name     string
+	Category string
+	Content  []string
+}
+
+// LeakDetect 对httpx保存的响应头及响应体进行敏感信息泄露检测
+type LeakDetect struct {
+	Rules  []LeakRule
+	Result []LeakResult
+
+	mutex sync.Mutex
+}
+
+// builtinLeakRules 内置的规则
+var builtinLeakRules = []LeakRule{
+	// 异常堆栈、错误信息
+	{Name: "java-stacktrace", Category: LeakCategoryStackTrace, Part: LeakPartBody, Regex: `\bat (?:[\w$]+\.)+[\w$<>]+\([\w$]+\.java:\d+\)`},
+	{Name: "python-traceback", Category: LeakCategoryStackTrace, Part: LeakPartBody, Regex: `Traceback \(most recent call last\):`},
+	{Name: "php-error", Category: LeakCategoryStackTrace, Part: LeakPartBody, Regex: `<b>(?:Fatal error|Warning|Parse error|Notice)</b>:\s+[^<]{0,200} in <b>([^<]+)</b> on line <b>\d+</b>`, Group: 1},
+	{Name: "aspnet-error", Category: LeakCategoryStackTrace, Part: LeakPartBody, Regex: `Server Error in '[^']*' Application|\[\w+Exception: [^\]]{0,200}\]`},
+	{Name: "go-panic", Category: LeakCategoryStackTrace, Part: LeakPartBody, Regex: `goroutine \d+ \[running\]:`},
+	{Name: "sql-error", Category: LeakCategoryStackTrace, Part: LeakPartBody, Regex: `You have an error in your SQL syntax|ORA-\d{5}:|SQLSTATE\[\w+\]|Unclosed quotation mark after the character string|PG::SyntaxError|psycopg2\.errors\.\w+`},
+	// 目录浏览
+	{Name: "directory-listing", Category: LeakCategoryDirListing, Part: LeakPartBody, Regex: `(?i)<title>\s*(Index of /[^<]*|Directory listing for [^<]*)</title>`, Group: 1},
+	{Name: "iis-directory-listing", Category: LeakCategoryDirListing, Part: LeakPartBody, Regex: `\[To Parent Directory\]`},
+	// 调试页面
+	{Name: "django-debug", Category: LeakCategoryDebug, Part: LeakPartBody, Regex: `You're seeing this error because you have <code>DEBUG = True</code>`},
+	{Name: "laravel-debug", Category: LeakCategoryDebug, Part: LeakPartBody, Regex: `Whoops! There was an error|<title>Whoops!`},
+	{Name: "werkzeug-debugger", Category: LeakCategoryDebug, Part: LeakPartBody, Regex: `Werkzeug Debugger|__debugger__=yes`},
+	{Name: "spring-whitelabel", Category: LeakCategoryDebug, Part: LeakPartBody, Regex: `Whitelabel Error Page`},
+	{Name: "rails-debug", Category: LeakCategoryDebug, Part: LeakPartBody, Regex: `Action Controller: Exception caught`},
+	{Name: "phpinfo", Category: LeakCategoryDebug, Part: LeakPartBody, Regex: `<title>phpinfo\(\)</title>`},
+	{Name: "symfony-profiler", Category: LeakCategoryDebug, Part: LeakPartHeader, Regex: `(?im)^X-Debug-Token(?:-Link)?:\s*(\S+)`, Group: 1},
+	// 凭据、密钥
+	{Name: "private-key", Category: LeakCategoryCredential, Regex: `-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`},
+	{Name: "aws-access-key", Category: LeakCategoryCredential, Regex: `\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`, Group: 1},
+	{Name: "aliyun-access-key", Category: LeakCategoryCredential, Regex: `\b(LTAI[0-9A-Za-z]{12,20})\b`, Group: 1},
+	{Name: "google-api-key", Category: LeakCategoryCredential, Regex: `\b(AIza[0-9A-Za-z_-]{35})`, Group: 1},
+	{Name: "github-token", Category: LeakCategoryCredential, Regex: `\b(gh[pousr]_[A-Za-z0-9]{36})\b`, Group: 1},
+	{Name: "slack-token", Category: LeakCategoryCredential, Regex: `\b(xox[baprs]-[0-9A-Za-z-]{10,48})\b`, Group: 1},
+	{Name: "password-assignment", Category: LeakCategoryCredential, Part: LeakPartBody, Regex: `(?i)["']?(?:password|passwd|pwd|secret_key|access_token)["']?\s*[:=]\s*["']([^"'\s]{6,64})["']`, Group: 1},
+	{Name: "database-connection-string", Category: LeakCategoryCredential, Regex: `\b((?:mysql|postgres(?:ql)?|mongodb(?:\+srv)?|redis|jdbc:[a-z]+)://[^\s:/@"'<>]+:[^\s@"'<>]+@[^\s"'<>]+)`, Group: 1},
+	// 内网IP
+	{Name: "internal-ip", Category: LeakCategoryInternalIP, Regex: `\b((?:10\.\d{1,3}|172\.(?:1[6-9]|2\d|3[01])|192\.168)\.\d{1,3}\.\d{1,3})\b`, Group: 1},
+	// 个人信息
+	{Name: "china-idcard", Category: LeakCategoryPII, Part: LeakPartBody, Regex: `\b([1-9]\d{5}(?:19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx])\b`, Group: 1},
+	{Name: "china-mobile", Category: LeakCategoryPII, Part: LeakPartBody, Regex: `(?:^|[^\d.])(1[3-9]\d{9})(?:[^\d.]|$)`, Group: 1},
+}
+
+// leakValidators 对匹配的内容进一步校验，减少误报
+var leakValidators = map[string]func(content string) bool{
+	"china-idcard": checkChinaIDCard,
+}
+
+// NewLeakDetect 创建敏感信息泄露检测对象，加载内置及自定义的规则
+func NewLeakDetect() *LeakDetect {
+	l := &LeakDetect{}
+	customRules, err := LoadLeakRule(filepath.Join(conf.GetRootPath(), "thirdparty/custom/leak_rule.json"))
+	if err != nil {
+		logging.CLILog.Warning(err)
+	}
+	l.Rules = mergeLeakRule(builtinLeakRules, customRules)
+	logging.CLILog.Infof("Load leak rule total:%d", len(l.Rules))
+	return l
+}
+
+// LoadLeakRule 加载自定义的规则
+func LoadLeakRule(ruleFile string) (rules []LeakRule, err error) {
+	content, err := os.ReadFile(ruleFile)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(content, &rules)
+	return
+}
+
+// mergeLeakRule 合并规则并编译正则，自定义规则与内置规则同名时覆盖内置规则
+func mergeLeakRule(builtinRules, customRules []LeakRule) (rules []LeakRule) {
+	customMap := make(map[string]struct{})
+	for _, r := range customRules {
+		customMap[r.Name] = struct{}{}
+	}
+	var allRules []LeakRule
+	for _, r := range builtinRules {
+		if _, ok := customMap[r.Name]; !ok {
+			allRules = append(allRules, r)
+		}
+	}
+	for _, r := range append(allRules, customRules...) {
+		var err error
+		if r.regex, err = regexp.Compile(r.Regex); err != nil {
+			logging.RuntimeLog.Errorf("compile leak rule %s fail:%v", r.Name, err)
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return
+}
+
+// Check 检测一个web服务的响应，target为IP或域名
+func (l *LeakDetect) Check(target, url, header, body string) (result []LeakResult) {
+	// 对内网IP的目标，内网IP不作为泄露
+	isInternalTarget := isInternalIP(target)
+	for _, r := range l.Rules {
+		if r.Category == LeakCategoryInternalIP && isInternalTarget {
+			continue
+		}
+		var contents []string
+		contentMap := make(map[string]struct{})
+		for _, part := range []string{LeakPartHeader, LeakPartBody} {
+			if r.Part != "" && r.Part != part {
+				continue
+			}
+			text := body
+			if part == LeakPartHeader {
+				text = header
+			}
+			for _, m := range r.regex.FindAllStringSubmatch(text, -1) {
+				if r.Group >= len(m) || m[r.Group] == "" || len(contents) >= leakMaxSamples {
+					continue
+				}
+				content := m[r.Group]
+				if validator, ok := leakValidators[r.Name]; ok && !validator(content) {
+					continue
+				}
+				if len(content) > leakMaxContentLength {
+					content = content[:leakMaxContentLength]
+				}
+				if _, ok := contentMap[content]; !ok {
+					contentMap[content] = struct{}{}
+					contents = append(contents, content)
+				}
+			}
+		}
+		if len(contents) > 0 {
+			result = append(result, LeakResult{Target: target, Url: url, Name: r.Name, Category: r.Category, Content: contents})
+		}
+	}
+	if len(result) > 0 {
+		l.mutex.Lock()
+		l.Result = append(l.Result, result...)
+		l.mutex.Unlock()
+	}
+	return
+}
+
+// isInternalIP 是否为内网IP
+func isInternalIP(ip string) bool {
+	parsedIP := net.ParseIP(ip)
+	return parsedIP != nil && (parsedIP.IsPrivate() || parsedIP.IsLoopback())
+}
+
+// checkChinaIDCard 校验身份证号码的校验位
+func checkChinaIDCard(id string) bool {
+	if len(id) != 18 {
+		return false
+	}
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(id[i]-'0') * w
+	}
+	return "10X98765432"[sum%11] == strings.ToUpper(id[17:])[0]
+}
@@ -19,20 +19,54 @@ var (
 	fpScreenshotThreadNum      = make(map[string]int)
 	fpObserverWardThreadNumber = make(map[string]int)
 	fpIconHashThreadNumber     = make(map[string]int)
+	fpVhostThreadNumber        = make(map[string]int)
+	fpVhostRunnerThreads       = make(map[string]int)
+	vhostMaxCandidates         = make(map[string]int)
+	fpCDNOriginThreadNumber    = make(map[string]int)
+	fpCDNOriginRunnerThreads   = make(map[string]int)
+	cdnOriginMaxCandidates     = make(map[string]int)
 )
 
 func init() {
 	fpHttpxThreadNumber[conf.HighPerformance] = 8
 	fpHttpxThreadNumber[conf.NormalPerformance] = 4
+	fpHttpxThreadNumber[conf.LowPerformance] = 2
 	//
 	fpScreenshotThreadNum[conf.HighPerformance] = 6
 	fpScreenshotThreadNum[conf.NormalPerformance] = 3
+	fpScreenshotThreadNum[conf.LowPerformance] = 1
 	//
 	fpObserverWardThreadNumber[conf.HighPerformance] = 8
 	fpObserverWardThreadNumber[conf.NormalPerformance] = 4
+	fpObserverWardThreadNumber[conf.LowPerformance] = 2
 	//
 	fpIconHashThreadNumber[conf.HighPerformance] = 8
 	fpIconHashThreadNumber[conf.NormalPerformance] = 4
+	fpIconHashThreadNumber[conf.LowPerformance] = 2
+	//
+	fpVhostThreadNumber[conf.HighPerformance] = 8
+	fpVhostThreadNumber[conf.NormalPerformance] = 4
+	fpVhostThreadNumber[conf.LowPerformance] = 2
+	//
+	fpVhostRunnerThreads[conf.HighPerformance] = 20
+	fpVhostRunnerThreads[conf.NormalPerformance] = 10
+	fpVhostRunnerThreads[conf.LowPerformance] = 5
+	//
+	vhostMaxCandidates[conf.HighPerformance] = 5000
+	vhostMaxCandidates[conf.NormalPerformance] = 2000
+	vhostMaxCandidates[conf.LowPerformance] = 1000
+	//
+	fpCDNOriginThreadNumber[conf.HighPerformance] = 8
+	fpCDNOriginThreadNumber[conf.NormalPerformance] = 4
+	fpCDNOriginThreadNumber[conf.LowPerformance] = 2
+	//
+	fpCDNOriginRunnerThreads[conf.HighPerformance] = 20
+	fpCDNOriginRunnerThreads[conf.NormalPerformance] = 10
+	fpCDNOriginRunnerThreads[conf.LowPerformance] = 5
+	//
+	cdnOriginMaxCandidates[conf.HighPerformance] = 500
+	cdnOriginMaxCandidates[conf.NormalPerformance] = 200
+	cdnOriginMaxCandidates[conf.LowPerformance] = 100
 }
 
 type Config struct {
@@ -51,7 +51,7 @@ func NewScreenShot() *ScreenShot {
 
 // Do 执行任务
 func (s *ScreenShot) Do() {
-	swg := sizedwaitgroup.New(fpScreenshotThreadNum[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(fpScreenshotThreadNum[conf.GetWorkerPerformanceMode()])
 
 	btc := custom.NewBlackTargetCheck(custom.CheckAll)
 	if s.ResultPortScan.IPResult != nil {
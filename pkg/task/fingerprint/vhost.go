@@ -0,0 +1,316 @@
+package fingerprint
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"github.com/remeh/sizedwaitgroup"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// vhostHttpTimeout 请求的超时时间
+	vhostHttpTimeout = 10 * time.Second
+	// vhostMaxBodySize 读取页面内容的最大长度
+	vhostMaxBodySize = 1024 * 1024
+	// vhostMinLengthDelta 与基准页面长度的最小差异，小于该值认为是相同的页面
+	vhostMinLengthDelta = 50
+)
+
+var vhostTitleRegex = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// vhostServerNameKey 请求的context中保存TLS SNI的key
+type vhostServerNameKey struct{}
+
+// VhostConfig 虚拟主机发现的任务参数
+type VhostConfig struct {
+	Target      string `json:"target"`
+	OrgId       *int   `json:"orgId"`
+	WorkspaceId int    `json:"workspaceId"`
+}
+
+// VhostResult 发现的虚拟主机
+type VhostResult struct {
+	IP         string
+	Port       int
+	Scheme     string
+	Host       string
+	StatusCode int
+	Title      string
+	Length     int
+}
+
+// vhostResponse 用于比较的页面特征
+type vhostResponse struct {
+	StatusCode int
+	Title      string
+	Length     int
+	Location   string
+}
+
+// Vhost 虚拟主机发现：对IP的web端口使用候选域名作为Host头进行请求，与默认站点（基准页面）不同的响应作为虚拟主机
+type Vhost struct {
+	Config VhostConfig
+	// IPPort 需要发现虚拟主机的IP及端口
+	IPPort map[string][]int
+	// Names 候选的域名（工作空间中的子域名）
+	Names  []string
+	Result []VhostResult
+
+	client *http.Client
+	mutex  sync.Mutex
+}
+
+// NewVhost 创建虚拟主机发现对象
+func NewVhost(config VhostConfig) *Vhost {
+	dialer := &net.Dialer{Timeout: vhostHttpTimeout}
+	transport := &http.Transport{
+		DialContext:       dialer.DialContext,
+		DisableKeepAlives: true,
+		// 使用候选域名作为TLS的SNI
+		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			serverName, _ := ctx.Value(vhostServerNameKey{}).(string)
+			tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{InsecureSkipVerify: true, ServerName: serverName}}
+			return tlsDialer.DialContext(ctx, network, addr)
+		},
+	}
+	return &Vhost{
+		Config: config,
+		IPPort: make(map[string][]int),
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   vhostHttpTimeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Do 执行虚拟主机发现
+func (v *Vhost) Do() {
+	words := loadVhostWordlist(filepath.Join(conf.GetRootPath(), "thirdparty/dict/vhost.txt"))
+	swg := sizedwaitgroup.New(fpVhostThreadNumber[conf.GetWorkerPerformanceMode()])
+	for ip, ports := range v.IPPort {
+		for _, port := range ports {
+			if _, ok := blankPort[port]; ok {
+				continue
+			}
+			swg.Add()
+			go func(ip string, port int) {
+				defer swg.Done()
+				v.RunVhost(ip, port, words)
+			}(ip, port)
+		}
+	}
+	swg.Wait()
+}
+
+// RunVhost 对一个IP的端口进行虚拟主机发现
+func (v *Vhost) RunVhost(ip string, port int, words []string) {
+	scheme, baselines, tolerance := v.getBaseline(ip, port)
+	if len(baselines) == 0 {
+		return
+	}
+	var certNames []string
+	if scheme == "https" {
+		certNames = getCertNames(ip, port)
+	}
+	candidates := v.getCandidates(ip, certNames, words, vhostMaxCandidates[conf.GetWorkerPerformanceMode()])
+	logging.RuntimeLog.Infof("%s:%d vhost discovery with %d candidates", ip, port, len(candidates))
+
+	swg := sizedwaitgroup.New(fpVhostRunnerThreads[conf.GetWorkerPerformanceMode()])
+	for _, name := range candidates {
+		swg.Add()
+		go func(name string) {
+			defer swg.Done()
+			// Host头的变化：域名、域名:端口
+			hosts := []string{name}
+			if !(scheme == "http" && port == 80) && !(scheme == "https" && port == 443) {
+				hosts = append(hosts, fmt.Sprintf("%s:%d", name, port))
+			}
+			for _, host := range hosts {
+				r, err := v.request(scheme, ip, port, host, name)
+				if err != nil || !isDistinctResponse(r, baselines, tolerance) {
+					continue
+				}
+				logging.RuntimeLog.Infof("find vhost %s on %s://%s:%d", name, scheme, ip, port)
+				v.mutex.Lock()
+				v.Result = append(v.Result, VhostResult{IP: ip, Port: port, Scheme: scheme, Host: name, StatusCode: r.StatusCode, Title: r.Title, Length: r.Length})
+				v.mutex.Unlock()
+				break
+			}
+		}(name)
+	}
+	swg.Wait()
+}
+
+// getBaseline 获取端口的协议及基准页面（IP作为Host、不存在的域名作为Host），以及动态页面的长度容差
+func (v *Vhost) getBaseline(ip string, port int) (scheme string, baselines []vhostResponse, tolerance int) {
+	schemes := []string{"https", "http"}
+	if port == 80 {
+		schemes = []string{"http", "https"}
+	}
+	for _, scheme = range schemes {
+		ipHost := net.JoinHostPort(ip, strconv.Itoa(port))
+		r1, err := v.request(scheme, ip, port, ipHost, "")
+		if err != nil {
+			continue
+		}
+		baselines = append(baselines, r1)
+		// 再次请求，页面长度的变化作为动态页面的容差
+		tolerance = vhostMinLengthDelta
+		if r2, err := v.request(scheme, ip, port, ipHost, ""); err == nil {
+			if delta := abs(r1.Length-r2.Length) * 2; delta > tolerance {
+				tolerance = delta
+			}
+		}
+		randomHost := fmt.Sprintf("%s.invalid", utils.GetRandomString2(16))
+		if r3, err := v.request(scheme, ip, port, randomHost, randomHost); err == nil {
+			baselines = append(baselines, r3)
+		}
+		return
+	}
+	return "", nil, 0
+}
+
+// getCandidates 生成候选域名：证书中的域名、工作空间中的子域名、字典与主域名组合的域名
+func (v *Vhost) getCandidates(ip string, certNames []string, words []string, maxCandidates int) (candidates []string) {
+	namesMap := make(map[string]struct{})
+	add := func(name string) bool {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := namesMap[name]; ok || name == ip || !utils.CheckDomain(name) {
+			return true
+		}
+		namesMap[name] = struct{}{}
+		candidates = append(candidates, name)
+		return maxCandidates <= 0 || len(candidates) < maxCandidates
+	}
+	for _, names := range [][]string{certNames, v.Names} {
+		for _, name := range names {
+			if !add(name) {
+				return
+			}
+		}
+	}
+	if len(words) == 0 {
+		return
+	}
+	fldMap := make(map[string]struct{})
+	var flds []string
+	tld := domainscan.NewTldExtract()
+	for _, name := range candidates {
+		if fld := tld.ExtractFLD(name); fld != "" {
+			if _, ok := fldMap[fld]; !ok {
+				fldMap[fld] = struct{}{}
+				flds = append(flds, fld)
+			}
+		}
+	}
+	for _, fld := range flds {
+		for _, word := range words {
+			if !add(fmt.Sprintf("%s.%s", word, fld)) {
+				return
+			}
+		}
+	}
+	return
+}
+
+// request 使用指定的Host头请求IP的端口，serverName为TLS的SNI
+func (v *Vhost) request(scheme, ip string, port int, host, serverName string) (r vhostResponse, err error) {
+	ctx := context.WithValue(context.Background(), vhostServerNameKey{}, serverName)
+	url := fmt.Sprintf("%s://%s/", scheme, net.JoinHostPort(ip, strconv.Itoa(port)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return
+	}
+	req.Host = host
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/107.0.0.0 Safari/537.36")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, vhostMaxBodySize))
+
+	r.StatusCode = resp.StatusCode
+	r.Length = len(body)
+	if m := vhostTitleRegex.FindSubmatch(body); len(m) > 1 {
+		r.Title = strings.TrimSpace(html.UnescapeString(string(m[1])))
+	}
+	// 跳转地址中包含Host的，替换后再比较
+	r.Location = strings.ReplaceAll(resp.Header.Get("Location"), host, "{host}")
+	if serverName != "" {
+		r.Location = strings.ReplaceAll(r.Location, serverName, "{host}")
+	}
+	return
+}
+
+// isDistinctResponse 响应是否与所有的基准页面都不相同；400、421为服务器拒绝了Host
+func isDistinctResponse(r vhostResponse, baselines []vhostResponse, tolerance int) bool {
+	if r.StatusCode == http.StatusBadRequest || r.StatusCode == http.StatusMisdirectedRequest {
+		return false
+	}
+	for _, b := range baselines {
+		if r.StatusCode == b.StatusCode && r.Title == b.Title && r.Location == b.Location && abs(r.Length-b.Length) <= tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// getCertNames 获取端口TLS证书中的域名
+func getCertNames(ip string, port int) (names []string) {
+	dialer := &net.Dialer{Timeout: vhostHttpTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(ip, strconv.Itoa(port)), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return
+	}
+	for _, name := range append([]string{certs[0].Subject.CommonName}, certs[0].DNSNames...) {
+		names = append(names, strings.TrimPrefix(name, "*."))
+	}
+	return
+}
+
+// loadVhostWordlist 加载虚拟主机的字典
+func loadVhostWordlist(wordlistFile string) (words []string) {
+	content, err := os.ReadFile(wordlistFile)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		return
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		word := strings.ToLower(strings.TrimSpace(line))
+		if word != "" && !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+	return
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
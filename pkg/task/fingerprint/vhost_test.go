@@ -0,0 +1,51 @@
+package fingerprint
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestVhost_RunVhost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		switch {
+		case r.Host == "admin.vhost.test":
+			fmt.Fprint(w, "<html><title>Admin Console</title><body>login</body></html>")
+		case r.Host != host && host == "intranet.vhost.test":
+			// 只匹配带端口的Host头
+			fmt.Fprint(w, "<html><title>Intranet</title><body>welcome</body></html>")
+		default:
+			// 默认站点跳转到https的相同Host，跳转地址随Host变化
+			http.Redirect(w, r, "https://"+r.Host+"/", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	ip, portString, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portString)
+	v := NewVhost(VhostConfig{})
+	v.Names = []string{"admin.vhost.test", "intranet.vhost.test", "redirect.vhost.test", "www.vhost.test"}
+	v.RunVhost(ip, port, nil)
+	for _, r := range v.Result {
+		t.Log(r)
+	}
+	found := make(map[string]struct{})
+	for _, r := range v.Result {
+		found[r.Host] = struct{}{}
+	}
+	for _, host := range []string{"admin.vhost.test", "intranet.vhost.test"} {
+		if _, ok := found[host]; !ok {
+			t.Errorf("vhost %s not found", host)
+		}
+	}
+	if len(v.Result) != 2 {
+		t.Errorf("expect 2 vhost,got %d", len(v.Result))
+	}
+}
@@ -0,0 +1,197 @@
+package fingerprint
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// WafGeneric 未匹配到厂商特征，但触发请求被拦截
+	WafGeneric = "Generic"
+
+	// wafHttpTimeout 触发请求的超时时间
+	wafHttpTimeout = 10 * time.Second
+	// wafMaxBodySize 读取页面内容的最大长度
+	wafMaxBodySize = 512 * 1024
+)
+
+// WafSignature WAF厂商的特征：Header匹配响应头（包括Set-Cookie），Body匹配响应体，任一匹配即认为是该厂商
+type WafSignature struct {
+	Name   string
+	Header string
+	Body   string
+
+	header *regexp.Regexp
+	body   *regexp.Regexp
+}
+
+// builtinWafSignatures 内置的WAF厂商特征
+var builtinWafSignatures = []WafSignature{
+	{Name: "Cloudflare", Header: `(?im)^(server:\s*cloudflare|cf-ray:|set-cookie:\s*__cf)`, Body: `Attention Required! \| Cloudflare|cf-error-details|cloudflare-nginx`},
+	{Name: "Aliyun", Header: `(?im)^set-cookie:\s*acw_(tc|sc__v\d)=`, Body: `errors\.aliyun\.com|由于您访问的URL有可能对网站造成安全威胁，您的访问被阻断`},
+	{Name: "Tencent", Header: `(?im)^server:\s*tencent-cloud-waf`, Body: `waf\.tencent-cloud\.com|腾讯T-Sec Web应用防火墙`},
+	{Name: "Huawei", Header: `(?im)^(set-cookie:\s*HWWAFSESID=|server:\s*HuaweiCloudWAF)`, Body: `hwclouds\.com/waf|hws_security`},
+	{Name: "Safeline", Header: `(?im)^set-cookie:\s*sl-session=`, Body: `<!-- event_id: [0-9a-f]{32} -->|safeline\.chaitin\.cn`},
+	{Name: "ModSecurity", Header: `(?im)^server:.*(mod_security|NOYB)`, Body: `This error was generated by Mod_Security|rules of the mod_security module|ModSecurity Action`},
+	{Name: "Akamai", Header: `(?im)^server:\s*AkamaiGHost`, Body: `(?s)<TITLE>Access Denied</TITLE>.*Reference&#32;&#35;[0-9a-f.]+`},
+	{Name: "Imperva", Header: `(?im)^(set-cookie:\s*(incap_ses|visid_incap)_|x-iinfo:|x-cdn:\s*incapsula)`, Body: `Incapsula incident ID|_Incapsula_Resource`},
+	{Name: "AWS", Header: `(?im)^x-amzn-waf-`, Body: `(?s)Request blocked\..*Generated by cloudfront \(CloudFront\)`},
+	{Name: "F5 BIG-IP ASM", Header: `(?im)^set-cookie:\s*TS[0-9a-f]{6,8}=`, Body: `The requested URL was rejected\. Please consult with your administrator\.`},
+	{Name: "Sucuri", Header: `(?im)^(x-sucuri-(id|block):|server:\s*sucuri)`, Body: `Sucuri WebSite Firewall`},
+	{Name: "FortiWeb", Header: `(?im)^set-cookie:\s*FORTIWAFSID=`, Body: `\.fgd_icon|FortiWeb Application Firewall`},
+	{Name: "Barracuda", Header: `(?im)^set-cookie:\s*(barra_counter_session|BNI__BARRACUDA_LB_COOKIE)=`},
+	{Name: "Wordfence", Body: `Generated by Wordfence|This response was generated by Wordfence`},
+	{Name: "SafeDog", Header: `(?im)^(server:.*safedog|x-powered-by:\s*WAF/2\.0|set-cookie:\s*safedog-flow-item=)`, Body: `(?i)safedog\.cn|404\.safedog`},
+	{Name: "Yunsuo", Header: `(?im)^set-cookie:\s*yunsuo_session`, Body: `yunsuologo`},
+	{Name: "BT", Body: `宝塔网站防火墙|btwaf`},
+	{Name: "D盾", Body: `D盾_拦截`},
+	{Name: "360", Header: `(?im)^(server:\s*360wzws|x-safe-firewall:|x-powered-by-360wzb)`, Body: `wangzhan\.360\.cn|360wzws`},
+	{Name: "Jiasule", Header: `(?im)^(server:\s*jiasule|set-cookie:\s*__jsluid)`, Body: `static\.jiasule\.com|notice-jiasule`},
+	{Name: "Yunjiasu", Header: `(?im)^server:\s*yunjiasu`, Body: `yunjiasu-nginx`},
+}
+
+// wafTrigger 触发WAF拦截的请求参数（未编码）
+type wafTrigger struct {
+	Key   string
+	Value string
+}
+
+// wafTriggers 触发WAF拦截的请求参数：只包含常见的攻击特征，不会对目标造成影响
+var wafTriggers = []wafTrigger{
+	{Key: "id", Value: "1 AND 1=1 UNION SELECT 1,2,3--"},
+	{Key: "q", Value: "<script>alert(1)</script>"},
+	{Key: "file", Value: "../../../../etc/passwd"},
+}
+
+// wafBlockStatus WAF拦截时常见的状态码（501、503等也可能是服务本身的错误，不作为拦截的依据）
+var wafBlockStatus = map[int]struct{}{
+	http.StatusForbidden:     {},
+	http.StatusNotAcceptable: {},
+	http.StatusTeapot:        {},
+	419:                      {},
+	999:                      {},
+}
+
+// WafDetect WAF检测：先匹配httpx保存的响应，未匹配到时发送触发请求，匹配拦截页面的厂商特征或拦截的状态码
+type WafDetect struct {
+	Signatures []WafSignature
+
+	client *http.Client
+}
+
+// NewWafDetect 创建WAF检测对象
+func NewWafDetect() *WafDetect {
+	w := &WafDetect{
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, DisableKeepAlives: true},
+			Timeout:   wafHttpTimeout,
+		},
+	}
+	for _, s := range builtinWafSignatures {
+		var err error
+		if s.Header != "" {
+			if s.header, err = regexp.Compile(s.Header); err != nil {
+				logging.RuntimeLog.Errorf("compile waf signature %s fail:%v", s.Name, err)
+				continue
+			}
+		}
+		if s.Body != "" {
+			if s.body, err = regexp.Compile(s.Body); err != nil {
+				logging.RuntimeLog.Errorf("compile waf signature %s fail:%v", s.Name, err)
+				continue
+			}
+		}
+		w.Signatures = append(w.Signatures, s)
+	}
+	return w
+}
+
+// Check 检测url是否有WAF，statusCode、header、body为httpx获取的正常请求的响应；返回WAF的厂商
+func (w *WafDetect) Check(targetUrl string, statusCode int, header, body string) (names []string) {
+	if names = w.match(header, body); len(names) > 0 {
+		return
+	}
+	if targetUrl == "" || !strings.HasPrefix(targetUrl, "http") {
+		return
+	}
+	blocked := false
+	for _, trigger := range wafTriggers {
+		triggerUrl, err := newTriggerUrl(targetUrl, trigger)
+		if err != nil {
+			return
+		}
+		respStatusCode, respHeader, respBody, err := w.request(triggerUrl)
+		if err != nil {
+			continue
+		}
+		if names = w.match(respHeader, respBody); len(names) > 0 {
+			return
+		}
+		// 正常请求没有被拒绝，而触发请求返回了拦截的状态码
+		if _, ok := wafBlockStatus[respStatusCode]; ok && respStatusCode != statusCode && statusCode < 400 {
+			blocked = true
+		}
+	}
+	if blocked {
+		names = append(names, WafGeneric)
+	}
+	return
+}
+
+// newTriggerUrl 在url原有的路径和查询参数上增加触发参数
+func newTriggerUrl(targetUrl string, trigger wafTrigger) (string, error) {
+	u, err := url.Parse(targetUrl)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Add(trigger.Key, trigger.Value)
+	u.RawQuery = query.Encode()
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// match 匹配响应的厂商特征
+func (w *WafDetect) match(header, body string) (names []string) {
+	if header == "" && body == "" {
+		return
+	}
+	for _, s := range w.Signatures {
+		if (s.header != nil && s.header.MatchString(header)) || (s.body != nil && s.body.MatchString(body)) {
+			names = append(names, s.Name)
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+// request 发送触发请求，返回状态码、响应头（每行为“key: value”）及响应体
+func (w *WafDetect) request(requestUrl string) (statusCode int, header, body string, err error) {
+	req, err := http.NewRequest(http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/107.0.0.0 Safari/537.36")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	content, _ := io.ReadAll(io.LimitReader(resp.Body, wafMaxBodySize))
+
+	var headers []string
+	for k, values := range resp.Header {
+		for _, v := range values {
+			headers = append(headers, fmt.Sprintf("%s: %s", k, v))
+		}
+	}
+	return resp.StatusCode, strings.Join(headers, "\n"), string(content), nil
+}
@@ -0,0 +1,80 @@
+package fingerprint
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWafDetect_match(t *testing.T) {
+	w := NewWafDetect()
+	header := "HTTP/1.1 200 OK\r\nServer: cloudflare\r\nCF-RAY: 7a1b2c3d4e5f6a7b-HKG\r\nContent-Type: text/html"
+	names := w.match(header, "<html><title>Example</title></html>")
+	t.Log(names)
+	if !reflect.DeepEqual(names, []string{"Cloudflare"}) {
+		t.Errorf("unexpected waf:%v", names)
+	}
+	if names = w.match("Server: nginx\r\nContent-Type: text/html", "<html><title>Example</title></html>"); len(names) > 0 {
+		t.Errorf("unexpected waf:%v", names)
+	}
+	// 页面中只是提到厂商名称
+	if names = w.match("Server: nginx", "<html><title>SafeLine WAF部署指南</title></html>"); len(names) > 0 {
+		t.Errorf("unexpected waf:%v", names)
+	}
+}
+
+func TestNewTriggerUrl(t *testing.T) {
+	trigger := wafTrigger{Key: "q", Value: "<script>alert(1)</script>"}
+	tests := map[string]string{
+		"http://example.com":               "http://example.com?q=%3Cscript%3Ealert%281%29%3C%2Fscript%3E",
+		"http://example.com/login.php?a=1": "http://example.com/login.php?a=1&q=%3Cscript%3Ealert%281%29%3C%2Fscript%3E",
+		"https://example.com/app/#/home":   "https://example.com/app/?q=%3Cscript%3Ealert%281%29%3C%2Fscript%3E",
+	}
+	for u, expected := range tests {
+		triggerUrl, err := newTriggerUrl(u, trigger)
+		t.Log(triggerUrl)
+		if err != nil || triggerUrl != expected {
+			t.Errorf("unexpected trigger url:%s,%v", triggerUrl, err)
+		}
+	}
+}
+
+func TestWafDetect_Check(t *testing.T) {
+	w := NewWafDetect()
+	// 拦截页面匹配厂商特征
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.RawQuery, "script") {
+			rw.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(rw, "<html><title>请求已被拦截</title><body><!-- event_id: 0123456789abcdef0123456789abcdef --></body></html>")
+			return
+		}
+		fmt.Fprint(rw, "<html><title>Example</title></html>")
+	}))
+	defer server.Close()
+	names := w.Check(server.URL, http.StatusOK, "", "")
+	t.Log(names)
+	if !reflect.DeepEqual(names, []string{"Safeline"}) {
+		t.Errorf("unexpected waf:%v", names)
+	}
+	// 只返回拦截的状态码
+	server2 := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.RawQuery, "passwd") {
+			rw.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		fmt.Fprint(rw, "<html><title>Example</title></html>")
+	}))
+	defer server2.Close()
+	names = w.Check(server2.URL, http.StatusOK, "", "")
+	t.Log(names)
+	if !reflect.DeepEqual(names, []string{WafGeneric}) {
+		t.Errorf("unexpected waf:%v", names)
+	}
+	// 正常请求也被拒绝的不能判断
+	if names = w.Check(server2.URL, http.StatusForbidden, "", ""); len(names) > 0 {
+		t.Errorf("unexpected waf:%v", names)
+	}
+}
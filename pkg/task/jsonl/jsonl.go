@@ -0,0 +1,235 @@
+package jsonl
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
+	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"io"
+	"sort"
+	"sync"
+)
+
+// FormatVersion JSON Lines结果格式的版本
+const FormatVersion = 1
+
+// 每一行结果的类型
+const (
+	RecordIP            = "ip"
+	RecordDomain        = "domain"
+	RecordVulnerability = "vulnerability"
+	RecordScreenshot    = "screenshot"
+)
+
+// Record JSON Lines中的一行结果，结果的结构与worker扫描的结果一致
+type Record struct {
+	Version       int                      `json:"version"`
+	Type          string                   `json:"type"`
+	IP            string                   `json:"ip,omitempty"`
+	Domain        string                   `json:"domain,omitempty"`
+	IPResult      *portscan.IPResult       `json:"ipResult,omitempty"`
+	DomainResult  *domainscan.DomainResult `json:"domainResult,omitempty"`
+	Vulnerability *pocscan.Result          `json:"vulnerability,omitempty"`
+	// Screenshot 截图的内容为base64编码的png
+	Screenshot *fingerprint.ScreenshotFileInfo `json:"screenshot,omitempty"`
+}
+
+// ParseResult 解析JSON Lines得到的结果
+type ParseResult struct {
+	IPResult     portscan.Result
+	DomainResult domainscan.Result
+	VulResult    []pocscan.Result
+	Screenshot   []fingerprint.ScreenshotFileInfo
+	// InvalidLines 格式错误、版本不支持或内容无效而忽略的行数
+	InvalidLines int
+}
+
+// Writer 按行输出JSON格式的结果
+type Writer struct {
+	sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewWriter 创建输出JSON Lines的Writer
+func NewWriter(w io.Writer) *Writer {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &Writer{encoder: encoder}
+}
+
+// Write 输出一行结果
+func (w *Writer) Write(record Record) error {
+	w.Lock()
+	defer w.Unlock()
+
+	record.Version = FormatVersion
+	return w.encoder.Encode(record)
+}
+
+// WriteIPResult 每个IP输出一行结果
+func (w *Writer) WriteIPResult(result *portscan.Result) (count int, err error) {
+	var ips []string
+	for ip := range result.IPResult {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		// 组织是server的数据，不输出
+		ipResult := *result.IPResult[ip]
+		ipResult.OrgId = nil
+		if err = w.Write(Record{Type: RecordIP, IP: ip, IPResult: &ipResult}); err != nil {
+			return
+		}
+		count++
+	}
+	return
+}
+
+// WriteDomainResult 每个域名输出一行结果
+func (w *Writer) WriteDomainResult(result *domainscan.Result) (count int, err error) {
+	var domains []string
+	for domain := range result.DomainResult {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	for _, domain := range domains {
+		domainResult := *result.DomainResult[domain]
+		domainResult.OrgId = nil
+		if err = w.Write(Record{Type: RecordDomain, Domain: domain, DomainResult: &domainResult}); err != nil {
+			return
+		}
+		count++
+	}
+	return
+}
+
+// WriteVulnerability 每个漏洞输出一行结果
+func (w *Writer) WriteVulnerability(results []pocscan.Result) (count int, err error) {
+	for i := range results {
+		vul := results[i]
+		vul.WorkspaceId = 0
+		if err = w.Write(Record{Type: RecordVulnerability, Vulnerability: &vul}); err != nil {
+			return
+		}
+		count++
+	}
+	return
+}
+
+// WriteScreenshot 每个截图输出一行结果
+func (w *Writer) WriteScreenshot(results []fingerprint.ScreenshotFileInfo) (count int, err error) {
+	for i := range results {
+		if err = w.Write(Record{Type: RecordScreenshot, Screenshot: &results[i]}); err != nil {
+			return
+		}
+		count++
+	}
+	return
+}
+
+// Parse 解析JSON Lines格式的结果，相同IP、域名的多行结果进行合并
+func Parse(content []byte) *ParseResult {
+	r := &ParseResult{
+		IPResult:     portscan.Result{IPResult: make(map[string]*portscan.IPResult)},
+		DomainResult: domainscan.Result{DomainResult: make(map[string]*domainscan.DomainResult)},
+	}
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil || !r.addRecord(&record) {
+			r.InvalidLines++
+		}
+	}
+	return r
+}
+
+// addRecord 检查并合并一行结果
+func (r *ParseResult) addRecord(record *Record) bool {
+	if record.Version <= 0 || record.Version > FormatVersion {
+		return false
+	}
+	switch record.Type {
+	case RecordIP:
+		if !utils.CheckIPV4(record.IP) || record.IPResult == nil {
+			return false
+		}
+		r.addIPResult(record.IP, record.IPResult)
+	case RecordDomain:
+		if !utils.CheckDomain(record.Domain) || record.DomainResult == nil {
+			return false
+		}
+		r.addDomainResult(record.Domain, record.DomainResult)
+	case RecordVulnerability:
+		vul := record.Vulnerability
+		if vul == nil || vul.Target == "" || vul.PocFile == "" || vul.Source == "" {
+			return false
+		}
+		vul.WorkspaceId = 0
+		r.VulResult = append(r.VulResult, *vul)
+	case RecordScreenshot:
+		ss := record.Screenshot
+		if ss == nil || ss.Port <= 0 || ss.Port > 65535 || len(ss.Content) == 0 {
+			return false
+		}
+		if ss.Protocol != "http" && ss.Protocol != "https" {
+			return false
+		}
+		if !utils.CheckIPV4(ss.Domain) && !utils.CheckDomain(ss.Domain) {
+			return false
+		}
+		r.Screenshot = append(r.Screenshot, *ss)
+	default:
+		return false
+	}
+	return true
+}
+
+// addIPResult 合并IP的结果，组织由导入时指定
+func (r *ParseResult) addIPResult(ip string, ipResult *portscan.IPResult) {
+	if !r.IPResult.HasIP(ip) {
+		r.IPResult.SetIP(ip)
+	}
+	ipr := r.IPResult.IPResult[ip]
+	if ipResult.Location != "" {
+		ipr.Location = ipResult.Location
+	}
+	if ipResult.Status != "" {
+		ipr.Status = ipResult.Status
+	}
+	for port, portResult := range ipResult.Ports {
+		if port <= 0 || port > 65535 || portResult == nil {
+			continue
+		}
+		if !r.IPResult.HasPort(ip, port) {
+			r.IPResult.SetPort(ip, port)
+		}
+		if portResult.Status != "" {
+			ipr.Ports[port].Status = portResult.Status
+		}
+		for _, par := range portResult.PortAttrs {
+			r.IPResult.SetPortAttr(ip, port, par)
+		}
+		for _, hr := range portResult.HttpInfo {
+			r.IPResult.SetPortHttpInfo(ip, port, hr)
+		}
+	}
+}
+
+// addDomainResult 合并域名的结果
+func (r *ParseResult) addDomainResult(domain string, domainResult *domainscan.DomainResult) {
+	if !r.DomainResult.HasDomain(domain) {
+		r.DomainResult.SetDomain(domain)
+	}
+	for _, dar := range domainResult.DomainAttrs {
+		r.DomainResult.SetDomainAttr(domain, dar)
+	}
+	for _, hr := range domainResult.HttpInfo {
+		r.DomainResult.SetHttpInfo(domain, hr)
+	}
+}
@@ -0,0 +1,93 @@
+package jsonl
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
+	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	orgId := 1
+	ipResult := portscan.Result{IPResult: make(map[string]*portscan.IPResult)}
+	ipResult.IPResult["192.168.1.1"] = &portscan.IPResult{OrgId: &orgId, Ports: map[int]*portscan.PortResult{
+		80: {Status: "open", PortAttrs: []portscan.PortAttrResult{{Source: "nmap", Tag: "service", Content: "http"}}},
+	}}
+	domainResult := domainscan.Result{DomainResult: make(map[string]*domainscan.DomainResult)}
+	domainResult.DomainResult["www.example.com"] = &domainscan.DomainResult{DomainAttrs: []domainscan.DomainAttrResult{{Source: "domainscan", Tag: "A", Content: "192.168.1.1"}}}
+	vul := []pocscan.Result{{Target: "192.168.1.1", Url: "http://192.168.1.1", PocFile: "test.yaml", Source: "nuclei", WorkspaceId: 1}}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.WriteIPResult(&ipResult)
+	w.WriteDomainResult(&domainResult)
+	w.WriteVulnerability(vul)
+	t.Log(buf.String())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines,got %d", len(lines))
+	}
+	var r Record
+	if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != FormatVersion || r.Type != RecordIP || r.IP != "192.168.1.1" || r.IPResult.OrgId != nil || r.IPResult.Ports[80].Status != "open" {
+		t.Errorf("invalid ip record:%s", lines[0])
+	}
+	// 原结果不应被修改
+	if ipResult.IPResult["192.168.1.1"].OrgId == nil {
+		t.Error("source result modified")
+	}
+}
+
+func TestParse(t *testing.T) {
+	content := strings.Join([]string{
+		`{"version":1,"type":"ip","ip":"192.168.1.1","ipResult":{"Location":"内网","Ports":{"80":{"Status":"open","PortAttrs":[{"Source":"nmap","Tag":"service","Content":"http"}]}}}}`,
+		`{"version":1,"type":"ip","ip":"192.168.1.1","ipResult":{"Ports":{"443":{"Status":"open","HttpInfo":[{"Source":"httpx","Tag":"header","Content":"Server: nginx"}]}}}}`,
+		`{"version":1,"type":"domain","domain":"www.example.com","domainResult":{"DomainAttrs":[{"Source":"domainscan","Tag":"A","Content":"192.168.1.1"}]}}`,
+		`{"version":1,"type":"vulnerability","vulnerability":{"target":"192.168.1.1","url":"http://192.168.1.1","pocFile":"test.yaml","source":"nuclei","workspaceId":3}}`,
+		`{"version":1,"type":"screenshot","screenshot":{"domain":"www.example.com","port":443,"protocol":"https","content":"iVBORw0KGgo="}}`,
+		// 无效的行
+		`{"version":2,"type":"ip","ip":"192.168.1.2","ipResult":{}}`,
+		`{"version":1,"type":"ip","ip":"192.168.1","ipResult":{}}`,
+		`{"version":1,"type":"screenshot","screenshot":{"domain":"../etc","port":80,"protocol":"http","content":"AA=="}}`,
+		`{"version":1,"type":"unknown"}`,
+		`not json`,
+		``,
+	}, "\n")
+	r := Parse([]byte(content))
+	t.Log(r.IPResult.IPResult, r.DomainResult.DomainResult, r.VulResult, len(r.Screenshot), r.InvalidLines)
+
+	if r.InvalidLines != 5 {
+		t.Errorf("expected 5 invalid lines,got %d", r.InvalidLines)
+	}
+	ipr, ok := r.IPResult.IPResult["192.168.1.1"]
+	if !ok || len(ipr.Ports) != 2 || ipr.Location != "内网" || len(ipr.Ports[443].HttpInfo) != 1 {
+		t.Errorf("invalid ip result:%v", ipr)
+	}
+	if len(r.DomainResult.DomainResult) != 1 || len(r.VulResult) != 1 || len(r.Screenshot) != 1 {
+		t.Error("invalid parse result")
+	}
+	if r.VulResult[0].WorkspaceId != 0 {
+		t.Error("workspace id should be reset")
+	}
+}
+
+func TestWriteAndParse(t *testing.T) {
+	ipResult := portscan.Result{IPResult: make(map[string]*portscan.IPResult)}
+	ipResult.IPResult["10.0.0.1"] = &portscan.IPResult{Ports: map[int]*portscan.PortResult{22: {Status: "open"}}}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.WriteIPResult(&ipResult)
+	w.WriteScreenshot([]fingerprint.ScreenshotFileInfo{{Domain: "10.0.0.1", Port: 80, Protocol: "http", Content: []byte{0x89, 'P', 'N', 'G'}}})
+
+	r := Parse(buf.Bytes())
+	if r.InvalidLines != 0 || !r.IPResult.HasPort("10.0.0.1", 22) || len(r.Screenshot) != 1 || string(r.Screenshot[0].Content) != "\x89PNG" {
+		t.Errorf("write and parse mismatch:%s", buf.String())
+	}
+}
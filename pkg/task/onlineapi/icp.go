@@ -86,7 +86,7 @@ func (i *ICPQuery) SaveLocalICPInfo() bool {
 
 // RunICPQuery 通过API在线查询一个域名的ICP备案信息
 func (i *ICPQuery) RunICPQuery(domain string) *ICPInfo {
-	if len(conf.GlobalWorkerConfig().API.ICP.Key) == 0 {
+	if len(i.getAPIKey()) == 0 {
 		logging.RuntimeLog.Warning("no icp searchEngine key,search exit")
 		logging.CLILog.Warning("no icp searchEngine key,search exit")
 		return nil
@@ -118,8 +118,16 @@ func (i *ICPQuery) RunICPQuery(domain string) *ICPInfo {
 	return nil
 }
 
+// getAPIKey 优先使用server集中管理的密钥
+func (i *ICPQuery) getAPIKey() string {
+	if i.Config.APIKey != "" {
+		return i.Config.APIKey
+	}
+	return conf.GlobalWorkerConfig().API.ICP.Key
+}
+
 func (i *ICPQuery) selectOneAPIKey() string {
-	keys := strings.Split(conf.GlobalWorkerConfig().API.ICP.Key, ",")
+	keys := strings.Split(i.getAPIKey(), ",")
 	if len(keys) == 0 {
 		return ""
 	}
@@ -25,10 +25,14 @@ type OnlineAPIConfig struct {
 	SearchLimitCount   int    `json:"searchlimitcount"`
 	SearchPageSize     int    `json:"searchpagesize"`
 	WorkspaceId        int    `json:"workspaceId"`
+	// APIKey server集中管理的密钥，不序列化到任务参数中
+	APIKey string `json:"-"`
 }
 
 type ICPQueryConfig struct {
 	Target string `json:"target"`
+	// APIKey server集中管理的密钥，不序列化到任务参数中
+	APIKey string `json:"-"`
 }
 
 type WhoisQueryConfig struct {
@@ -46,6 +46,10 @@ func NewOnlineAPISearch(config OnlineAPIConfig, apiName string) *OnlineSearch {
 	case "0zone":
 		s.searchEngine = new(ZeroZone)
 	}
+	// 优先使用server集中管理的密钥
+	if config.APIKey != "" {
+		s.apiKey = config.APIKey
+	}
 	s.Config.SearchLimitCount = conf.GlobalWorkerConfig().API.SearchLimitCount
 	if s.Config.SearchPageSize = conf.GlobalWorkerConfig().API.SearchPageSize; s.Config.SearchPageSize <= 0 {
 		s.Config.SearchPageSize = pageSizeDefault
@@ -352,6 +352,14 @@ func (g *Goby) GetVulnerability(api string, taskId string) (err error) {
 	return
 }
 
+// getAuth goby-cmd api的认证信息，优先使用server集中管理的密钥
+func (g *Goby) getAuth() string {
+	if g.Config.GobyAuth != "" {
+		return g.Config.GobyAuth
+	}
+	return fmt.Sprintf("%s:%s", conf.GlobalWorkerConfig().Pocscan.Goby.AuthUser, conf.GlobalWorkerConfig().Pocscan.Goby.AuthPass)
+}
+
 // postData 发送参数到goby-cmd api
 func (g *Goby) postData(method string, apiUrl string, data []byte) (body []byte, err error) {
 	var req *http.Request
@@ -360,7 +368,7 @@ func (g *Goby) postData(method string, apiUrl string, data []byte) (body []byte,
 		logging.RuntimeLog.Error(err)
 		return
 	}
-	apiAuth := base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", conf.GlobalWorkerConfig().Pocscan.Goby.AuthUser, conf.GlobalWorkerConfig().Pocscan.Goby.AuthPass)))
+	apiAuth := base64.URLEncoding.EncodeToString([]byte(g.getAuth()))
 	req.Header.Add("Authorization", fmt.Sprintf("Basic %s", apiAuth))
 	req.Header.Add("Content-Type", "application/json")
 	client := &http.Client{Timeout: 5 * time.Second}
@@ -0,0 +1,99 @@
+package pocscan
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"strings"
+)
+
+// Nessus 导入Nessus的.nessus（NessusClientData_v2）格式的漏洞报告
+type Nessus struct {
+}
+
+type nessusReport struct {
+	Hosts []nessusReportHost `xml:"Report>ReportHost"`
+}
+
+type nessusReportHost struct {
+	Name       string `xml:"name,attr"`
+	Properties []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"HostProperties>tag"`
+	Items []nessusReportItem `xml:"ReportItem"`
+}
+
+type nessusReportItem struct {
+	Port         int      `xml:"port,attr"`
+	SvcName      string   `xml:"svc_name,attr"`
+	Protocol     string   `xml:"protocol,attr"`
+	Severity     int      `xml:"severity,attr"`
+	PluginID     string   `xml:"pluginID,attr"`
+	PluginName   string   `xml:"pluginName,attr"`
+	RiskFactor   string   `xml:"risk_factor"`
+	Synopsis     string   `xml:"synopsis"`
+	Solution     string   `xml:"solution"`
+	PluginOutput string   `xml:"plugin_output"`
+	CVE          []string `xml:"cve"`
+}
+
+// getIP 获取主机的IP，报告中的主机名可能为域名
+func (h *nessusReportHost) getIP() string {
+	for _, p := range h.Properties {
+		if p.Name == "host-ip" && utils.CheckIPV4(strings.TrimSpace(p.Value)) {
+			return strings.TrimSpace(p.Value)
+		}
+	}
+	if utils.CheckIPV4(h.Name) {
+		return h.Name
+	}
+	return ""
+}
+
+// ParseContentResult 解析Nessus报告中的漏洞，忽略严重程度为None（仅为信息收集）的插件
+func (n *Nessus) ParseContentResult(content []byte) (result []Result) {
+	var report nessusReport
+	if err := xml.NewDecoder(bytes.NewReader(content)).Decode(&report); err != nil {
+		logging.RuntimeLog.Error(err)
+		return
+	}
+	for _, host := range report.Hosts {
+		target := host.getIP()
+		if target == "" {
+			target = host.Name
+		}
+		if target == "" {
+			continue
+		}
+		for _, item := range host.Items {
+			if item.Severity <= 0 || item.PluginName == "" {
+				continue
+			}
+			url := target
+			if item.Port > 0 {
+				url = fmt.Sprintf("%s:%d", target, item.Port)
+			}
+			var extra []string
+			extra = append(extra, fmt.Sprintf("pluginID:%s", item.PluginID), fmt.Sprintf("risk:%s", item.RiskFactor))
+			if len(item.CVE) > 0 {
+				extra = append(extra, fmt.Sprintf("cve:%s", strings.Join(item.CVE, ",")))
+			}
+			for _, s := range []string{item.Synopsis, item.PluginOutput, item.Solution} {
+				if s = strings.TrimSpace(s); s != "" {
+					extra = append(extra, s)
+				}
+			}
+			result = append(result, Result{
+				Target:  target,
+				Url:     url,
+				PocFile: item.PluginName,
+				Source:  "nessus",
+				Extra:   strings.Join(extra, "\n"),
+			})
+		}
+	}
+	return
+}
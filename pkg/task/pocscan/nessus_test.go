@@ -0,0 +1,41 @@
+package pocscan
+
+import "testing"
+
+var nessusReportContent = `<?xml version="1.0" ?>
+<NessusClientData_v2>
+<Report name="test">
+<ReportHost name="192.168.3.167"><HostProperties>
+<tag name="host-ip">192.168.3.167</tag>
+<tag name="operating-system">Linux Kernel 3.10</tag>
+</HostProperties>
+<ReportItem port="0" svc_name="general" protocol="tcp" severity="0" pluginID="19506" pluginName="Nessus Scan Information" pluginFamily="Settings">
+<plugin_output>Nessus version : 10.4.1</plugin_output>
+<risk_factor>None</risk_factor>
+</ReportItem>
+<ReportItem port="80" svc_name="www" protocol="tcp" severity="3" pluginID="156860" pluginName="Apache Log4Shell RCE detection" pluginFamily="CGI abuses">
+<cve>CVE-2021-44228</cve>
+<risk_factor>Critical</risk_factor>
+<synopsis>The remote web server is vulnerable to RCE.</synopsis>
+<plugin_output>Nessus was able to exploit the issue.</plugin_output>
+</ReportItem>
+<ReportItem port="22" svc_name="ssh" protocol="tcp" severity="2" pluginID="70658" pluginName="SSH Server CBC Mode Ciphers Enabled" pluginFamily="Misc.">
+<risk_factor>Low</risk_factor>
+</ReportItem>
+</ReportHost>
+</Report>
+</NessusClientData_v2>`
+
+func TestNessus_ParseContentResult(t *testing.T) {
+	n := Nessus{}
+	result := n.ParseContentResult([]byte(nessusReportContent))
+	for _, r := range result {
+		t.Log(r.Target, r.Url, r.PocFile, r.Extra)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 vulnerabilities,got %d", len(result))
+	}
+	if result[0].Target != "192.168.3.167" || result[0].Url != "192.168.3.167:80" || result[0].Source != "nessus" {
+		t.Errorf("invalid result:%v", result[0])
+	}
+}
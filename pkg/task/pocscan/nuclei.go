@@ -79,9 +79,9 @@ func (n *Nuclei) Do() {
 	cmdArgs = append(
 		cmdArgs,
 		"--timeout", "5", "-no-color",
-		"-c", fmt.Sprintf("%d", nucleiConcurrencyThreadNumber[conf.WorkerPerformanceMode]),
-		"-bs", fmt.Sprintf("%d", nucleiConcurrencyThreadNumber[conf.WorkerPerformanceMode]),
-		"-rl", fmt.Sprintf("%d", nucleiConcurrencyThreadNumber[conf.WorkerPerformanceMode]*6),
+		"-c", fmt.Sprintf("%d", nucleiConcurrencyThreadNumber[conf.GetWorkerPerformanceMode()]),
+		"-bs", fmt.Sprintf("%d", nucleiConcurrencyThreadNumber[conf.GetWorkerPerformanceMode()]),
+		"-rl", fmt.Sprintf("%d", nucleiConcurrencyThreadNumber[conf.GetWorkerPerformanceMode()]*6),
 		"-t", filepath.Join(conf.GetAbsRootPath(), conf.GlobalWorkerConfig().Pocscan.Nuclei.PocPath, n.Config.PocFile),
 		"-json", "-o", resultTempFile, "-l", inputTargetFile,
 	)
@@ -120,6 +120,30 @@ func (n *Nuclei) parseNucleiContentResult(content []byte) {
 	})
 }
 
+// ParseContentResult 解析导入的nuclei结果，支持-jsonl（每行一个结果）及-json-export（JSON数组）格式
+func (n *Nuclei) ParseContentResult(content []byte) (result []Result) {
+	n.Result = nil
+	content = bytes.TrimSpace(content)
+	if bytes.HasPrefix(content, []byte("[")) {
+		var items []json.RawMessage
+		if err := json.Unmarshal(content, &items); err != nil {
+			logging.RuntimeLog.Error(err)
+			return
+		}
+		for _, item := range items {
+			n.parseNucleiContentResult(item)
+		}
+	} else {
+		for _, line := range bytes.Split(content, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) > 0 {
+				n.parseNucleiContentResult(line)
+			}
+		}
+	}
+	return n.Result
+}
+
 // parseNucleiResult 解析nuclei的运行结果
 func (n *Nuclei) parseNucleiResult(outputTempFile string) {
 	inputFile, err := os.Open(outputTempFile)
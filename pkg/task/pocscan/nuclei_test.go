@@ -1,6 +1,7 @@
 package pocscan
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -21,4 +22,20 @@ func TestNuclei_LoadPocFile(t *testing.T) {
 	n := NewNuclei(Config{})
 	pocs := n.LoadPocFile()
 	t.Log(pocs)
-}
\ No newline at end of file
+}
+func TestNuclei_ParseContentResult(t *testing.T) {
+	content := `{"template-id":"CVE-2021-44228","info":{"name":"Apache Log4j2 RCE","severity":"critical"},"type":"http","host":"http://192.168.3.167:8080","matched-at":"http://192.168.3.167:8080/api","timestamp":"2023-01-01T00:00:00Z"}
+{"template-id":"tomcat-detect","info":{"name":"Tomcat Detection","severity":"info"},"type":"http","host":"https://www.example.com","timestamp":"2023-01-01T00:00:00Z"}
+`
+	n := Nuclei{}
+	result := n.ParseContentResult([]byte(content))
+	t.Log(result)
+	if len(result) != 2 || result[0].Target != "192.168.3.167" || result[0].PocFile != "CVE-2021-44228" {
+		t.Errorf("invalid jsonl result:%v", result)
+	}
+	// -json-export的JSON数组格式
+	result = n.ParseContentResult([]byte("[" + strings.Join(strings.Split(strings.TrimSpace(content), "\n"), ",") + "]"))
+	if len(result) != 2 || result[1].Target != "www.example.com" {
+		t.Errorf("invalid json export result:%v", result)
+	}
+}
@@ -0,0 +1,113 @@
+package pocscan
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// OpenVAS 导入OpenVAS（GVM）导出的XML格式的漏洞报告
+type OpenVAS struct {
+}
+
+type openvasResult struct {
+	Name string `xml:"name"`
+	Host struct {
+		IP       string `xml:",chardata"`
+		Hostname string `xml:"hostname"`
+	} `xml:"host"`
+	Port string `xml:"port"`
+	Nvt  struct {
+		Oid  string `xml:"oid,attr"`
+		Name string `xml:"name"`
+		Refs []struct {
+			Type string `xml:"type,attr"`
+			Id   string `xml:"id,attr"`
+		} `xml:"refs>ref"`
+	} `xml:"nvt"`
+	Threat      string `xml:"threat"`
+	Severity    string `xml:"severity"`
+	Description string `xml:"description"`
+}
+
+// parseOpenVASResults 解析OpenVAS报告中的检测结果（<result>），报告可能嵌套在get_reports_response或多层report中
+func parseOpenVASResults(content []byte) (results []openvasResult, err error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, e := decoder.Token()
+		if e == io.EOF {
+			return
+		}
+		if e != nil {
+			return results, e
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "result" {
+			continue
+		}
+		var r openvasResult
+		if err = decoder.DecodeElement(&r, &start); err != nil {
+			return
+		}
+		if r.Nvt.Oid != "" {
+			results = append(results, r)
+		}
+	}
+}
+
+// getOpenVASPort 解析端口，如“443/tcp”，“general/tcp”等没有端口
+func getOpenVASPort(port string) (portNumber int, protocol string) {
+	portProtocol := strings.SplitN(strings.TrimSpace(port), "/", 2)
+	if len(portProtocol) != 2 {
+		return
+	}
+	portNumber, _ = strconv.Atoi(portProtocol[0])
+	return portNumber, portProtocol[1]
+}
+
+// ParseContentResult 解析OpenVAS报告中的漏洞，忽略Log级别（仅为信息收集）的结果
+func (o *OpenVAS) ParseContentResult(content []byte) (result []Result) {
+	results, err := parseOpenVASResults(content)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+	}
+	for _, r := range results {
+		target := strings.TrimSpace(r.Host.IP)
+		if target == "" || r.Threat == "" || r.Threat == "Log" || r.Threat == "False Positive" {
+			continue
+		}
+		url := target
+		if port, _ := getOpenVASPort(r.Port); port > 0 {
+			url = fmt.Sprintf("%s:%d", target, port)
+		}
+		name := r.Nvt.Name
+		if name == "" {
+			name = r.Name
+		}
+		var cves []string
+		for _, ref := range r.Nvt.Refs {
+			if ref.Type == "cve" {
+				cves = append(cves, ref.Id)
+			}
+		}
+		extra := []string{fmt.Sprintf("oid:%s", r.Nvt.Oid), fmt.Sprintf("threat:%s", r.Threat), fmt.Sprintf("severity:%s", r.Severity)}
+		if len(cves) > 0 {
+			extra = append(extra, fmt.Sprintf("cve:%s", strings.Join(cves, ",")))
+		}
+		if d := strings.TrimSpace(r.Description); d != "" {
+			extra = append(extra, d)
+		}
+		result = append(result, Result{
+			Target:  target,
+			Url:     url,
+			PocFile: name,
+			Source:  "openvas",
+			Extra:   strings.Join(extra, "\n"),
+		})
+	}
+	return
+}
@@ -0,0 +1,48 @@
+package pocscan
+
+import "testing"
+
+var openvasReportContent = `<report id="a1" format_id="a994b278" extension="xml" content_type="text/xml">
+<owner><name>admin</name></owner>
+<name>2023-01-01T00:00:00Z</name>
+<report id="a1">
+<results start="1" max="100">
+<result id="r1">
+<name>Apache Tomcat RCE Vulnerability</name>
+<host>192.168.3.242<asset asset_id="h1"/><hostname>www.example.com</hostname></host>
+<port>8080/tcp</port>
+<nvt oid="1.3.6.1.4.1.25623.1.0.1">
+<type>nvt</type>
+<name>Apache Tomcat RCE Vulnerability</name>
+<cvss_base>9.8</cvss_base>
+<refs><ref type="cve" id="CVE-2017-12617"/><ref type="url" id="https://tomcat.apache.org"/></refs>
+</nvt>
+<threat>High</threat>
+<severity>9.8</severity>
+<description>Installed version: 8.5.0</description>
+</result>
+<result id="r2">
+<name>OS Detection Consolidation and Reporting</name>
+<host>192.168.3.242</host>
+<port>general/tcp</port>
+<nvt oid="1.3.6.1.4.1.25623.1.0.2"><name>OS Detection Consolidation and Reporting</name></nvt>
+<threat>Log</threat>
+<severity>0.0</severity>
+</result>
+</results>
+</report>
+</report>`
+
+func TestOpenVAS_ParseContentResult(t *testing.T) {
+	o := OpenVAS{}
+	result := o.ParseContentResult([]byte(openvasReportContent))
+	for _, r := range result {
+		t.Log(r.Target, r.Url, r.PocFile, r.Extra)
+	}
+	if len(result) != 1 {
+		t.Fatalf("expected 1 vulnerability,got %d", len(result))
+	}
+	if result[0].Target != "192.168.3.242" || result[0].Url != "192.168.3.242:8080" || result[0].Source != "openvas" {
+		t.Errorf("invalid result:%v", result[0])
+	}
+}
@@ -27,6 +27,8 @@ type Config struct {
 	CmdBin           string `json:"cmdBin"`
 	IsLoadOpenedPort bool   `json:"loadOpenedPort"`
 	WorkspaceId      int    `json:"workspaceId"`
+	// GobyAuth server集中管理的goby认证（user:pass），不序列化到任务参数中
+	GobyAuth string `json:"-"`
 }
 
 type Result struct {
@@ -109,6 +111,7 @@ type ImportOfflineResult struct {
 func init() {
 	nucleiConcurrencyThreadNumber[conf.HighPerformance] = 20
 	nucleiConcurrencyThreadNumber[conf.NormalPerformance] = 10
+	nucleiConcurrencyThreadNumber[conf.LowPerformance] = 5
 }
 
 func NewImportOfflineResult(resultType string, workspaceId int) *ImportOfflineResult {
@@ -118,7 +121,14 @@ func NewImportOfflineResult(resultType string, workspaceId int) *ImportOfflineRe
 		i.offlineInterface = new(FScan)
 	case "gogo":
 		i.offlineInterface = new(Gogo)
-	case "goby":
+	case "nuclei":
+		i.offlineInterface = new(Nuclei)
+	case "xray":
+		i.offlineInterface = new(Xray)
+	case "nessus":
+		i.offlineInterface = new(Nessus)
+	case "openvas":
+		i.offlineInterface = new(OpenVAS)
 	}
 	return i
 }
@@ -11,9 +11,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
+// xrayHtmlVulnRegex xray的html报告中的漏洞数据
+var xrayHtmlVulnRegex = regexp.MustCompile(`webVulns\.push\((\{.*?\})\)\s*</script>`)
+
 type Xray struct {
 	Config Config
 	Result []Result
@@ -114,6 +118,11 @@ func (x *Xray) parseXrayResult(outputTempFile string) {
 		logging.RuntimeLog.Error(err.Error())
 		return
 	}
+	x.addXrayResult(xr)
+}
+
+// addXrayResult 保存xray的漏洞结果
+func (x *Xray) addXrayResult(xr []xrayJSONResult) {
 	for _, r := range xr {
 		var extraAll []string
 		for _, s := range r.Detail.Snapshot {
@@ -134,6 +143,35 @@ func (x *Xray) parseXrayResult(outputTempFile string) {
 	}
 }
 
+// ParseContentResult 解析导入的xray结果，支持--json-output及--html-output格式
+func (x *Xray) ParseContentResult(content []byte) (result []Result) {
+	x.Result = nil
+	content = bytes.TrimSpace(content)
+	var xr []xrayJSONResult
+	if bytes.HasPrefix(content, []byte("[")) {
+		// xray扫描中断时json结果可能没有结束的“]”
+		if !bytes.HasSuffix(content, []byte("]")) {
+			content = append(bytes.TrimRight(content, ","), ']')
+		}
+		if err := json.Unmarshal(content, &xr); err != nil {
+			logging.RuntimeLog.Error(err)
+			return
+		}
+	} else {
+		// html报告中每个漏洞为一个webVulns.push({...})
+		for _, m := range xrayHtmlVulnRegex.FindAllSubmatch(content, -1) {
+			var r xrayJSONResult
+			if err := json.Unmarshal(m[1], &r); err != nil {
+				logging.RuntimeLog.Error(err)
+				continue
+			}
+			xr = append(xr, r)
+		}
+	}
+	x.addXrayResult(xr)
+	return x.Result
+}
+
 // LoadPocFile 加载poc文件列表
 func (x *Xray) LoadPocFile() (pocs []string) {
 	files, _ := filepath.Glob(filepath.Join(conf.GetRootPath(), conf.GlobalWorkerConfig().Pocscan.Xray.PocPath, "*.yml"))
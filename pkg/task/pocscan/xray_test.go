@@ -35,3 +35,22 @@ func TestXray_RunXray3(t *testing.T) {
 	xray.Do()
 	t.Log(xray.Result)
 }
+
+func TestXray_ParseContentResult(t *testing.T) {
+	jsonContent := `[{"create_time":1670000000000,"detail":{"addr":"http://192.168.3.242:8080/","payload":"","snapshot":[["GET / HTTP/1.1\r\n","HTTP/1.1 200 OK\r\n"]]},"plugin":"poc-yaml-tomcat-cve-2017-12615-rce","target":{"url":"http://192.168.3.242:8080/"}},
+{"create_time":1670000000000,"detail":{"addr":"http://192.168.3.242:8080/","payload":"","snapshot":[]},"plugin":"baseline/sensitive/server-error","target":{"url":"http://192.168.3.242:8080/"}},`
+	x := Xray{}
+	result := x.ParseContentResult([]byte(jsonContent))
+	t.Log(result)
+	if len(result) != 1 || result[0].Target != "192.168.3.242" {
+		t.Errorf("invalid json result:%v", result)
+	}
+
+	htmlContent := `<html><body><script class='web-vulns'>webVulns.push({"create_time":1670000000000,"detail":{"addr":"http://www.example.com/","snapshot":[["req","resp"]]},"plugin":"poc-yaml-thinkphp5-controller-rce","target":{"url":"http://www.example.com/"}})</script>
+<script class='web-vulns'>webVulns.push({"create_time":1670000000001,"detail":{"addr":"http://192.168.3.1/","snapshot":[]},"plugin":"poc-yaml-spring-actuator-heapdump-file","target":{"url":"http://192.168.3.1/"}})</script></body></html>`
+	result = x.ParseContentResult([]byte(htmlContent))
+	t.Log(result)
+	if len(result) != 2 || result[0].Target != "www.example.com" || result[1].PocFile != "poc-yaml-spring-actuator-heapdump-file" {
+		t.Errorf("invalid html result:%v", result)
+	}
+}
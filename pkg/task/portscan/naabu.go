@@ -0,0 +1,73 @@
+package portscan
+
+import (
+	"encoding/json"
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"strconv"
+	"strings"
+)
+
+// Naabu 导入naabu的扫描结果
+type Naabu struct {
+}
+
+type naabuJSONResult struct {
+	Host     string `json:"host"`
+	IP       string `json:"ip"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// ParseContentResult 解析naabu扫描的结果，支持普通text（ip:port）及-json格式
+func (n *Naabu) ParseContentResult(content []byte) (result Result) {
+	result.IPResult = make(map[string]*IPResult)
+
+	s := custom.NewService()
+	for _, l := range strings.Split(string(content), "\n") {
+		line := strings.TrimSpace(strings.Trim(l, "\r"))
+		var ip string
+		var port int
+		if strings.HasPrefix(line, "{") {
+			var r naabuJSONResult
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				continue
+			}
+			if r.Protocol != "" && r.Protocol != "tcp" {
+				continue
+			}
+			ip, port = r.IP, r.Port
+			if ip == "" {
+				ip = r.Host
+			}
+		} else {
+			hostPort := strings.Split(line, ":")
+			if len(hostPort) != 2 {
+				continue
+			}
+			ip = hostPort[0]
+			port, _ = strconv.Atoi(hostPort[1])
+		}
+		addIPPortService(&result, &s, ip, port, "naabu")
+	}
+	return
+}
+
+// addIPPortService 添加导入的IP及开放的端口，端口服务根据端口号获取
+func addIPPortService(result *Result, s *custom.Service, ip string, port int, source string) {
+	if !utils.CheckIPV4(ip) || port <= 0 || port > 65535 {
+		return
+	}
+	if !result.HasIP(ip) {
+		result.SetIP(ip)
+	}
+	if result.HasPort(ip, port) {
+		return
+	}
+	result.SetPort(ip, port)
+	result.SetPortAttr(ip, port, PortAttrResult{
+		Source:  source,
+		Tag:     "service",
+		Content: s.FindService(port, ip),
+	})
+}
@@ -0,0 +1,23 @@
+package portscan
+
+import "testing"
+
+func TestNaabu_ParseContentResult(t *testing.T) {
+	content := `192.168.3.1:53
+192.168.3.167:80
+192.168.3.167:443
+{"host":"www.example.com","ip":"192.168.3.242","port":8080,"protocol":"tcp","timestamp":"2023-01-01T00:00:00Z"}
+{"ip":"192.168.3.242","port":53,"protocol":"udp"}
+invalid line
+`
+	n := Naabu{}
+	result := n.ParseContentResult([]byte(content))
+	for ip, r := range result.IPResult {
+		for port, p := range r.Ports {
+			t.Log(ip, port, p.PortAttrs)
+		}
+	}
+	if len(result.IPResult) != 3 || len(result.IPResult["192.168.3.167"].Ports) != 2 || len(result.IPResult["192.168.3.242"].Ports) != 1 {
+		t.Errorf("invalid result:%v", result.IPResult)
+	}
+}
@@ -0,0 +1,73 @@
+package portscan
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"strings"
+)
+
+// Nessus 导入Nessus的.nessus格式报告中的主机及端口
+type Nessus struct {
+}
+
+type nessusReport struct {
+	Hosts []struct {
+		Name       string `xml:"name,attr"`
+		Properties []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"HostProperties>tag"`
+		Items []struct {
+			Port     int    `xml:"port,attr"`
+			SvcName  string `xml:"svc_name,attr"`
+			Protocol string `xml:"protocol,attr"`
+		} `xml:"ReportItem"`
+	} `xml:"Report>ReportHost"`
+}
+
+// ParseContentResult 解析Nessus报告，每个主机的TCP端口及服务
+func (n *Nessus) ParseContentResult(content []byte) (result Result) {
+	result.IPResult = make(map[string]*IPResult)
+
+	var report nessusReport
+	if err := xml.NewDecoder(bytes.NewReader(content)).Decode(&report); err != nil {
+		logging.RuntimeLog.Error(err)
+		return
+	}
+	s := custom.NewService()
+	for _, host := range report.Hosts {
+		ip := host.Name
+		for _, p := range host.Properties {
+			if p.Name == "host-ip" {
+				ip = strings.TrimSpace(p.Value)
+				break
+			}
+		}
+		if !utils.CheckIPV4(ip) {
+			continue
+		}
+		if !result.HasIP(ip) {
+			result.SetIP(ip)
+		}
+		for _, item := range host.Items {
+			if item.Port <= 0 || item.Port > 65535 || item.Protocol != "tcp" || result.HasPort(ip, item.Port) {
+				continue
+			}
+			result.SetPort(ip, item.Port)
+			// nessus无法确认的服务名称以“?”结尾
+			service := strings.TrimSuffix(item.SvcName, "?")
+			if service == "" || service == "unknown" || service == "general" {
+				service = s.FindService(item.Port, ip)
+			}
+			result.SetPortAttr(ip, item.Port, PortAttrResult{
+				Source:  "nessus",
+				Tag:     "service",
+				Content: service,
+			})
+		}
+	}
+	return
+}
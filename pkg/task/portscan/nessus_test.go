@@ -0,0 +1,26 @@
+package portscan
+
+import "testing"
+
+func TestNessus_ParseContentResult(t *testing.T) {
+	content := `<?xml version="1.0" ?>
+<NessusClientData_v2><Report name="test">
+<ReportHost name="www.example.com"><HostProperties><tag name="host-ip">192.168.3.167</tag></HostProperties>
+<ReportItem port="0" svc_name="general" protocol="tcp" severity="0" pluginID="19506" pluginName="Nessus Scan Information"></ReportItem>
+<ReportItem port="80" svc_name="www" protocol="tcp" severity="0" pluginID="10107" pluginName="HTTP Server Type and Version"></ReportItem>
+<ReportItem port="8443" svc_name="pcsync-https?" protocol="tcp" severity="0" pluginID="11219" pluginName="Nessus SYN scanner"></ReportItem>
+<ReportItem port="161" svc_name="snmp" protocol="udp" severity="0" pluginID="10550" pluginName="SNMP Query"></ReportItem>
+</ReportHost>
+</Report></NessusClientData_v2>`
+	n := Nessus{}
+	result := n.ParseContentResult([]byte(content))
+	for ip, ipr := range result.IPResult {
+		for port, p := range ipr.Ports {
+			t.Log(ip, port, p.PortAttrs)
+		}
+	}
+	ipr, ok := result.IPResult["192.168.3.167"]
+	if !ok || len(ipr.Ports) != 2 || ipr.Ports[8443].PortAttrs[0].Content != "pcsync-https" {
+		t.Errorf("invalid result:%v", result.IPResult)
+	}
+}
@@ -0,0 +1,51 @@
+package portscan
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// OpenVAS 导入OpenVAS（GVM）XML格式报告中的主机及端口
+type OpenVAS struct {
+}
+
+// ParseContentResult 解析OpenVAS报告，检测结果（<result>）中的主机及TCP端口
+func (o *OpenVAS) ParseContentResult(content []byte) (result Result) {
+	result.IPResult = make(map[string]*IPResult)
+
+	s := custom.NewService()
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err != io.EOF {
+				logging.RuntimeLog.Error(err)
+			}
+			return
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "result" {
+			continue
+		}
+		var r struct {
+			Host string `xml:"host"`
+			Port string `xml:"port"`
+		}
+		if err = decoder.DecodeElement(&r, &start); err != nil {
+			logging.RuntimeLog.Error(err)
+			return
+		}
+		// 端口格式为“443/tcp”，“general/tcp”等没有端口
+		portProtocol := strings.SplitN(strings.TrimSpace(r.Port), "/", 2)
+		if len(portProtocol) != 2 || portProtocol[1] != "tcp" {
+			continue
+		}
+		port, _ := strconv.Atoi(portProtocol[0])
+		addIPPortService(&result, &s, strings.TrimSpace(r.Host), port, "openvas")
+	}
+}
@@ -0,0 +1,17 @@
+package portscan
+
+import "testing"
+
+func TestOpenVAS_ParseContentResult(t *testing.T) {
+	content := `<report id="a1"><report id="a1"><results>
+<result id="r1"><host>192.168.3.242<hostname>www.example.com</hostname></host><port>8080/tcp</port><nvt oid="1.3.6.1.4.1.25623.1.0.1"><name>Apache Tomcat RCE</name></nvt><threat>High</threat></result>
+<result id="r2"><host>192.168.3.242</host><port>general/tcp</port><nvt oid="1.3.6.1.4.1.25623.1.0.2"><name>OS Detection</name></nvt><threat>Log</threat></result>
+<result id="r3"><host>192.168.3.242</host><port>22/tcp</port><nvt oid="1.3.6.1.4.1.25623.1.0.3"><name>SSH Detection</name></nvt><threat>Log</threat></result>
+</results></report></report>`
+	o := OpenVAS{}
+	result := o.ParseContentResult([]byte(content))
+	t.Log(result.IPResult)
+	if ipr, ok := result.IPResult["192.168.3.242"]; !ok || len(ipr.Ports) != 2 {
+		t.Errorf("invalid result:%v", result.IPResult)
+	}
+}
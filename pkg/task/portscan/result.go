@@ -30,9 +30,18 @@ type Config struct {
 	CmdBin           string `json:"cmdBin"`
 	IsLoadOpenedPort bool   `json:"loadOpenedPort"`
 	IsPortscan       bool   `json:"isPortscan"`
+	IsVhost          bool   `json:"vhost"`
 	WorkspaceId      int    `json:"workspaceId"`
 }
 
+// IPAttrResult IP属性结果
+type IPAttrResult struct {
+	RelatedId int
+	Source    string
+	Tag       string
+	Content   string
+}
+
 // PortAttrResult 端口属性结果
 type PortAttrResult struct {
 	RelatedId int
@@ -60,6 +69,7 @@ type IPResult struct {
 	OrgId    *int
 	Location string
 	Status   string
+	IPAttrs  []IPAttrResult
 	Ports    map[int]*PortResult
 }
 
@@ -92,6 +102,14 @@ func NewImportOfflineResult(resultType string) *ImportOfflineResult {
 		i.offlineInterface = new(Gogo)
 	case "goby":
 		i.offlineInterface = new(Goby)
+	case "naabu":
+		i.offlineInterface = new(Naabu)
+	case "rustscan":
+		i.offlineInterface = new(RustScan)
+	case "nessus":
+		i.offlineInterface = new(Nessus)
+	case "openvas":
+		i.offlineInterface = new(OpenVAS)
 	}
 	return i
 }
@@ -126,6 +144,13 @@ func (r *Result) SetIP(ip string) {
 	r.IPResult[ip] = &IPResult{Ports: make(map[int]*PortResult)}
 }
 
+func (r *Result) SetIPAttr(ip string, iar IPAttrResult) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.IPResult[ip].IPAttrs = append(r.IPResult[ip].IPAttrs, iar)
+}
+
 func (r *Result) HasPort(ip string, port int) bool {
 	r.RLock()
 	defer r.RUnlock()
@@ -185,6 +210,24 @@ func (r *Result) SaveResult(config Config) string {
 			}
 		}
 		resultIPCount++
+		//save ip attribute
+		for _, ipAttrResult := range ipResult.IPAttrs {
+			ipAttr := &db.IpAttr{
+				RelatedId: ip.Id,
+				Source:    ipAttrResult.Source,
+				Tag:       ipAttrResult.Tag,
+			}
+			if len(ipAttrResult.Content) > db.AttrContentSize {
+				ipAttr.Content = ipAttrResult.Content[:db.AttrContentSize]
+			} else {
+				ipAttr.Content = ipAttrResult.Content
+			}
+			if ipAttr.IsSingleValueTag() {
+				ipAttr.SaveOrUpdateByTag()
+			} else {
+				ipAttr.SaveOrUpdate()
+			}
+		}
 		for portNumber, portResult := range ipResult.Ports {
 			//save port
 			port := &db.Port{
@@ -0,0 +1,39 @@
+package portscan
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/task/custom"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RustScan 导入rustscan的扫描结果
+type RustScan struct {
+}
+
+var (
+	// rustscanOpenRegex 默认输出：Open 192.168.1.1:80
+	rustscanOpenRegex = regexp.MustCompile(`^Open (\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}):(\d{1,5})$`)
+	// rustscanGreppableRegex -g参数的输出：192.168.1.1 -> [80,443]
+	rustscanGreppableRegex = regexp.MustCompile(`^(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}) -> \[([\d,\s]+)]$`)
+)
+
+// ParseContentResult 解析rustscan扫描的结果
+func (r *RustScan) ParseContentResult(content []byte) (result Result) {
+	result.IPResult = make(map[string]*IPResult)
+
+	s := custom.NewService()
+	for _, l := range strings.Split(string(content), "\n") {
+		line := strings.TrimSpace(strings.Trim(l, "\r"))
+		if m := rustscanOpenRegex.FindStringSubmatch(line); len(m) == 3 {
+			port, _ := strconv.Atoi(m[2])
+			addIPPortService(&result, &s, m[1], port, "rustscan")
+		} else if m = rustscanGreppableRegex.FindStringSubmatch(line); len(m) == 3 {
+			for _, p := range strings.Split(m[2], ",") {
+				port, _ := strconv.Atoi(strings.TrimSpace(p))
+				addIPPortService(&result, &s, m[1], port, "rustscan")
+			}
+		}
+	}
+	return
+}
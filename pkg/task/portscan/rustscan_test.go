@@ -0,0 +1,22 @@
+package portscan
+
+import "testing"
+
+func TestRustScan_ParseContentResult(t *testing.T) {
+	content := `.----. .-. .-. .----..---.  .----. .---.   .--.  .-. .-.
+[~] The config file is expected to be at "/root/.rustscan.toml"
+Open 192.168.3.167:22
+Open 192.168.3.167:80
+192.168.3.242 -> [80,443,8080]
+`
+	r := RustScan{}
+	result := r.ParseContentResult([]byte(content))
+	for ip, ipr := range result.IPResult {
+		for port := range ipr.Ports {
+			t.Log(ip, port)
+		}
+	}
+	if len(result.IPResult) != 2 || len(result.IPResult["192.168.3.167"].Ports) != 2 || len(result.IPResult["192.168.3.242"].Ports) != 3 {
+		t.Errorf("invalid result:%v", result.IPResult)
+	}
+}
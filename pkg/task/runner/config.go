@@ -23,8 +23,10 @@ type PortscanRequestParam struct {
 	TaskCronRule       string `form:"cronrule" json:"-"`
 	TaskCronComment    string `form:"croncomment" json:"-"`
 	IsLoadOpenedPort   bool   `form:"load_opened_port"`
+	IsVhost            bool   `form:"vhost"`
 	IsIgnoreOutofChina bool   `form:"ignoreoutofchina"`
 	IsIgnoreCDN        bool   `form:"ignorecdn"`
+	WorkerLabel        string `form:"worker_label"`
 }
 
 type DomainscanRequestParam struct {
@@ -37,6 +39,11 @@ type DomainscanRequestParam struct {
 	IsIPPortscan       bool   `form:"portscan"`
 	IsSubnetPortscan   bool   `form:"networkscan"`
 	IsCrawler          bool   `form:"crawler"`
+	IsPermutation      bool   `form:"permutation"`
+	IsTakeover         bool   `form:"takeover"`
+	IsCDNOrigin        bool   `form:"cdnorigin"`
+	IsZoneTransfer     bool   `form:"zonetransfer"`
+	IsJSAnalysis       bool   `form:"jsanalysis"`
 	IsFofa             bool   `form:"fofasearch"`
 	IsQuake            bool   `form:"quakesearch"`
 	IsHunter           bool   `form:"huntersearch"`
@@ -52,6 +59,7 @@ type DomainscanRequestParam struct {
 	TaskCronComment    string `form:"croncomment" json:"-"`
 	IsIgnoreOutofChina bool   `form:"ignoreoutofchina"`
 	IsIgnoreCDN        bool   `form:"ignorecdn"`
+	WorkerLabel        string `form:"worker_label"`
 }
 
 type PocscanRequestParam struct {
@@ -67,6 +75,7 @@ type PocscanRequestParam struct {
 	IsTaskCron       bool   `form:"taskcron" json:"-"`
 	TaskCronRule     string `form:"cronrule" json:"-"`
 	TaskCronComment  string `form:"croncomment" json:"-"`
+	WorkerLabel      string `form:"worker_label"`
 }
 
 type XScanRequestParam struct {
@@ -87,6 +96,7 @@ type XScanRequestParam struct {
 	IsTaskCron      bool   `form:"taskcron" json:"-"`
 	TaskCronRule    string `form:"cronrule" json:"-"`
 	TaskCronComment string `form:"croncomment" json:"-"`
+	WorkerLabel     string `form:"worker_label"`
 }
 
 type taskKeySearchParam struct {
@@ -105,7 +105,7 @@ func StartDomainScanTask(req DomainscanRequestParam, mainTaskId string, workspac
 	ts.TaskMode = req.TaskMode
 	targets := ts.DoDomainSlice()
 	for _, t := range targets {
-		// 每个获取子域名的方式采用独立任务，以提高速度
+		// 每个获取子域名的方式采用独立任务，以提高速度；子域名变换及域传送只在第一个任务中执行
 		var taskStarted bool
 		if req.IsSubfinder {
 			subConfig := req
@@ -121,6 +121,8 @@ func StartDomainScanTask(req DomainscanRequestParam, mainTaskId string, workspac
 			subConfig := req
 			subConfig.IsSubfinder = false
 			subConfig.IsCrawler = false
+			subConfig.IsPermutation = req.IsPermutation && !taskStarted
+			subConfig.IsZoneTransfer = req.IsZoneTransfer && !taskStarted
 			if taskId, err = doDomainscan(workspaceId, mainTaskId, t, subConfig, "subdomainbrute"); err != nil {
 				logging.RuntimeLog.Error(err)
 				return
@@ -131,6 +133,8 @@ func StartDomainScanTask(req DomainscanRequestParam, mainTaskId string, workspac
 			subConfig := req
 			subConfig.IsSubfinder = false
 			subConfig.IsSubdomainBrute = false
+			subConfig.IsPermutation = req.IsPermutation && !taskStarted
+			subConfig.IsZoneTransfer = req.IsZoneTransfer && !taskStarted
 			if taskId, err = doDomainscan(workspaceId, mainTaskId, t, subConfig, "subdomaincrawler"); err != nil {
 				logging.RuntimeLog.Error(err)
 				return
@@ -320,6 +324,8 @@ func StartXDomainScanTask(req XScanRequestParam, mainTaskId string, workspaceId
 		IsNucleiPoc:   req.IsNucleiPocscan,
 		NucleiPocFile: req.NucleiPocFile,
 		IsGobyPoc:     req.IsGobyPocscan,
+		// CDN源站发现
+		IsCDNOrigin: conf.GlobalWorkerConfig().Domainscan.IsCDNOrigin,
 		//
 		WorkspaceId: workspaceId,
 	}
@@ -334,12 +340,18 @@ func StartXDomainScanTask(req XScanRequestParam, mainTaskId string, workspaceId
 		if utils.CheckIPV4(target) || utils.CheckIPV4Subnet(target) {
 			continue
 		}
-		// 子域名枚举、爆破、爬虫拆分成为多个任务并行执行
+		// 子域名枚举、爆破、爬虫拆分成为多个任务并行执行；子域名变换及域传送只在第一个任务中执行
+		isPermutation := conf.GlobalWorkerConfig().Domainscan.IsPermutation
+		isZoneTransfer := conf.GlobalWorkerConfig().Domainscan.IsZoneTransfer
 		if conf.GlobalWorkerConfig().Domainscan.IsSubDomainFinder {
 			configRun := config
 			configRun.Domain = make(map[string]struct{})
 			configRun.Domain[target] = struct{}{}
 			configRun.IsSubDomainFinder = true
+			configRun.IsPermutation = isPermutation
+			configRun.IsZoneTransfer = isZoneTransfer
+			isPermutation = false
+			isZoneTransfer = false
 			configJSON, _ := json.Marshal(configRun)
 			taskId, err = serverapi.NewRunTask("xsubfinder", string(configJSON), mainTaskId, "")
 			if err != nil {
@@ -352,6 +364,10 @@ func StartXDomainScanTask(req XScanRequestParam, mainTaskId string, workspaceId
 			configRun.Domain = make(map[string]struct{})
 			configRun.Domain[target] = struct{}{}
 			configRun.IsSubDomainBrute = true
+			configRun.IsPermutation = isPermutation
+			configRun.IsZoneTransfer = isZoneTransfer
+			isPermutation = false
+			isZoneTransfer = false
 			configJSON, _ := json.Marshal(configRun)
 			taskId, err = serverapi.NewRunTask("xsubdomainbrute", string(configJSON), mainTaskId, "")
 			if err != nil {
@@ -364,6 +380,10 @@ func StartXDomainScanTask(req XScanRequestParam, mainTaskId string, workspaceId
 			configRun.Domain = make(map[string]struct{})
 			configRun.Domain[target] = struct{}{}
 			configRun.IsSubDomainCrawler = true
+			configRun.IsPermutation = isPermutation
+			configRun.IsZoneTransfer = isZoneTransfer
+			isPermutation = false
+			isZoneTransfer = false
 			configJSON, _ := json.Marshal(configRun)
 			taskId, err = serverapi.NewRunTask("xsubdomaincrawler", string(configJSON), mainTaskId, "")
 			if err != nil {
@@ -371,6 +391,20 @@ func StartXDomainScanTask(req XScanRequestParam, mainTaskId string, workspaceId
 				return "", err
 			}
 		}
+		// 未启用子域名枚举、爆破、爬虫时，单独执行子域名变换及域传送
+		if isPermutation || isZoneTransfer {
+			configRun := config
+			configRun.Domain = make(map[string]struct{})
+			configRun.Domain[target] = struct{}{}
+			configRun.IsPermutation = isPermutation
+			configRun.IsZoneTransfer = isZoneTransfer
+			configJSON, _ := json.Marshal(configRun)
+			taskId, err = serverapi.NewRunTask("xdomainscan", string(configJSON), mainTaskId, "")
+			if err != nil {
+				logging.RuntimeLog.Errorf("start xdomainscan fail:%s", err.Error())
+				return "", err
+			}
+		}
 		if conf.GlobalWorkerConfig().Domainscan.IsICP {
 			_, err = doICPQuery(mainTaskId, target)
 			if err != nil {
@@ -546,6 +580,7 @@ func doPortscan(workspaceId int, mainTaskId string, target string, port string,
 		CmdBin:           req.CmdBin,
 		IsPortscan:       req.IsPortScan,
 		IsLoadOpenedPort: req.IsLoadOpenedPort,
+		IsVhost:          req.IsVhost,
 		WorkspaceId:      workspaceId,
 	}
 	if req.CmdBin == "" {
@@ -634,6 +669,11 @@ func doDomainscan(workspaceId int, mainTaskId string, target string, req Domains
 		IsSubDomainFinder:  req.IsSubfinder,
 		IsSubDomainBrute:   req.IsSubdomainBrute,
 		IsCrawler:          req.IsCrawler,
+		IsPermutation:      req.IsPermutation,
+		IsTakeover:         req.IsTakeover,
+		IsCDNOrigin:        req.IsCDNOrigin,
+		IsZoneTransfer:     req.IsZoneTransfer,
+		IsJSAnalysis:       req.IsJSAnalysis,
 		IsHttpx:            req.IsHttpx,
 		IsIPPortScan:       req.IsIPPortscan,
 		IsIPSubnetPortScan: req.IsSubnetPortscan,
@@ -1,6 +1,7 @@
 package serverapi
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/RichardKnop/machinery/v2/tasks"
@@ -11,6 +12,9 @@ import (
 	"time"
 )
 
+// CheckTaskWorker 检查是否有在线的worker能执行任务，由RPC server设置（worker的心跳及能力保存在server中）
+var CheckTaskWorker func(taskName, configJSON, topicName string) error
+
 // NewRunTask 创建一个新执行任务
 func NewRunTask(taskName, configJSON, mainTaskId, lastRunTaskId string) (taskId string, err error) {
 	dbMTask := db.TaskMain{TaskId: mainTaskId}
@@ -25,12 +29,22 @@ func NewRunTask(taskName, configJSON, mainTaskId, lastRunTaskId string) (taskId
 		logging.RuntimeLog.Error(msg)
 		return "", errors.New(msg)
 	}
-	topicName := ampq.GetTopicByTaskName(taskName, dbWorkspace.WorkspaceGUID)
+	topicName := ampq.GetTopicByTaskName(taskName, dbWorkspace.WorkspaceGUID, GetMainTaskWorkerLabel(dbMTask.KwArgs))
 	if topicName == "" {
 		msg := fmt.Sprintf("task not defined for topic:%s", taskName)
 		logging.RuntimeLog.Error(msg)
 		return "", errors.New(msg)
 	}
+	// 在线的worker都不能执行的任务直接失败，避免任务在worker之间反复重新分发
+	if CheckTaskWorker != nil {
+		if err = CheckTaskWorker(taskName, configJSON, topicName); err != nil {
+			logging.RuntimeLog.Error(err)
+			taskId = uuid.New().String()
+			addTask(taskId, taskName, configJSON, mainTaskId, lastRunTaskId, dbWorkspace.Id)
+			updateFailedTask(taskId, err.Error())
+			return "", err
+		}
+	}
 	server := ampq.GetServerTaskAMPQServer(topicName)
 	// 延迟5秒后执行：如果不延迟，有可能任务在完成数据库之前执行，从而导致task not exist错误
 	eta := time.Now().Add(time.Second * 5)
@@ -57,6 +71,17 @@ func NewRunTask(taskName, configJSON, mainTaskId, lastRunTaskId string) (taskId
 	return taskId, nil
 }
 
+// GetMainTaskWorkerLabel 获取maintask参数中指定执行任务的worker标签
+func GetMainTaskWorkerLabel(kwArgs string) string {
+	var args struct {
+		WorkerLabel string
+	}
+	if err := json.Unmarshal([]byte(kwArgs), &args); err != nil {
+		return ""
+	}
+	return args.WorkerLabel
+}
+
 // RevokeUnexcusedTask 取消一个未开始执行的任务
 func RevokeUnexcusedTask(taskId string) (isRevoked bool, err error) {
 	task := &db.TaskRun{TaskId: taskId}
@@ -109,3 +134,17 @@ func updateRevokedTask(taskId string) {
 		logging.RuntimeLog.Errorf("update task:%s,state:%s fail !", taskId, ampq.REVOKED)
 	}
 }
+
+// updateFailedTask 更新不能执行的任务状态
+func updateFailedTask(taskId, result string) {
+	dt := time.Now()
+	task := &db.TaskRun{
+		TaskId:     taskId,
+		State:      ampq.FAILURE,
+		Result:     result,
+		FailedTime: &dt,
+	}
+	if !task.SaveOrUpdate() {
+		logging.RuntimeLog.Errorf("update task:%s,state:%s fail !", taskId, ampq.FAILURE)
+	}
+}
@@ -36,6 +36,8 @@ var taskMaps = map[string]interface{}{
 	"icpquery":          ICPQuery,
 	"whoisquery":        WhoisQuery,
 	"fingerprint":       Fingerprint,
+	"vhost":             Vhost,
+	"cdnorigin":         CDNOrigin,
 	"xportscan":         XPortScan,
 	"xonlineapi":        XOnlineAPI,
 	"xfofa":             XOnlineAPI,
@@ -87,7 +89,8 @@ func ParseConfig(configJSON string, config interface{}) (err error) {
 // StartWorker 启动worker
 func StartWorker(topicName string, concurrency int) error {
 	server := ampq.GetWorkerAMPQServer(topicName, concurrency)
-	err := server.RegisterTasks(taskMaps)
+	// 只注册worker能执行的任务，不能执行的任务由消息中间件重新分发给其它worker
+	err := server.RegisterTasks(GetCapableTaskMaps(WStatus.Capability))
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return err
@@ -173,7 +176,7 @@ func UpdateTaskStatus(taskId string, state string, worker string, result string)
 		Result: result,
 	}
 	var updateStatus bool
-	if err := comm.CallXClient("UpdateTask", &taskStatus, &updateStatus); err != nil {
+	if err := comm.CallXClientWithSpool("UpdateTask", &taskStatus, &updateStatus); err != nil {
 		logging.RuntimeLog.Error(err)
 		return false
 	}
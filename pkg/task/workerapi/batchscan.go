@@ -66,7 +66,7 @@ func BatchScan(taskId, mainTaskId, configJSON string) (result string, err error)
 		IPConfig:   &config,
 		IPResult:   resultPortScan.IPResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithSpool("SaveScanResult", &resultArgs, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return FailedTask(err.Error()), err
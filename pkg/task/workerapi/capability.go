@@ -0,0 +1,162 @@
+package workerapi
+
+import (
+	"context"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// capabilityBinary 需要检测的可执行文件及获取版本的参数
+type capabilityBinary struct {
+	Name        string
+	Path        string
+	VersionArgs []string
+}
+
+// chromeExecNames chrome可能的可执行文件名称（与chromedp的查找顺序一致）
+var chromeExecNames = []string{
+	"headless_shell",
+	"headless-shell",
+	"chromium",
+	"chromium-browser",
+	"google-chrome",
+	"google-chrome-stable",
+	"google-chrome-beta",
+	"google-chrome-unstable",
+	"/usr/bin/google-chrome",
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"chrome",
+	"chrome.exe",
+}
+
+// egressIPQueryURL 查询worker出口IP的地址
+var egressIPQueryURL = []string{
+	"https://api.ipify.org",
+	"https://ifconfig.me/ip",
+}
+
+var versionRegex = regexp.MustCompile(`v?\d+\.\d+(\.\d+)*`)
+
+// DetectWorkerCapability 检测worker的执行能力：已安装的工具及版本、chrome、raw socket权限、已配置的API及出口IP
+func DetectWorkerCapability() ampq.WorkerCapability {
+	capability := ampq.WorkerCapability{Items: make(map[string]string)}
+
+	binaries := []capabilityBinary{
+		{Name: ampq.CapabilityNmap, Path: "nmap", VersionArgs: []string{"--version"}},
+		{Name: ampq.CapabilityMasscan, Path: "masscan", VersionArgs: []string{"--version"}},
+		{Name: ampq.CapabilityHttpx, Path: filepath.Join(conf.GetAbsRootPath(), "thirdparty/httpx", utils.GetThirdpartyBinNameByPlatform(utils.Httpx)), VersionArgs: []string{"-version"}},
+		{Name: ampq.CapabilitySubfinder, Path: filepath.Join(conf.GetAbsRootPath(), "thirdparty/subfinder", utils.GetThirdpartyBinNameByPlatform(utils.Subfinder)), VersionArgs: []string{"-version"}},
+		{Name: ampq.CapabilityNuclei, Path: filepath.Join(conf.GetAbsRootPath(), "thirdparty/nuclei", utils.GetThirdpartyBinNameByPlatform(utils.Nuclei)), VersionArgs: []string{"-version"}},
+		{Name: ampq.CapabilityXray, Path: filepath.Join(conf.GetAbsRootPath(), "thirdparty/xray", utils.GetThirdpartyBinNameByPlatform(utils.Xray)), VersionArgs: []string{"version"}},
+		{Name: ampq.CapabilityObserverWard, Path: filepath.Join(conf.GetAbsRootPath(), "thirdparty/fingerprinthub", utils.GetThirdpartyBinNameByPlatform(utils.ObserverWard)), VersionArgs: []string{"--version"}},
+	}
+	if runtime.GOOS == "windows" {
+		binaries[0].Path = "nmap.exe"
+		binaries[1].Path = "masscan.exe"
+	}
+	for _, bin := range binaries {
+		if version, ok := getBinaryVersion(bin.Path, bin.VersionArgs...); ok {
+			capability.Items[bin.Name] = version
+		}
+	}
+	for _, name := range chromeExecNames {
+		if version, ok := getBinaryVersion(name, "--version"); ok {
+			capability.Items[ampq.CapabilityChrome] = version
+			break
+		}
+	}
+	if checkRawSocket() {
+		capability.Items[ampq.CapabilityRawSocket] = "enabled"
+	}
+	// 本地配置的API key只用于显示，key可以由server集中管理，不作为接收任务的条件
+	api := conf.GlobalWorkerConfig().API
+	apiKeys := map[string]string{
+		ampq.CapabilityFofa:   api.Fofa.Key,
+		ampq.CapabilityQuake:  api.Quake.Key,
+		ampq.CapabilityHunter: api.Hunter.Key,
+		ampq.CapabilityICP:    api.ICP.Key,
+	}
+	for name, key := range apiKeys {
+		if key != "" {
+			capability.Items[name] = "configured"
+		}
+	}
+	if len(conf.GlobalWorkerConfig().Pocscan.Goby.API) > 0 {
+		capability.Items[ampq.CapabilityGoby] = strings.Join(conf.GlobalWorkerConfig().Pocscan.Goby.API, ",")
+	}
+	capability.EgressIP = getEgressIP()
+
+	return capability
+}
+
+// GetCapableTaskMaps 根据worker的能力，获取worker能执行的任务
+func GetCapableTaskMaps(capability ampq.WorkerCapability) map[string]interface{} {
+	capableTaskMaps := make(map[string]interface{})
+	for taskName, taskFunc := range taskMaps {
+		// 未检测能力时不作限制
+		if capability.Items == nil || ampq.CheckTaskCapability(taskName, capability) {
+			capableTaskMaps[taskName] = wrapTaskWithGate(taskFunc)
+		}
+	}
+	return capableTaskMaps
+}
+
+// getBinaryVersion 检查可执行文件是否存在，并执行获取版本信息
+func getBinaryVersion(path string, versionArgs ...string) (version string, ok bool) {
+	binPath, err := exec.LookPath(path)
+	if err != nil {
+		return "", false
+	}
+	if len(versionArgs) == 0 {
+		return "installed", true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// 部分工具的版本信息输出到stderr，或者返回非0值，只要有输出即可
+	output, _ := exec.CommandContext(ctx, binPath, versionArgs...).CombinedOutput()
+	if v := versionRegex.Find(output); v != nil {
+		return string(v), true
+	}
+	return "installed", true
+}
+
+// checkRawSocket 检查是否有raw socket权限（SYN扫描需要）
+func checkRawSocket() bool {
+	conn, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// getEgressIP 获取worker访问互联网的出口IP，无法访问时使用本机出口的IP
+func getEgressIP() string {
+	client := http.Client{Timeout: 5 * time.Second}
+	for _, url := range egressIPQueryURL {
+		resp, err := client.Get(url)
+		if err != nil {
+			continue
+		}
+		content, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			continue
+		}
+		if ip := strings.TrimSpace(string(content)); utils.CheckIPV4(ip) {
+			return ip
+		}
+	}
+	ip, _ := utils.GetOutBoundIP()
+	return ip
+}
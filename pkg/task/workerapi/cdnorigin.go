@@ -0,0 +1,71 @@
+package workerapi
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/comm"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"strings"
+)
+
+// CDNOrigin CDN源站发现任务
+func CDNOrigin(taskId, mainTaskId, configJSON string) (result string, err error) {
+	var ok bool
+	if ok, result, err = CheckTaskStatus(taskId); !ok {
+		return result, err
+	}
+
+	config := fingerprint.CDNOriginConfig{}
+	if err = ParseConfig(configJSON, &config); err != nil {
+		logging.RuntimeLog.Error(err)
+		return FailedTask(err.Error()), err
+	}
+	origin := fingerprint.NewCDNOrigin(config)
+	// 读取server中已保存的候选源站IP
+	for _, domain := range strings.Split(config.Target, ",") {
+		domain = strings.TrimSpace(domain)
+		if domain == "" {
+			continue
+		}
+		candidates := make(map[string]string)
+		args := comm.LoadCDNOriginCandidateArgs{TaskId: taskId, Domain: domain}
+		if err = comm.CallXClient("LoadCDNOriginCandidate", &args, &candidates); err != nil {
+			logging.RuntimeLog.Error(err)
+			continue
+		}
+		origin.Candidates[domain] = candidates
+	}
+	origin.Do()
+	if len(origin.Result) == 0 {
+		return SucceedTask(""), nil
+	}
+	// 源站保存为域名的属性，源站IP保存为IP资产
+	resultDomainScan := domainscan.Result{DomainResult: make(map[string]*domainscan.DomainResult)}
+	resultPortScan := portscan.Result{IPResult: make(map[string]*portscan.IPResult)}
+	for _, r := range origin.Result {
+		if !resultDomainScan.HasDomain(r.Domain) {
+			resultDomainScan.SetDomain(r.Domain)
+		}
+		resultDomainScan.SetDomainAttr(r.Domain, domainscan.DomainAttrResult{Source: "cdnorigin", Tag: "origin", Content: fmt.Sprintf("%s source:%s %s://%s:%d status:%d title:%s", r.IP, r.Source, r.Scheme, r.IP, r.Port, r.StatusCode, r.Title)})
+		if !resultPortScan.HasIP(r.IP) {
+			resultPortScan.SetIP(r.IP)
+		}
+		resultPortScan.SetIPAttr(r.IP, portscan.IPAttrResult{Source: "cdnorigin", Tag: "origin", Content: r.Domain})
+	}
+	doLocation(&resultPortScan)
+	resultArgs := comm.ScanResultArgs{
+		TaskID:       taskId,
+		MainTaskId:   mainTaskId,
+		IPConfig:     &portscan.Config{OrgId: config.OrgId, WorkspaceId: config.WorkspaceId},
+		IPResult:     resultPortScan.IPResult,
+		DomainConfig: &domainscan.Config{OrgId: config.OrgId, WorkspaceId: config.WorkspaceId},
+		DomainResult: resultDomainScan.DomainResult,
+	}
+	if err = comm.CallXClientWithSpool("SaveScanResult", &resultArgs, &result); err != nil {
+		logging.RuntimeLog.Error(err)
+		return FailedTask(err.Error()), err
+	}
+	return SucceedTask(result), nil
+}
@@ -8,8 +8,11 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
+	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
 	"github.com/hanc00l/nemo_go/pkg/utils"
+	"sort"
 	"strings"
 )
 
@@ -25,7 +28,10 @@ func DomainScan(taskId, mainTaskId, configJSON string) (result string, err error
 		logging.RuntimeLog.Error(err)
 		return FailedTask(err.Error()), err
 	}
-	resultDomainScan := doDomainScan(config)
+	if config.IsSubDomainFinder {
+		config.ProviderConfig = comm.FetchWorkerSecret(comm.SecretSubfinder, taskId)
+	}
+	resultDomainScan, resultVul := doDomainScan(taskId, config)
 	// 如果有端口扫描的选项
 	if config.IsIPPortScan || config.IsIPSubnetPortScan {
 		doPortScanByDomainscan(taskId, mainTaskId, config, &resultDomainScan)
@@ -37,11 +43,34 @@ func DomainScan(taskId, mainTaskId, configJSON string) (result string, err error
 		DomainConfig: &config,
 		DomainResult: resultDomainScan.DomainResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithSpool("SaveScanResult", &resultArgs, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return FailedTask(err.Error()), err
 	}
+	// 保存子域名接管、域传送、JS分析的漏洞
+	if len(resultVul) > 0 {
+		vulArgs := comm.ScanResultArgs{
+			TaskID:              taskId,
+			MainTaskId:          mainTaskId,
+			VulnerabilityResult: resultVul,
+		}
+		var vulResult string
+		if err = comm.CallXClientWithSpool("SaveVulnerabilityResult", &vulArgs, &vulResult); err != nil {
+			logging.RuntimeLog.Error(err)
+			return FailedTask(err.Error()), err
+		}
+		result = fmt.Sprintf("%s,%s", result, vulResult)
+	}
+	// CDN源站发现任务：在域名结果保存后执行，使用数据库中已保存的历史解析记录等
+	if config.IsCDNOrigin {
+		if cdnDomains := getResultCDNDomain(&resultDomainScan); len(cdnDomains) > 0 {
+			originConfig := fingerprint.CDNOriginConfig{Target: strings.Join(cdnDomains, ","), OrgId: config.OrgId, WorkspaceId: config.WorkspaceId}
+			if _, err = sendTask(taskId, mainTaskId, originConfig, "cdnorigin"); err != nil {
+				return FailedTask(err.Error()), err
+			}
+		}
+	}
 	_, err = NewFingerprintTask(taskId, mainTaskId, nil, &resultDomainScan, FingerprintTaskConfig{
 		IsHttpx:          config.IsHttpx,
 		IsFingerprintHub: config.IsFingerprintHub,
@@ -56,8 +85,8 @@ func DomainScan(taskId, mainTaskId, configJSON string) (result string, err error
 	return SucceedTask(result), nil
 }
 
-// doDomainScan 域名收集任务
-func doDomainScan(config domainscan.Config) (resultDomainScan domainscan.Result) {
+// doDomainScan 域名收集任务，返回域名结果及子域名接管、域传送、JS分析的漏洞
+func doDomainScan(taskId string, config domainscan.Config) (resultDomainScan domainscan.Result, resultVul []pocscan.Result) {
 	// 子域名枚举
 	if config.IsSubDomainFinder {
 		subdomain := domainscan.NewSubFinder(config)
@@ -66,19 +95,31 @@ func doDomainScan(config domainscan.Config) (resultDomainScan domainscan.Result)
 	}
 	// 子域名爆破
 	if config.IsSubDomainBrute {
-		massdns := domainscan.NewMassdns(config)
-		massdns.Do()
-		resultDomainScan = massdns.Result
+		dnsBrute := domainscan.NewDNSBrute(config)
+		dnsBrute.Do()
+		resultDomainScan = dnsBrute.Result
 	}
 	//  Crawler
 	if config.IsCrawler {
 		crawler := domainscan.NewCrawler(config)
 		crawler.Do()
 		resultDomainScan = crawler.Result
+		// JS分析
+		if config.IsJSAnalysis {
+			resultVul = append(resultVul, doJSAnalysis(config, &resultDomainScan)...)
+		}
+	}
+	// 域传送及NSEC遍历
+	if config.IsZoneTransfer {
+		resultVul = append(resultVul, doZoneTransfer(config, &resultDomainScan)...)
+	}
+	// 子域名变换
+	if config.IsPermutation {
+		doPermutation(taskId, config, &resultDomainScan)
 	}
 	// 域名解析
 	resolve := domainscan.NewResolve(config)
-	if !config.IsSubDomainFinder && !config.IsSubDomainBrute && !config.IsCrawler {
+	if !config.IsSubDomainFinder && !config.IsSubDomainBrute && !config.IsCrawler && !config.IsPermutation && !config.IsZoneTransfer {
 		// 对config中Target进行域名解析
 		resolve.Do()
 		resultDomainScan = resolve.Result
@@ -87,10 +128,143 @@ func doDomainScan(config domainscan.Config) (resultDomainScan domainscan.Result)
 		resolve.Result.DomainResult = resultDomainScan.DomainResult
 		resolve.Do()
 	}
+	// 子域名接管检测：CNAME解析NXDOMAIN的域名没有A及CNAME记录，需在去除无效域名前检测
+	if config.IsTakeover {
+		resultVul = append(resultVul, doTakeover(config, &resultDomainScan)...)
+	}
 	//去除结果中无域名解析A或CNAME记录的域名
 	checkDomainResolveResult(&resultDomainScan)
 
-	return resultDomainScan
+	return resultDomainScan, resultVul
+}
+
+// doTakeover 对域名结果进行子域名接管检测，存在风险的域名保存CNAME记录并生成漏洞结果
+func doTakeover(config domainscan.Config, resultDomainScan *domainscan.Result) (resultVul []pocscan.Result) {
+	var domains []string
+	for domain := range resultDomainScan.DomainResult {
+		domains = append(domains, domain)
+	}
+	takeover := domainscan.NewTakeover(config)
+	takeover.Do(domains)
+	for _, r := range takeover.Result {
+		domainResult := resultDomainScan.DomainResult[r.Domain]
+		if domainResult != nil && !isHaveResolveRecord(&domainResult.DomainAttrs) {
+			resultDomainScan.SetDomainAttr(r.Domain, domainscan.DomainAttrResult{Source: "takeover", Tag: "CNAME", Content: r.CName})
+		}
+		resultVul = append(resultVul, pocscan.Result{
+			Target:      r.Domain,
+			Url:         r.Domain,
+			PocFile:     fmt.Sprintf("subdomain-takeover-%s", strings.ToLower(strings.ReplaceAll(r.Service, " ", "-"))),
+			Source:      "takeover",
+			Extra:       fmt.Sprintf("cname:%s\nservice:%s\n%s", r.CName, r.Service, r.Reason),
+			WorkspaceId: config.WorkspaceId,
+		})
+	}
+	return
+}
+
+// doJSAnalysis 分析爬虫页面引用的JS，子域名合并到resultDomainScan，API路径等保存为目标域名的属性，密钥等生成漏洞结果
+func doJSAnalysis(config domainscan.Config, resultDomainScan *domainscan.Result) (resultVul []pocscan.Result) {
+	if resultDomainScan.DomainResult == nil {
+		resultDomainScan.DomainResult = make(map[string]*domainscan.DomainResult)
+	}
+	jsAnalysis := domainscan.NewJSAnalysis(config)
+	jsAnalysis.ReqResponseList = resultDomainScan.ReqResponseList
+	jsAnalysis.Do()
+	for domain := range jsAnalysis.Result.DomainResult {
+		if !resultDomainScan.HasDomain(domain) {
+			resultDomainScan.SetDomain(domain)
+		}
+	}
+	for _, f := range jsAnalysis.Findings {
+		if !resultDomainScan.HasDomain(f.Domain) {
+			resultDomainScan.SetDomain(f.Domain)
+		}
+		resultDomainScan.SetDomainAttr(f.Domain, domainscan.DomainAttrResult{Source: "jsanalysis", Tag: "js_" + f.Category, Content: f.Content})
+		if f.IsFinding {
+			resultVul = append(resultVul, pocscan.Result{
+				Target:      f.Domain,
+				Url:         f.Url,
+				PocFile:     fmt.Sprintf("js-%s", f.Name),
+				Source:      "jsanalysis",
+				Extra:       fmt.Sprintf("category:%s\n%s", f.Category, f.Content),
+				WorkspaceId: config.WorkspaceId,
+			})
+		}
+	}
+	return
+}
+
+// doZoneTransfer 对域名的NS服务器进行域传送及NSEC遍历，获取的子域名合并到resultDomainScan，DNS配置缺陷生成漏洞结果
+func doZoneTransfer(config domainscan.Config, resultDomainScan *domainscan.Result) (resultVul []pocscan.Result) {
+	if resultDomainScan.DomainResult == nil {
+		resultDomainScan.DomainResult = make(map[string]*domainscan.DomainResult)
+	}
+	zoneTransfer := domainscan.NewZoneTransfer(config)
+	zoneTransfer.Do()
+	for domain := range zoneTransfer.Result.DomainResult {
+		if !resultDomainScan.HasDomain(domain) {
+			resultDomainScan.SetDomain(domain)
+		}
+	}
+	for _, v := range zoneTransfer.Vulnerability {
+		pocFile := "dns-zone-transfer"
+		if v.Type == domainscan.ZoneTransferNSEC {
+			pocFile = "dnssec-nsec-zone-walking"
+		}
+		names := v.Names
+		if len(names) > 100 {
+			names = names[:100]
+		}
+		resultVul = append(resultVul, pocscan.Result{
+			Target:      v.Domain,
+			Url:         v.NameServer,
+			PocFile:     pocFile,
+			Source:      "zonetransfer",
+			Extra:       fmt.Sprintf("nameserver:%s\nnames:%d\n%s", v.NameServer, len(v.Names), strings.Join(names, "\n")),
+			WorkspaceId: config.WorkspaceId,
+		})
+	}
+	return
+}
+
+// doPermutation 根据当前任务及工作空间中已有的子域名进行子域名变换，结果合并到resultDomainScan
+func doPermutation(taskId string, config domainscan.Config, resultDomainScan *domainscan.Result) {
+	if resultDomainScan.DomainResult == nil {
+		resultDomainScan.DomainResult = make(map[string]*domainscan.DomainResult)
+	}
+	var knownDomains []string
+	for _, line := range strings.Split(config.Target, ",") {
+		domain := strings.TrimSpace(line)
+		if domain == "" || utils.CheckIPV4(domain) || utils.CheckIPV4Subnet(domain) {
+			continue
+		}
+		if !resultDomainScan.HasDomain(domain) {
+			resultDomainScan.SetDomain(domain)
+		}
+		// 本地扫描（nemo scan）没有工作空间，只使用当前任务的结果
+		if taskId == "" {
+			continue
+		}
+		var subdomains []string
+		args := comm.LoadSubDomainArgs{TaskId: taskId, Domain: domain}
+		if err := comm.CallXClient("LoadSubDomain", &args, &subdomains); err != nil {
+			logging.RuntimeLog.Errorf("load subdomain fail:%v", err)
+			continue
+		}
+		knownDomains = append(knownDomains, subdomains...)
+	}
+	for domain := range resultDomainScan.DomainResult {
+		knownDomains = append(knownDomains, domain)
+	}
+	permutation := domainscan.NewPermutation(config)
+	permutation.KnownDomains = knownDomains
+	permutation.Do()
+	for domain := range permutation.Result.DomainResult {
+		if !resultDomainScan.HasDomain(domain) {
+			resultDomainScan.SetDomain(domain)
+		}
+	}
 }
 
 // doPortScanByDomainscan 对IP进行端口扫描
@@ -189,11 +363,25 @@ func getResultIPList(resultDomainScan *domainscan.Result) (ipResult, ipSubnetRes
 	return
 }
 
-// checkDomainResolveResult 检查域名结果，去除没有解析记录的无效域名
+// getResultCDNDomain 提取域名收集结果中使用了CDN的域名
+func getResultCDNDomain(resultDomainScan *domainscan.Result) (domains []string) {
+	for domain, da := range resultDomainScan.DomainResult {
+		for _, dar := range da.DomainAttrs {
+			if dar.Tag == "CDN" {
+				domains = append(domains, domain)
+				break
+			}
+		}
+	}
+	sort.Strings(domains)
+	return
+}
+
+// checkDomainResolveResult 检查域名结果，去除没有解析记录的无效域名（保留有JS分析结果的域名，如没有A记录的主域名）
 func checkDomainResolveResult(resultDomainScan *domainscan.Result) {
 	var removedDomain []string
 	for domain, domainResult := range resultDomainScan.DomainResult {
-		if isHaveResolveRecord(&domainResult.DomainAttrs) == false {
+		if isHaveResolveRecord(&domainResult.DomainAttrs) == false && isHaveJSAnalysisAttr(&domainResult.DomainAttrs) == false {
 			removedDomain = append(removedDomain, domain)
 		}
 	}
@@ -202,6 +390,16 @@ func checkDomainResolveResult(resultDomainScan *domainscan.Result) {
 	}
 }
 
+// isHaveJSAnalysisAttr 域名是否有JS分析的属性（js_endpoint、js_bucket等）
+func isHaveJSAnalysisAttr(domainAttrs *[]domainscan.DomainAttrResult) bool {
+	for _, dar := range *domainAttrs {
+		if strings.HasPrefix(dar.Tag, "js_") {
+			return true
+		}
+	}
+	return false
+}
+
 func isHaveResolveRecord(domainAttrs *[]domainscan.DomainAttrResult) bool {
 	if len(*domainAttrs) == 0 {
 		return false
@@ -60,3 +60,22 @@ func TestDomainWithPortScanScan(t *testing.T) {
 	result, err := serverapi.NewRunTask("domainscan", string(configJSON), "", "")
 	t.Log(result, err)
 }
+
+func TestCheckDomainResolveResult(t *testing.T) {
+	result := domainscan.Result{DomainResult: map[string]*domainscan.DomainResult{
+		"www.example.com": {DomainAttrs: []domainscan.DomainAttrResult{{Tag: "A", Content: "1.1.1.1"}}},
+		"example.com":     {DomainAttrs: []domainscan.DomainAttrResult{{Tag: "js_endpoint", Content: "/api/login"}}},
+		"dev.example.com": {DomainAttrs: []domainscan.DomainAttrResult{{Tag: "title", Content: "dev"}}},
+	}}
+	checkDomainResolveResult(&result)
+	t.Log(len(result.DomainResult))
+	if _, ok := result.DomainResult["www.example.com"]; !ok {
+		t.Errorf("resolved domain removed")
+	}
+	if _, ok := result.DomainResult["example.com"]; !ok {
+		t.Errorf("domain with js analysis attrs removed")
+	}
+	if _, ok := result.DomainResult["dev.example.com"]; ok {
+		t.Errorf("unresolved domain not removed")
+	}
+}
@@ -2,10 +2,13 @@ package workerapi
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/comm"
+	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
 	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
+	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
 	"strings"
 )
@@ -49,6 +52,7 @@ func Fingerprint(taskId, mainTaskId, configJSON string) (result string, err erro
 // doFingerPrintAndSave 指纹的综合任务，包括IP与domain
 func doFingerPrintAndSave(taskId string, mainTaskId string, config FingerprintTaskConfig) (resultPortScan portscan.Result, resultDomainScan domainscan.Result, result string, err error) {
 	var domainPort map[string]map[int]struct{}
+	var resultVul []pocscan.Result
 	// 返回的结果
 	resultPortScan.IPResult = make(map[string]*portscan.IPResult)
 	resultDomainScan.DomainResult = make(map[string]*domainscan.DomainResult)
@@ -72,7 +76,7 @@ func doFingerPrintAndSave(taskId string, mainTaskId string, config FingerprintTa
 			IsIconHash:       config.IsIconHash,
 			WorkspaceId:      config.WorkspaceId,
 		}
-		doIPFingerPrint(portscanConfig, &resultPortScan)
+		resultVul = append(resultVul, doIPFingerPrint(portscanConfig, &resultPortScan)...)
 		resultArgs.IPConfig = &portscanConfig
 		resultArgs.IPResult = resultPortScan.IPResult
 	}
@@ -96,16 +100,30 @@ func doFingerPrintAndSave(taskId string, mainTaskId string, config FingerprintTa
 			IsIconHash:       config.IsIconHash,
 			WorkspaceId:      config.WorkspaceId,
 		}
-		doDomainFingerPrint(domainscanConfig, &resultDomainScan, domainPort)
+		resultVul = append(resultVul, doDomainFingerPrint(domainscanConfig, &resultDomainScan, domainPort)...)
 		resultArgs.DomainConfig = &domainscanConfig
 		resultArgs.DomainResult = resultDomainScan.DomainResult
 	}
 	// 保存结果
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithSpool("SaveScanResult", &resultArgs, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return
 	}
+	// 保存敏感信息泄露的漏洞
+	if len(resultVul) > 0 {
+		vulArgs := comm.ScanResultArgs{
+			TaskID:              taskId,
+			MainTaskId:          mainTaskId,
+			VulnerabilityResult: resultVul,
+		}
+		var vulResult string
+		if err = comm.CallXClientWithSpool("SaveVulnerabilityResult", &vulArgs, &vulResult); err != nil {
+			logging.RuntimeLog.Error(err)
+			return
+		}
+		result = strings.Join([]string{result, vulResult}, ",")
+	}
 	// screenshot任务
 	if config.IsScreenshot {
 		resultScreenshot := doScreenshotAndSave(config.WorkspaceId, mainTaskId, &resultPortScan, &resultDomainScan, domainPort)
@@ -115,12 +133,19 @@ func doFingerPrintAndSave(taskId string, mainTaskId string, config FingerprintTa
 	return
 }
 
-// doIPFingerPrint 对 IP结果进行指纹识别
-func doIPFingerPrint(config portscan.Config, resultPortScan *portscan.Result) {
+// doIPFingerPrint 对 IP结果进行指纹识别，返回敏感信息泄露的漏洞
+func doIPFingerPrint(config portscan.Config, resultPortScan *portscan.Result) (resultVul []pocscan.Result) {
 	if config.IsHttpx {
 		httpx := fingerprint.NewHttpxFinger()
+		if conf.GlobalWorkerConfig().Fingerprint.IsLeak {
+			httpx.EnableLeakDetect()
+		}
+		if conf.GlobalWorkerConfig().Fingerprint.IsWaf {
+			httpx.EnableWafDetect()
+		}
 		httpx.ResultPortScan = *resultPortScan
 		httpx.DoHttpxAndFingerPrint()
+		resultVul = getLeakVulnerability(config.WorkspaceId, httpx.Leak)
 	}
 	if config.IsFingerprintHub {
 		fp := fingerprint.NewFingerprintHub()
@@ -130,16 +155,24 @@ func doIPFingerPrint(config portscan.Config, resultPortScan *portscan.Result) {
 	if config.IsIconHash {
 		doIconHashAndSave(config.WorkspaceId, resultPortScan, nil, nil)
 	}
+	return
 }
 
-// doDomainFingerPrint 对域名结果进行指纹识别
-func doDomainFingerPrint(config domainscan.Config, resultDomainScan *domainscan.Result, domainPort map[string]map[int]struct{}) {
+// doDomainFingerPrint 对域名结果进行指纹识别，返回敏感信息泄露的漏洞
+func doDomainFingerPrint(config domainscan.Config, resultDomainScan *domainscan.Result, domainPort map[string]map[int]struct{}) (resultVul []pocscan.Result) {
 	// 指纹识别
 	if config.IsHttpx {
 		httpx := fingerprint.NewHttpxFinger()
+		if conf.GlobalWorkerConfig().Fingerprint.IsLeak {
+			httpx.EnableLeakDetect()
+		}
+		if conf.GlobalWorkerConfig().Fingerprint.IsWaf {
+			httpx.EnableWafDetect()
+		}
 		httpx.ResultDomainScan = *resultDomainScan
 		httpx.DomainTargetPort = domainPort
 		httpx.DoHttpxAndFingerPrint()
+		resultVul = getLeakVulnerability(config.WorkspaceId, httpx.Leak)
 	}
 	if config.IsFingerprintHub {
 		fp := fingerprint.NewFingerprintHub()
@@ -150,6 +183,25 @@ func doDomainFingerPrint(config domainscan.Config, resultDomainScan *domainscan.
 	if config.IsIconHash {
 		doIconHashAndSave(config.WorkspaceId, nil, resultDomainScan, domainPort)
 	}
+	return
+}
+
+// getLeakVulnerability 将敏感信息泄露的检测结果转换为漏洞结果
+func getLeakVulnerability(workspaceId int, leak *fingerprint.LeakDetect) (resultVul []pocscan.Result) {
+	if leak == nil {
+		return
+	}
+	for _, r := range leak.Result {
+		resultVul = append(resultVul, pocscan.Result{
+			Target:      r.Target,
+			Url:         r.Url,
+			PocFile:     fmt.Sprintf("leak-%s", r.Name),
+			Source:      "leak",
+			Extra:       fmt.Sprintf("category:%s\n%s", r.Category, strings.Join(r.Content, "\n")),
+			WorkspaceId: workspaceId,
+		})
+	}
+	return
 }
 
 // doScreenshotAndSave 执行Screenshot并保存
@@ -168,7 +220,7 @@ func doScreenshotAndSave(workspaceId int, mainTaskId string, resultIPScan *ports
 		FileInfo:    ss.LoadResult(),
 		WorkspaceId: workspaceId,
 	}
-	err := comm.CallXClient("SaveScreenshotResult", &args, &result)
+	err := comm.CallXClientWithSpool("SaveScreenshotResult", &args, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return err.Error()
@@ -195,7 +247,7 @@ func doIconHashAndSave(workspaceId int, resultIPScan *portscan.Result, resultDom
 		WorkspaceId:  workspaceId,
 		IconHashInfo: hash.IconHashInfoResult.Result,
 	}
-	err := comm.CallXClient("SaveIconImageResult", &args, &result)
+	err := comm.CallXClientWithSpool("SaveIconImageResult", &args, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return err.Error()
@@ -2,6 +2,7 @@ package workerapi
 
 import (
 	"github.com/hanc00l/nemo_go/pkg/comm"
+	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
@@ -40,7 +41,7 @@ func IPLocation(taskId, mainTaskId, configJSON string) (result string, err error
 		IPConfig:   &portscan.Config{OrgId: config.OrgId},
 		IPResult:   resultPortScan.IPResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithSpool("SaveScanResult", &resultArgs, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return FailedTask(err.Error()), err
@@ -48,16 +49,30 @@ func IPLocation(taskId, mainTaskId, configJSON string) (result string, err error
 	return SucceedTask(result), nil
 }
 
-// doLocation 执行IP位置查询
+// doLocation 执行IP位置查询，结构化的国家、省份及城市保存为IP的属性；并获取IP的ASN、AS名称及宣告的网段
 func doLocation(portScanResult *portscan.Result) {
-	ipl := custom.NewIPLocation()
+	ipl := custom.NewIPLocation(conf.GlobalWorkerConfig().IPLocation.Provider...)
+	asnQuery := custom.GetASNQuery()
 	for ip, _ := range portScanResult.IPResult {
 		location := ipl.FindCustomIP(ip)
 		if location == "" {
-			location = ipl.FindPublicIP(ip)
+			geo := ipl.FindGeoLocation(ip)
+			location = geo.Location
+			for tag, content := range map[string]string{"country": geo.Country, "region": geo.Region, "city": geo.City} {
+				if content != "" {
+					portScanResult.SetIPAttr(ip, portscan.IPAttrResult{Source: "iplocation", Tag: tag, Content: content})
+				}
+			}
 		}
 		if location != "" {
 			portScanResult.IPResult[ip].Location = location
 		}
+		if info, ok := asnQuery.Find(ip); ok {
+			portScanResult.SetIPAttr(ip, portscan.IPAttrResult{Source: "asn", Tag: "asn", Content: custom.FormatASN(info.ASN)})
+			if info.Name != "" {
+				portScanResult.SetIPAttr(ip, portscan.IPAttrResult{Source: "asn", Tag: "as_name", Content: info.Name})
+			}
+			portScanResult.SetIPAttr(ip, portscan.IPAttrResult{Source: "asn", Tag: "as_prefix", Content: info.Prefix})
+		}
 	}
 }
@@ -0,0 +1,141 @@
+package workerapi
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"strings"
+)
+
+// LocalScanConfig 本地扫描（nemo scan）的参数
+type LocalScanConfig struct {
+	Target             string
+	Port               string
+	IsSubDomainFinder  bool
+	IsSubDomainBrute   bool
+	IsSubDomainCrawler bool
+	IsPermutation      bool
+	IsZoneTransfer     bool
+	IsFingerprint      bool
+	IsXrayPoc          bool
+	XrayPocFile        string
+	IsNucleiPoc        bool
+	NucleiPocFile      string
+	IsGobyPoc          bool
+}
+
+// LocalScan 本地扫描
+type LocalScan struct {
+	XScan
+	// port 域名解析的IP使用相同的端口进行扫描
+	port string
+}
+
+// NewLocalScan 创建本地扫描：在本机按XSCAN的流程执行端口扫描、域名收集、指纹识别及漏洞验证，不依赖server、消息队列及RPC
+func NewLocalScan(config LocalScanConfig) (*LocalScan, error) {
+	xConfig := XScanConfig{
+		IPPortString:       make(map[string]string),
+		Domain:             make(map[string]struct{}),
+		IsSubDomainFinder:  config.IsSubDomainFinder,
+		IsSubDomainBrute:   config.IsSubDomainBrute,
+		IsSubDomainCrawler: config.IsSubDomainCrawler,
+		IsPermutation:      config.IsPermutation,
+		IsZoneTransfer:     config.IsZoneTransfer,
+		IsFingerprint:      config.IsFingerprint,
+		IsXrayPoc:          config.IsXrayPoc,
+		XrayPocFile:        config.XrayPocFile,
+		IsNucleiPoc:        config.IsNucleiPoc,
+		NucleiPocFile:      config.NucleiPocFile,
+		IsGobyPoc:          config.IsGobyPoc,
+	}
+	port := config.Port
+	if port == "" {
+		port = conf.GlobalWorkerConfig().Portscan.Port
+	}
+	for _, t := range strings.Split(config.Target, ",") {
+		target := strings.TrimSpace(t)
+		if target == "" {
+			continue
+		}
+		if len(utils.ParseIP(target)) > 0 {
+			xConfig.IPPortString[target] = port
+		} else if utils.CheckDomain(target) {
+			xConfig.Domain[target] = struct{}{}
+		} else {
+			return nil, fmt.Errorf("invalid target:%s", target)
+		}
+	}
+	if len(xConfig.IPPortString) == 0 && len(xConfig.Domain) == 0 {
+		return nil, fmt.Errorf("no target to scan")
+	}
+	x := &LocalScan{XScan: XScan{Config: xConfig}, port: port}
+	x.ResultIP.IPResult = make(map[string]*portscan.IPResult)
+	x.ResultDomain.DomainResult = make(map[string]*domainscan.DomainResult)
+
+	return x, nil
+}
+
+// Do 执行本地扫描，结果保存在ResultIP、ResultDomain及ResultVul中
+func (x *LocalScan) Do() {
+	// 域名任务
+	if len(x.Config.Domain) > 0 {
+		logging.CLILog.Infof("domainscan:%d", len(x.Config.Domain))
+		config := x.newDomainscanConfig()
+		x.runDomainscan(config)
+		// 对域名解析的IP进行端口扫描
+		if config.IsIPPortScan {
+			ipResult, _ := getResultIPList(&x.ResultDomain)
+			for _, ip := range ipResult {
+				if _, ok := x.Config.IPPortString[ip]; !ok {
+					x.Config.IPPortString[ip] = x.port
+				}
+			}
+		}
+	}
+	// 端口扫描
+	if len(x.Config.IPPortString) > 0 {
+		logging.CLILog.Infof("portscan:%d", len(x.Config.IPPortString))
+		x.runPortscan()
+	}
+	// 指纹识别：icon及screenshot需要保存到server，本地扫描不执行
+	if x.Config.IsFingerprint {
+		logging.CLILog.Info("fingerprint...")
+		conf.GlobalWorkerConfig().ReloadConfig()
+		fpConfig := conf.GlobalWorkerConfig().Fingerprint
+		if len(x.ResultIP.IPResult) > 0 {
+			x.ResultVul = append(x.ResultVul, doIPFingerPrint(portscan.Config{IsHttpx: fpConfig.IsHttpx, IsFingerprintHub: fpConfig.IsFingerprintHub}, &x.ResultIP)...)
+		}
+		if len(x.ResultDomain.DomainResult) > 0 {
+			x.ResultVul = append(x.ResultVul, doDomainFingerPrint(domainscan.Config{IsHttpx: fpConfig.IsHttpx, IsFingerprintHub: fpConfig.IsFingerprintHub}, &x.ResultDomain, nil)...)
+		}
+	}
+	// 漏洞验证的目标为端口扫描及域名任务的结果
+	if !x.Config.IsXrayPoc && !x.Config.IsNucleiPoc && !x.Config.IsGobyPoc {
+		return
+	}
+	x.Config.IPPort = make(map[string][]int)
+	for ip, ipr := range x.ResultIP.IPResult {
+		for port := range ipr.Ports {
+			x.Config.IPPort[ip] = append(x.Config.IPPort[ip], port)
+		}
+	}
+	x.Config.Domain = make(map[string]struct{})
+	for domain := range x.ResultDomain.DomainResult {
+		x.Config.Domain[domain] = struct{}{}
+	}
+	if x.Config.IsXrayPoc {
+		logging.CLILog.Info("xray...")
+		x.runXrayScan()
+	}
+	if x.Config.IsNucleiPoc {
+		logging.CLILog.Info("nuclei...")
+		x.runNucleiScan()
+	}
+	if x.Config.IsGobyPoc {
+		logging.CLILog.Info("goby...")
+		x.runGobyScan("")
+	}
+}
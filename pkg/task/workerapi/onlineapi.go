@@ -69,6 +69,7 @@ func doOnlineAPI(taskId string, mainTaskId string, configJSON string, apiName st
 
 // doOnlineAPIAndSave 执行fofa、hunter及quake的资产搜索，并保存结果
 func doOnlineAPIAndSave(taskId string, mainTaskId string, apiName string, config onlineapi.OnlineAPIConfig) (ipResult portscan.Result, domainResult domainscan.Result, result string, err error) {
+	config.APIKey = comm.FetchWorkerSecret(apiName, taskId)
 	s := onlineapi.NewOnlineAPISearch(config, apiName)
 	s.Do()
 	ipResult = s.IpResult
@@ -88,7 +89,7 @@ func doOnlineAPIAndSave(taskId string, mainTaskId string, apiName string, config
 		IPResult:     ipResult.IPResult,
 		DomainResult: domainResult.DomainResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &args, &result)
+	err = comm.CallXClientWithSpool("SaveScanResult", &args, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 	}
@@ -107,10 +108,11 @@ func ICPQuery(taskId, mainTaskId, configJSON string) (result string, err error)
 		return FailedTask(err.Error()), err
 	}
 
+	config.APIKey = comm.FetchWorkerSecret(comm.SecretICP, taskId)
 	icp := onlineapi.NewICPQuery(config)
 	icp.Do()
 	// 保存结果
-	err = comm.CallXClient("SaveICPResult", &icp.QueriedICPInfo, &result)
+	err = comm.CallXClientWithSpool("SaveICPResult", &icp.QueriedICPInfo, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return FailedTask(err.Error()), err
@@ -134,7 +136,7 @@ func WhoisQuery(taskId, mainTaskId, configJSON string) (result string, err error
 	whois := onlineapi.NewWhois(config)
 	whois.Do()
 	// 保存结果
-	err = comm.CallXClient("SaveWhoisResult", &whois.QueriedWhoisInfo, &result)
+	err = comm.CallXClientWithSpool("SaveWhoisResult", &whois.QueriedWhoisInfo, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return FailedTask(err.Error()), err
@@ -145,7 +147,7 @@ func WhoisQuery(taskId, mainTaskId, configJSON string) (result string, err error
 
 // checkIgnoreResult 检查资产查询API中的IP资产，非中国IP或CDN，则不保存该结果
 func checkIgnoreResult(portScanResult *portscan.Result, domainScanResult *domainscan.Result, config onlineapi.OnlineAPIConfig) {
-	iplocation := custom.NewIPLocation()
+	iplocation := custom.NewIPLocation(conf.GlobalWorkerConfig().IPLocation.Provider...)
 	cdnCheck := custom.NewCDNCheck()
 	if len(portScanResult.IPResult) > 0 && (config.IsIgnoreOutofChina || config.IsIgnoreCDN) {
 		for ip := range portScanResult.IPResult {
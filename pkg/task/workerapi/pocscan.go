@@ -4,6 +4,8 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"strings"
 )
 
 // PocScan 漏洞验证任务
@@ -31,6 +33,11 @@ func PocScan(taskId, mainTaskId, configJSON string) (result string, err error) {
 			logging.RuntimeLog.Error(err)
 		}
 	}
+	// 排除蜜罐IP及解析到蜜罐的域名，全部为蜜罐时不执行扫描
+	config.Target = excludeHoneyPotTarget(taskId, config.Target)
+	if config.Target == "" {
+		return SucceedTask("all targets are honeypot"), nil
+	}
 	var scanResult []pocscan.Result
 	if config.CmdBin == "xray" {
 		x := pocscan.NewXray(config)
@@ -45,6 +52,7 @@ func PocScan(taskId, mainTaskId, configJSON string) (result string, err error) {
 		n.Do()
 		scanResult = n.Result
 	} else if config.CmdBin == "goby" {
+		config.GobyAuth = comm.FetchWorkerSecret(comm.SecretGoby, taskId)
 		g := pocscan.NewGoby(config)
 		g.Do()
 		scanResult = g.Result
@@ -55,7 +63,7 @@ func PocScan(taskId, mainTaskId, configJSON string) (result string, err error) {
 		MainTaskId:          mainTaskId,
 		VulnerabilityResult: scanResult,
 	}
-	err = comm.CallXClient("SaveVulnerabilityResult", &resultArgs, &result)
+	err = comm.CallXClientWithSpool("SaveVulnerabilityResult", &resultArgs, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 		return FailedTask(err.Error()), err
@@ -63,3 +71,36 @@ func PocScan(taskId, mainTaskId, configJSON string) (result string, err error) {
 
 	return SucceedTask(result), nil
 }
+
+// excludeHoneyPotTarget 从目标中排除server认定为蜜罐的IP或域名，全部排除时返回空
+func excludeHoneyPotTarget(taskId string, target string) string {
+	// 本地扫描（nemo scan）没有server
+	if taskId == "" {
+		return target
+	}
+	var honeypotIPs []string
+	args := comm.LoadHoneyPotIPArgs{TaskId: taskId, Target: target}
+	if err := comm.CallXClient("LoadHoneyPotIP", &args, &honeypotIPs); err != nil {
+		logging.RuntimeLog.Error(err)
+		return target
+	}
+	if len(honeypotIPs) == 0 {
+		return target
+	}
+	honeypot := make(map[string]struct{})
+	for _, ip := range honeypotIPs {
+		honeypot[ip] = struct{}{}
+	}
+	var targets []string
+	for _, t := range strings.Split(target, ",") {
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+		if _, ok := honeypot[utils.HostStrip(strings.TrimSpace(t))]; ok {
+			logging.RuntimeLog.Warningf("%s is honeypot,skip pocscan", t)
+			continue
+		}
+		targets = append(targets, t)
+	}
+	return strings.Join(targets, ",")
+}
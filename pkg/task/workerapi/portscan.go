@@ -5,6 +5,7 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
 	"github.com/hanc00l/nemo_go/pkg/utils"
 	"github.com/remeh/sizedwaitgroup"
@@ -17,6 +18,7 @@ var fpNmapThreadNumber = make(map[string]int)
 func init() {
 	fpNmapThreadNumber[conf.HighPerformance] = 10
 	fpNmapThreadNumber[conf.NormalPerformance] = 5
+	fpNmapThreadNumber[conf.LowPerformance] = 2
 }
 
 // PortScan 端口扫描任务
@@ -43,6 +45,13 @@ func PortScan(taskId, mainTaskId, configJSON string) (result string, err error)
 	if err != nil {
 		return FailedTask(err.Error()), err
 	}
+	// 虚拟主机发现任务：在端口扫描结果保存后执行，使用数据库中的开放端口
+	if config.IsVhost {
+		vhostConfig := fingerprint.VhostConfig{Target: config.Target, OrgId: config.OrgId, WorkspaceId: config.WorkspaceId}
+		if _, err = sendTask(taskId, mainTaskId, vhostConfig, "vhost"); err != nil {
+			return FailedTask(err.Error()), err
+		}
+	}
 	return SucceedTask(result), nil
 }
 
@@ -74,7 +83,7 @@ func doPortScanAndSave(taskId string, mainTaskId string, config portscan.Config)
 		IPConfig:   &config,
 		IPResult:   resultPortScan.IPResult,
 	}
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
+	err = comm.CallXClientWithSpool("SaveScanResult", &resultArgs, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 	}
@@ -121,7 +130,7 @@ func doMasscanPlusNmap(config portscan.Config) (resultPortScan portscan.Result)
 	masscan.Do()
 	ipPortMap := getResultIPPortMap(masscan.Result.IPResult)
 	//nmap多线程扫描
-	swg := sizedwaitgroup.New(fpNmapThreadNumber[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(fpNmapThreadNumber[conf.GetWorkerPerformanceMode()])
 	for ip, port := range ipPortMap {
 		nmapConfig := config
 		nmapConfig.Target = ip
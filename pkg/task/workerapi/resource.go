@@ -0,0 +1,178 @@
+package workerapi
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	psnet "github.com/shirou/gopsutil/v3/net"
+	"sync"
+	"time"
+)
+
+const (
+	// memoryHighWatermark 内存使用率超过该值时进入资源不足状态
+	memoryHighWatermark = 85.0
+	// memoryLowWatermark 内存使用率低于该值时退出资源不足状态（与high之间留有间隔，避免频繁切换）
+	memoryLowWatermark = 70.0
+	// memoryMinAvailable 可用内存低于该值时同样进入资源不足状态
+	memoryMinAvailable = 512 * 1024 * 1024
+	// lowResourceMaxRunningTask 资源不足时同时执行的任务数
+	lowResourceMaxRunningTask = 1
+	// resourceMonitorInterval 资源采集的间隔
+	resourceMonitorInterval = 10 * time.Second
+)
+
+// taskGate 限制worker同时执行的任务数，limit为0时不作限制
+type taskGate struct {
+	sync.Mutex
+	cond    *sync.Cond
+	running int
+	limit   int
+}
+
+// resourceCollector 采集资源时保存上一次的网络流量，用于计算速率
+type resourceCollector struct {
+	lastNetTime time.Time
+	lastNetSent uint64
+	lastNetRecv uint64
+}
+
+var runningTaskGate = newTaskGate()
+
+func newTaskGate() *taskGate {
+	g := &taskGate{}
+	g.cond = sync.NewCond(&g.Mutex)
+	return g
+}
+
+// acquire 等待直到可以执行任务
+func (g *taskGate) acquire() {
+	g.Lock()
+	defer g.Unlock()
+
+	for g.limit > 0 && g.running >= g.limit {
+		g.cond.Wait()
+	}
+	g.running++
+}
+
+// release 任务执行完成
+func (g *taskGate) release() {
+	g.Lock()
+	defer g.Unlock()
+
+	g.running--
+	g.cond.Broadcast()
+}
+
+// setLimit 调整同时执行的任务数
+func (g *taskGate) setLimit(limit int) {
+	g.Lock()
+	defer g.Unlock()
+
+	g.limit = limit
+	g.cond.Broadcast()
+}
+
+// status 当前执行的任务数及限制
+func (g *taskGate) status() (running, limit int) {
+	g.Lock()
+	defer g.Unlock()
+
+	return g.running, g.limit
+}
+
+// wrapTaskWithGate 任务执行前先等待资源，资源不足时任务在worker中排队而不是同时执行
+func wrapTaskWithGate(taskFunc interface{}) interface{} {
+	f, ok := taskFunc.(func(string, string, string) (string, error))
+	if !ok {
+		return taskFunc
+	}
+	return func(taskId, mainTaskId, configJSON string) (string, error) {
+		runningTaskGate.acquire()
+		defer runningTaskGate.release()
+
+		return f(taskId, mainTaskId, configJSON)
+	}
+}
+
+// collect 采集worker的资源使用情况
+func (rc *resourceCollector) collect() (resource ampq.WorkerResource) {
+	resource.CPUNumber, _ = cpu.Counts(true)
+	if percent, err := cpu.Percent(0, false); err == nil && len(percent) > 0 {
+		resource.CPUPercent = percent[0]
+	}
+	if memInfo, err := mem.VirtualMemory(); err == nil {
+		resource.MemTotal = memInfo.Total
+		resource.MemAvailable = memInfo.Available
+		resource.MemUsedPercent = memInfo.UsedPercent
+	}
+	if loadInfo, err := load.Avg(); err == nil {
+		resource.Load1 = loadInfo.Load1
+		resource.Load5 = loadInfo.Load5
+		resource.Load15 = loadInfo.Load15
+	}
+	if counters, err := psnet.IOCounters(false); err == nil && len(counters) > 0 {
+		now := time.Now()
+		if !rc.lastNetTime.IsZero() && counters[0].BytesSent >= rc.lastNetSent && counters[0].BytesRecv >= rc.lastNetRecv {
+			seconds := uint64(now.Sub(rc.lastNetTime).Seconds())
+			if seconds > 0 {
+				resource.NetSentRate = (counters[0].BytesSent - rc.lastNetSent) / seconds
+				resource.NetRecvRate = (counters[0].BytesRecv - rc.lastNetRecv) / seconds
+			}
+		}
+		rc.lastNetTime = now
+		rc.lastNetSent = counters[0].BytesSent
+		rc.lastNetRecv = counters[0].BytesRecv
+	}
+	return
+}
+
+// checkLowResource 根据内存使用情况判断是否资源不足
+func checkLowResource(resource ampq.WorkerResource, lastLowResource bool) bool {
+	if resource.MemTotal == 0 {
+		return false
+	}
+	if resource.MemUsedPercent >= memoryHighWatermark || resource.MemAvailable < memoryMinAvailable {
+		return true
+	}
+	if lastLowResource && resource.MemUsedPercent > memoryLowWatermark {
+		return true
+	}
+	return false
+}
+
+// StartResourceMonitor 定时采集worker的资源使用情况并通过心跳上报；
+// 内存不足时降低任务的线程数并限制同时执行的任务数，避免worker因内存耗尽被系统终止
+func StartResourceMonitor() {
+	rc := &resourceCollector{}
+	lowResource := false
+	for {
+		resource := rc.collect()
+		isLow := checkLowResource(resource, lowResource)
+		if isLow != lowResource {
+			if isLow {
+				runningTaskGate.setLimit(lowResourceMaxRunningTask)
+				logging.RuntimeLog.Warningf("worker low resource,memory used:%.1f%%,available:%dMB", resource.MemUsedPercent, resource.MemAvailable/1024/1024)
+				logging.CLILog.Warningf("worker low resource,memory used:%.1f%%,available:%dMB", resource.MemUsedPercent, resource.MemAvailable/1024/1024)
+			} else {
+				runningTaskGate.setLimit(0)
+				logging.RuntimeLog.Info("worker resource recovered")
+				logging.CLILog.Info("worker resource recovered")
+			}
+			conf.SetWorkerLowResource(isLow)
+			lowResource = isLow
+		}
+		resource.LowResource = lowResource
+		resource.RunningTask, resource.MaxRunningTask = runningTaskGate.status()
+
+		WStatus.Lock()
+		WStatus.Resource = resource
+		WStatus.Unlock()
+
+		time.Sleep(resourceMonitorInterval)
+	}
+}
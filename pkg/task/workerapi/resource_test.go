@@ -0,0 +1,51 @@
+package workerapi
+
+import (
+	"github.com/hanc00l/nemo_go/pkg/task/ampq"
+	"testing"
+	"time"
+)
+
+func TestResourceCollector_Collect(t *testing.T) {
+	rc := &resourceCollector{}
+	rc.collect()
+	time.Sleep(time.Second)
+	resource := rc.collect()
+	t.Log(resource)
+	t.Log(checkLowResource(resource, false))
+}
+
+func TestCheckLowResource(t *testing.T) {
+	resource := ampq.WorkerResource{MemTotal: 8 << 30, MemAvailable: 2 << 30, MemUsedPercent: 75}
+	if checkLowResource(resource, false) {
+		t.Error("expected not low resource")
+	}
+	// 在high与low之间时保持原来的状态
+	if !checkLowResource(resource, true) {
+		t.Error("expected low resource")
+	}
+	resource.MemAvailable = 256 << 20
+	if !checkLowResource(resource, false) {
+		t.Error("expected low resource")
+	}
+}
+
+func TestTaskGate(t *testing.T) {
+	g := newTaskGate()
+	g.setLimit(1)
+	g.acquire()
+	acquired := make(chan struct{})
+	go func() {
+		g.acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("task gate not limited")
+	case <-time.After(200 * time.Millisecond):
+	}
+	g.setLimit(0)
+	<-acquired
+	running, limit := g.status()
+	t.Log(running, limit)
+}
@@ -0,0 +1,77 @@
+package workerapi
+
+import (
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/comm"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"strconv"
+	"strings"
+)
+
+// Vhost 虚拟主机发现任务
+func Vhost(taskId, mainTaskId, configJSON string) (result string, err error) {
+	var ok bool
+	if ok, result, err = CheckTaskStatus(taskId); !ok {
+		return result, err
+	}
+
+	config := fingerprint.VhostConfig{}
+	if err = ParseConfig(configJSON, &config); err != nil {
+		logging.RuntimeLog.Error(err)
+		return FailedTask(err.Error()), err
+	}
+	vhost := fingerprint.NewVhost(config)
+	// 读取目标IP在数据库中已保存的开放端口
+	var resultIPPorts string
+	args := comm.LoadIPOpenedPortArgs{WorkspaceId: config.WorkspaceId, Target: config.Target}
+	if err = comm.CallXClient("LoadOpenedPort", &args, &resultIPPorts); err != nil {
+		logging.RuntimeLog.Error(err)
+		return FailedTask(err.Error()), err
+	}
+	for _, ipPort := range strings.Split(resultIPPorts, ",") {
+		dataArray := strings.Split(ipPort, ":")
+		if len(dataArray) != 2 || !utils.CheckIPV4(dataArray[0]) {
+			continue
+		}
+		if port, err := strconv.Atoi(dataArray[1]); err == nil {
+			vhost.IPPort[dataArray[0]] = append(vhost.IPPort[dataArray[0]], port)
+		}
+	}
+	if len(vhost.IPPort) == 0 {
+		return SucceedTask(""), nil
+	}
+	// 工作空间中的域名作为候选的虚拟主机
+	if err = comm.CallXClient("LoadWorkspaceDomain", &taskId, &vhost.Names); err != nil {
+		logging.RuntimeLog.Error(err)
+	}
+	vhost.Do()
+	if len(vhost.Result) == 0 {
+		return SucceedTask(""), nil
+	}
+	// 发现的虚拟主机保存为域名，A记录为IP
+	resultDomainScan := domainscan.Result{DomainResult: make(map[string]*domainscan.DomainResult)}
+	for _, r := range vhost.Result {
+		if !resultDomainScan.HasDomain(r.Host) {
+			resultDomainScan.SetDomain(r.Host)
+		}
+		resultDomainScan.SetDomainAttr(r.Host, domainscan.DomainAttrResult{Source: "vhost", Tag: "A", Content: r.IP})
+		resultDomainScan.SetDomainAttr(r.Host, domainscan.DomainAttrResult{Source: "vhost", Tag: "vhost", Content: fmt.Sprintf("%s://%s:%d status:%d length:%d", r.Scheme, r.IP, r.Port, r.StatusCode, r.Length)})
+		if r.Title != "" {
+			resultDomainScan.SetDomainAttr(r.Host, domainscan.DomainAttrResult{Source: "vhost", Tag: "title", Content: r.Title})
+		}
+	}
+	resultArgs := comm.ScanResultArgs{
+		TaskID:       taskId,
+		MainTaskId:   mainTaskId,
+		DomainConfig: &domainscan.Config{OrgId: config.OrgId, WorkspaceId: config.WorkspaceId},
+		DomainResult: resultDomainScan.DomainResult,
+	}
+	if err = comm.CallXClientWithSpool("SaveScanResult", &resultArgs, &result); err != nil {
+		logging.RuntimeLog.Error(err)
+		return FailedTask(err.Error()), err
+	}
+	return SucceedTask(result), nil
+}
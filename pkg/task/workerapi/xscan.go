@@ -7,6 +7,7 @@ import (
 	"github.com/hanc00l/nemo_go/pkg/conf"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
 	"github.com/hanc00l/nemo_go/pkg/task/onlineapi"
 	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
 	"github.com/hanc00l/nemo_go/pkg/task/portscan"
@@ -40,6 +41,9 @@ type XScanConfig struct {
 	IsSubDomainFinder  bool                `json:"subfinder,omitempty"`
 	IsSubDomainBrute   bool                `json:"subdomainBrute,omitempty"`
 	IsSubDomainCrawler bool                `json:"subdomainCrawler,omitempty"`
+	IsPermutation      bool                `json:"permutation,omitempty"`
+	IsZoneTransfer     bool                `json:"zonetransfer,omitempty"`
+	IsCDNOrigin        bool                `json:"cdnorigin,omitempty"`
 	// fingerprint
 	IsFingerprint bool `json:"fingerprint,omitempty"`
 	// xraypoc
@@ -58,6 +62,8 @@ type XScan struct {
 	ResultDomain domainscan.Result
 	ResultVul    []pocscan.Result
 	vulMutex     sync.Mutex
+	// taskId 正在执行的任务，server据此确定任务所在的工作空间；本地扫描时为空
+	taskId string
 }
 
 var (
@@ -69,12 +75,15 @@ var (
 func init() {
 	portscanMaxThreadNum[conf.HighPerformance] = 4
 	portscanMaxThreadNum[conf.NormalPerformance] = 2
+	portscanMaxThreadNum[conf.LowPerformance] = 1
 	//
 	domainscanMaxThreadNum[conf.HighPerformance] = 4
 	domainscanMaxThreadNum[conf.NormalPerformance] = 2
+	domainscanMaxThreadNum[conf.LowPerformance] = 1
 	//
 	xrayscanMaxThreadNum[conf.HighPerformance] = 4
 	xrayscanMaxThreadNum[conf.NormalPerformance] = 2
+	xrayscanMaxThreadNum[conf.LowPerformance] = 1
 
 }
 func NewXScan(config XScanConfig) *XScan {
@@ -371,9 +380,26 @@ func XGoby(taskId, mainTaskId, configJSON string) (result string, err error) {
 
 // Portscan 执行端口扫描，通过协程并发执行
 func (x *XScan) Portscan(taskId string, mainTaskId string) (result string, err error) {
+	x.runPortscan()
+	// 保存结果
+	resultArgs := comm.ScanResultArgs{
+		TaskID:     taskId,
+		MainTaskId: mainTaskId,
+		IPConfig:   &portscan.Config{OrgId: x.Config.OrgId, WorkspaceId: x.Config.WorkspaceId},
+		IPResult:   x.ResultIP.IPResult,
+	}
+	err = comm.CallXClientWithSpool("SaveScanResult", &resultArgs, &result)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+	}
+	return
+}
+
+// runPortscan 并发执行端口扫描，结果合并到ResultIP
+func (x *XScan) runPortscan() {
 	x.ResultIP.IPResult = make(map[string]*portscan.IPResult)
 
-	swg := sizedwaitgroup.New(portscanMaxThreadNum[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(portscanMaxThreadNum[conf.GetWorkerPerformanceMode()])
 	// 生成扫描参数
 	conf.GlobalWorkerConfig().ReloadConfig()
 	config := portscan.Config{
@@ -417,18 +443,6 @@ func (x *XScan) Portscan(taskId string, mainTaskId string) (result string, err e
 		}
 	}
 	swg.Wait()
-	// 保存结果
-	resultArgs := comm.ScanResultArgs{
-		TaskID:     taskId,
-		MainTaskId: mainTaskId,
-		IPConfig:   &portscan.Config{OrgId: config.OrgId, WorkspaceId: config.WorkspaceId},
-		IPResult:   x.ResultIP.IPResult,
-	}
-	err = comm.CallXClient("SaveScanResult", &resultArgs, &result)
-	if err != nil {
-		logging.RuntimeLog.Error(err)
-	}
-	return
 }
 
 // doPortscan 调用一次端口扫描
@@ -465,21 +479,29 @@ func (x *XScan) doPortscan(swg *sizedwaitgroup.SizedWaitGroup, config portscan.C
 func (x *XScan) doDomainscan(swg *sizedwaitgroup.SizedWaitGroup, config domainscan.Config) {
 	defer swg.Done()
 
-	var result domainscan.Result
 	//扫描
-	result = doDomainScan(config)
+	result, resultVul := doDomainScan(x.taskId, config)
 	//合并结果
 	x.ResultDomain.Lock()
 	for k, v := range result.DomainResult {
 		x.ResultDomain.DomainResult[k] = v
 	}
 	x.ResultDomain.Unlock()
+	if len(resultVul) > 0 {
+		x.vulMutex.Lock()
+		x.ResultVul = append(x.ResultVul, resultVul...)
+		x.vulMutex.Unlock()
+	}
 }
 
 // doXrayscan 调用一次Xray
 func (x *XScan) doXrayscan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.Config) {
 	defer swg.Done()
 
+	config.Target = excludeHoneyPotTarget(x.taskId, config.Target)
+	if config.Target == "" {
+		return
+	}
 	xray := pocscan.NewXray(config)
 	xray.Do()
 	//合并结果
@@ -492,6 +514,10 @@ func (x *XScan) doXrayscan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.Co
 func (x *XScan) doNucleiScan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.Config) {
 	defer swg.Done()
 
+	config.Target = excludeHoneyPotTarget(x.taskId, config.Target)
+	if config.Target == "" {
+		return
+	}
 	nuclei := pocscan.NewNuclei(config)
 	nuclei.Do()
 	//合并结果
@@ -504,6 +530,10 @@ func (x *XScan) doNucleiScan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.
 func (x *XScan) doGobyScan(swg *sizedwaitgroup.SizedWaitGroup, config pocscan.Config) {
 	defer swg.Done()
 
+	config.Target = excludeHoneyPotTarget(x.taskId, config.Target)
+	if config.Target == "" {
+		return
+	}
 	goby := pocscan.NewGoby(config)
 	goby.Do()
 	//合并结果
@@ -555,16 +585,63 @@ func (x *XScan) OnlineAPISearch(taskId string, mainTaskId string) (result string
 
 // Domainscan 执行域名任务
 func (x *XScan) Domainscan(taskId string, mainTaskId string) (result string, err error) {
-	x.ResultDomain.DomainResult = make(map[string]*domainscan.DomainResult)
-	swg := sizedwaitgroup.New(domainscanMaxThreadNum[conf.WorkerPerformanceMode])
+	x.taskId = taskId
+	config := x.newDomainscanConfig()
+	if config.IsSubDomainFinder {
+		config.ProviderConfig = comm.FetchWorkerSecret(comm.SecretSubfinder, taskId)
+	}
+	x.runDomainscan(config)
+	// 如果有端口扫描的选项
+	if config.IsIPPortScan || config.IsIPSubnetPortScan {
+		doPortScanByDomainscan(taskId, mainTaskId, config, &x.ResultDomain)
+	}
+	// 保存结果
+	resultArgs := comm.ScanResultArgs{
+		TaskID:       taskId,
+		MainTaskId:   mainTaskId,
+		DomainConfig: &domainscan.Config{OrgId: config.OrgId, WorkspaceId: x.Config.WorkspaceId},
+		DomainResult: x.ResultDomain.DomainResult,
+	}
+	if err = comm.CallXClientWithSpool("SaveScanResult", &resultArgs, &result); err != nil {
+		logging.RuntimeLog.Error(err)
+	}
+	// 保存子域名接管、域传送、JS分析的漏洞
+	if len(x.ResultVul) > 0 {
+		vulArgs := comm.ScanResultArgs{
+			TaskID:              taskId,
+			MainTaskId:          mainTaskId,
+			VulnerabilityResult: x.ResultVul,
+		}
+		var vulResult string
+		if err = comm.CallXClientWithSpool("SaveVulnerabilityResult", &vulArgs, &vulResult); err != nil {
+			logging.RuntimeLog.Error(err)
+		}
+	}
+	// CDN源站发现：在域名结果保存后执行
+	if x.Config.IsCDNOrigin {
+		if cdnDomains := getResultCDNDomain(&x.ResultDomain); len(cdnDomains) > 0 {
+			originConfig := fingerprint.CDNOriginConfig{Target: strings.Join(cdnDomains, ","), OrgId: x.Config.OrgId, WorkspaceId: x.Config.WorkspaceId}
+			if _, err = sendTask(taskId, mainTaskId, originConfig, "cdnorigin"); err != nil {
+				logging.RuntimeLog.Error(err)
+			}
+		}
+	}
+	return
+}
 
+// newDomainscanConfig 根据任务参数及worker配置生成域名任务的参数
+func (x *XScan) newDomainscanConfig() domainscan.Config {
 	conf.GlobalWorkerConfig().ReloadConfig()
-	config := domainscan.Config{
+	return domainscan.Config{
 		OrgId: x.Config.OrgId,
 		// domain方法：
 		IsSubDomainFinder: x.Config.IsSubDomainFinder,
 		IsSubDomainBrute:  x.Config.IsSubDomainBrute,
 		IsCrawler:         x.Config.IsSubDomainCrawler,
+		IsPermutation:     x.Config.IsPermutation,
+		IsZoneTransfer:    x.Config.IsZoneTransfer,
+		IsTakeover:        conf.GlobalWorkerConfig().Domainscan.IsTakeover,
+		IsJSAnalysis:      conf.GlobalWorkerConfig().Domainscan.IsJSAnalysis,
 		//
 		IsIgnoreCDN:        conf.GlobalWorkerConfig().Domainscan.IsIgnoreCDN,
 		IsIgnoreOutofChina: conf.GlobalWorkerConfig().Domainscan.IsIgnoreOutofChina,
@@ -572,6 +649,12 @@ func (x *XScan) Domainscan(taskId string, mainTaskId string) (result string, err
 
 		WorkspaceId: x.Config.WorkspaceId,
 	}
+}
+
+// runDomainscan 并发执行域名任务，结果合并到ResultDomain
+func (x *XScan) runDomainscan(config domainscan.Config) {
+	x.ResultDomain.DomainResult = make(map[string]*domainscan.DomainResult)
+	swg := sizedwaitgroup.New(domainscanMaxThreadNum[conf.GetWorkerPerformanceMode()])
 	for domain := range x.Config.Domain {
 		runConfig := config
 		runConfig.Target = domain
@@ -579,21 +662,6 @@ func (x *XScan) Domainscan(taskId string, mainTaskId string) (result string, err
 		go x.doDomainscan(&swg, runConfig)
 	}
 	swg.Wait()
-	// 如果有端口扫描的选项
-	if config.IsIPPortScan || config.IsIPSubnetPortScan {
-		doPortScanByDomainscan(taskId, mainTaskId, config, &x.ResultDomain)
-	}
-	// 保存结果
-	resultArgs := comm.ScanResultArgs{
-		TaskID:       taskId,
-		MainTaskId:   mainTaskId,
-		DomainConfig: &domainscan.Config{OrgId: config.OrgId, WorkspaceId: x.Config.WorkspaceId},
-		DomainResult: x.ResultDomain.DomainResult,
-	}
-	if err = comm.CallXClient("SaveScanResult", &resultArgs, &result); err != nil {
-		logging.RuntimeLog.Error(err)
-	}
-	return
 }
 
 // NewPortScan 根据IP/port列表，生成端口扫描任务
@@ -789,12 +857,29 @@ func (x *XScan) NewGobyScan(taskId, mainTaskId string) (result string, err error
 
 // NucleiScan 调用执行Nuclei扫描任务
 func (x *XScan) NucleiScan(taskId string, mainTaskId string) (result string, err error) {
+	x.taskId = taskId
+	x.runNucleiScan()
+	// 保存结果
+	resultArgs := comm.ScanResultArgs{
+		TaskID:              taskId,
+		MainTaskId:          mainTaskId,
+		VulnerabilityResult: x.ResultVul,
+	}
+	err = comm.CallXClientWithSpool("SaveVulnerabilityResult", &resultArgs, &result)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+	}
+	return
+}
+
+// runNucleiScan 并发执行Nuclei扫描，结果合并到ResultVul
+func (x *XScan) runNucleiScan() {
 	// 生成扫描参数
 	config := pocscan.Config{PocFile: x.Config.NucleiPocFile, WorkspaceId: x.Config.WorkspaceId}
 	if x.Config.NucleiPocFile == "" {
 		config.PocFile = "*"
 	}
-	swg := sizedwaitgroup.New(xrayscanMaxThreadNum[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(xrayscanMaxThreadNum[conf.GetWorkerPerformanceMode()])
 	if len(x.Config.IPPort) > 0 {
 		for ip, ports := range x.Config.IPPort {
 			for _, port := range ports {
@@ -814,25 +899,32 @@ func (x *XScan) NucleiScan(taskId string, mainTaskId string) (result string, err
 		}
 	}
 	swg.Wait()
+}
+
+// GobyScan 调用执行goby扫描任务
+func (x *XScan) GobyScan(taskId string, mainTaskId string) (result string, err error) {
+	x.taskId = taskId
+	x.runGobyScan(comm.FetchWorkerSecret(comm.SecretGoby, taskId))
 	// 保存结果
 	resultArgs := comm.ScanResultArgs{
 		TaskID:              taskId,
 		MainTaskId:          mainTaskId,
 		VulnerabilityResult: x.ResultVul,
 	}
-	err = comm.CallXClient("SaveVulnerabilityResult", &resultArgs, &result)
+	err = comm.CallXClientWithSpool("SaveVulnerabilityResult", &resultArgs, &result)
 	if err != nil {
 		logging.RuntimeLog.Error(err)
 	}
 	return
 }
 
-// GobyScan 调用执行goby扫描任务
-func (x *XScan) GobyScan(taskId string, mainTaskId string) (result string, err error) {
+// runGobyScan 执行goby扫描，结果合并到ResultVul
+func (x *XScan) runGobyScan(gobyAuth string) {
 	// 生成扫描参数
 	config := pocscan.Config{WorkspaceId: x.Config.WorkspaceId}
+	config.GobyAuth = gobyAuth
 	// goby支持通过,分隔的多个目标
-	swg := sizedwaitgroup.New(xrayscanMaxThreadNum[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(xrayscanMaxThreadNum[conf.GetWorkerPerformanceMode()])
 	if len(x.Config.IPPort) > 0 {
 		var targets []string
 		for ip, ports := range x.Config.IPPort {
@@ -856,17 +948,6 @@ func (x *XScan) GobyScan(taskId string, mainTaskId string) (result string, err e
 		go x.doGobyScan(&swg, runConfig)
 	}
 	swg.Wait()
-	// 保存结果
-	resultArgs := comm.ScanResultArgs{
-		TaskID:              taskId,
-		MainTaskId:          mainTaskId,
-		VulnerabilityResult: x.ResultVul,
-	}
-	err = comm.CallXClient("SaveVulnerabilityResult", &resultArgs, &result)
-	if err != nil {
-		logging.RuntimeLog.Error(err)
-	}
-	return
 }
 
 // NewXrayScan 生成xraypoc任务
@@ -894,12 +975,29 @@ func (x *XScan) NewXrayScan(taskId, mainTaskId string) (result string, err error
 
 // XrayScan 调用执行xray扫描任务
 func (x *XScan) XrayScan(taskId string, mainTaskId string) (result string, err error) {
+	x.taskId = taskId
+	x.runXrayScan()
+	// 保存结果
+	resultArgs := comm.ScanResultArgs{
+		TaskID:              taskId,
+		MainTaskId:          mainTaskId,
+		VulnerabilityResult: x.ResultVul,
+	}
+	err = comm.CallXClientWithSpool("SaveVulnerabilityResult", &resultArgs, &result)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+	}
+	return
+}
+
+// runXrayScan 并发执行xray扫描，结果合并到ResultVul
+func (x *XScan) runXrayScan() {
 	// 生成扫描参数
 	config := pocscan.Config{PocFile: x.Config.XrayPocFile, WorkspaceId: x.Config.WorkspaceId}
 	if x.Config.XrayPocFile == "" {
 		config.PocFile = "*"
 	}
-	swg := sizedwaitgroup.New(xrayscanMaxThreadNum[conf.WorkerPerformanceMode])
+	swg := sizedwaitgroup.New(xrayscanMaxThreadNum[conf.GetWorkerPerformanceMode()])
 	if len(x.Config.IPPort) > 0 {
 		for ip, ports := range x.Config.IPPort {
 			for _, port := range ports {
@@ -919,15 +1017,4 @@ func (x *XScan) XrayScan(taskId string, mainTaskId string) (result string, err e
 		}
 	}
 	swg.Wait()
-	// 保存结果
-	resultArgs := comm.ScanResultArgs{
-		TaskID:              taskId,
-		MainTaskId:          mainTaskId,
-		VulnerabilityResult: x.ResultVul,
-	}
-	err = comm.CallXClient("SaveVulnerabilityResult", &resultArgs, &result)
-	if err != nil {
-		logging.RuntimeLog.Error(err)
-	}
-	return
 }
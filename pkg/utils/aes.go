@@ -5,6 +5,7 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"errors"
 	"io"
 )
 
@@ -113,3 +114,36 @@ func AesDecryptCFB(encrypted []byte, key []byte) (decrypted []byte) {
 	stream.XORKeyStream(encrypted, encrypted)
 	return encrypted
 }
+
+// AesEncryptGCM 使用AES-GCM加密，密文的前12字节为随机的nonce
+func AesEncryptGCM(origData []byte, key []byte) (encrypted []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, origData, nil), nil
+}
+
+// AesDecryptGCM 解密AES-GCM加密的数据，数据被篡改时返回错误
+func AesDecryptGCM(encrypted []byte, key []byte) (decrypted []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, encrypted[:gcm.NonceSize()], encrypted[gcm.NonceSize():], nil)
+}
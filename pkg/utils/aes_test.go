@@ -0,0 +1,20 @@
+package utils
+
+import "testing"
+
+func TestAesGCM(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	encrypted, err := AesEncryptGCM([]byte("fofa-key"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := AesDecryptGCM(encrypted, key)
+	if err != nil || string(decrypted) != "fofa-key" {
+		t.Errorf("decrypt fail:%v,%s", err, decrypted)
+	}
+	// 密文被篡改
+	encrypted[len(encrypted)-1] ^= 0xff
+	if _, err = AesDecryptGCM(encrypted, key); err == nil {
+		t.Error("expected tampered ciphertext rejected")
+	}
+}
@@ -31,6 +31,15 @@ func GetTempPathFileName() (pathFileName string) {
 	return filepath.Join(os.TempDir(), fmt.Sprintf("%s.tmp", GetRandomString2(16)))
 }
 
+// GetMemTempPathFileName 获取一个位于内存文件系统（/dev/shm）的临时文件名，用于保存密钥等敏感内容；不支持时使用临时目录
+func GetMemTempPathFileName() (pathFileName string) {
+	dir := os.TempDir()
+	if fi, err := os.Stat("/dev/shm"); err == nil && fi.IsDir() {
+		dir = "/dev/shm"
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.tmp", GetRandomString2(16)))
+}
+
 // GetTempPNGPathFileName 获取一个临时文件名，后缀为PNG
 func GetTempPNGPathFileName() (pathFileName string) {
 	return filepath.Join(os.TempDir(), fmt.Sprintf("%s.png", GetRandomString2(16)))
@@ -153,7 +162,6 @@ type BinShortName string
 
 const (
 	ObserverWard BinShortName = "observer_ward"
-	MassDns      BinShortName = "massdns"
 	Xray         BinShortName = "xray"
 	Nuclei       BinShortName = "nuclei"
 	Worker       BinShortName = "worker"
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/db"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/notify"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
@@ -15,6 +16,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -56,6 +58,8 @@ type DefaultConfig struct {
 	IsScreenshot     bool `json:"screenshot" form:"screenshot"`
 	IsFingerprintHub bool `json:"fingerprinthub" form:"fingerprinthub"`
 	IsIconHash       bool `json:"iconhash" form:"iconhash"`
+	IsLeak           bool `json:"leak" form:"leak"`
+	IsWaf            bool `json:"waf" form:"waf"`
 	// onlineapi
 	IsFofa           bool   `json:"fofa" form:"fofa"`
 	IsQuake          bool   `json:"quake" form:"quake"`
@@ -74,6 +78,11 @@ type DefaultConfig struct {
 	IsSubDomainFinder  bool   `json:"subfinder" form:"subfinder"`
 	IsSubDomainBrute   bool   `json:"subdomainbrute" form:"subdomainbrute"`
 	IsSubDomainCrawler bool   `json:"subdomaincrawler" form:"subdomaincrawler"`
+	IsPermutation      bool   `json:"permutation" form:"permutation"`
+	IsTakeover         bool   `json:"takeover" form:"takeover"`
+	IsZoneTransfer     bool   `json:"zonetransfer" form:"zonetransfer"`
+	IsJSAnalysis       bool   `json:"jsanalysis" form:"jsanalysis"`
+	IsCDNOrigin        bool   `json:"cdnorigin" form:"cdnorigin"`
 	IsIgnoreCDN        bool   `json:"ignorecdn" form:"ignorecdn"`
 	IsIgnoreOutofChina bool   `json:"ignoreoutofchina" form:"ignoreoutofchina"`
 	IsPortscan         bool   `json:"portscan" form:"portscan"`
@@ -81,6 +90,23 @@ type DefaultConfig struct {
 	IsICP              bool   `json:"icp" form:"icp"`
 }
 
+type workerSecretRequestParam struct {
+	SecretName  string `form:"secret_name"`
+	ScopeType   string `form:"scope_type"`
+	ScopeValue  string `form:"scope_value"`
+	SecretValue string `form:"secret_value"`
+	Description string `form:"description"`
+}
+
+type WorkerSecretData struct {
+	Id             int    `json:"id"`
+	Index          int    `json:"index"`
+	SecretName     string `json:"secret_name"`
+	Scope          string `json:"scope"`
+	Description    string `json:"description"`
+	UpdateDatetime string `json:"update_time"`
+}
+
 func (c *ConfigController) IndexAction() {
 	c.Layout = "base.html"
 	c.TplName = "config.html"
@@ -123,6 +149,8 @@ func (c *ConfigController) LoadDefaultConfigAction() {
 		IsScreenshot:     fingerprint.IsScreenshot,
 		IsFingerprintHub: fingerprint.IsFingerprintHub,
 		IsIconHash:       fingerprint.IsIconHash,
+		IsLeak:           fingerprint.IsLeak,
+		IsWaf:            fingerprint.IsWaf,
 		//
 		ServerChanToken: notifyToken["serverchan"].Token,
 		DingTalkToken:   notifyToken["dingtalk"].Token,
@@ -137,6 +165,11 @@ func (c *ConfigController) LoadDefaultConfigAction() {
 		IsSubDomainFinder:  domainscan.IsSubDomainFinder,
 		IsSubDomainBrute:   domainscan.IsSubDomainBrute,
 		IsSubDomainCrawler: domainscan.IsSubdomainCrawler,
+		IsPermutation:      domainscan.IsPermutation,
+		IsTakeover:         domainscan.IsTakeover,
+		IsZoneTransfer:     domainscan.IsZoneTransfer,
+		IsJSAnalysis:       domainscan.IsJSAnalysis,
+		IsCDNOrigin:        domainscan.IsCDNOrigin,
 		IsIgnoreCDN:        domainscan.IsIgnoreCDN,
 		IsIgnoreOutofChina: domainscan.IsIgnoreOutofChina,
 		IsPortscan:         domainscan.IsPortScan,
@@ -446,6 +479,8 @@ func (c *ConfigController) SaveFingerprintAction() {
 	conf.GlobalWorkerConfig().Fingerprint.IsFingerprintHub = data.IsFingerprintHub
 	conf.GlobalWorkerConfig().Fingerprint.IsScreenshot = data.IsScreenshot
 	conf.GlobalWorkerConfig().Fingerprint.IsIconHash = data.IsIconHash
+	conf.GlobalWorkerConfig().Fingerprint.IsLeak = data.IsLeak
+	conf.GlobalWorkerConfig().Fingerprint.IsWaf = data.IsWaf
 	err = conf.GlobalWorkerConfig().WriteConfig()
 	if err != nil {
 		logging.RuntimeLog.Error("save config file error:", err)
@@ -478,6 +513,11 @@ func (c *ConfigController) SaveDomainscanAction() {
 	conf.GlobalWorkerConfig().Domainscan.IsSubDomainFinder = data.IsSubDomainFinder
 	conf.GlobalWorkerConfig().Domainscan.IsSubDomainBrute = data.IsSubDomainBrute
 	conf.GlobalWorkerConfig().Domainscan.IsSubdomainCrawler = data.IsSubDomainCrawler
+	conf.GlobalWorkerConfig().Domainscan.IsPermutation = data.IsPermutation
+	conf.GlobalWorkerConfig().Domainscan.IsTakeover = data.IsTakeover
+	conf.GlobalWorkerConfig().Domainscan.IsZoneTransfer = data.IsZoneTransfer
+	conf.GlobalWorkerConfig().Domainscan.IsJSAnalysis = data.IsJSAnalysis
+	conf.GlobalWorkerConfig().Domainscan.IsCDNOrigin = data.IsCDNOrigin
 	conf.GlobalWorkerConfig().Domainscan.IsIgnoreCDN = data.IsIgnoreCDN
 	conf.GlobalWorkerConfig().Domainscan.IsIgnoreOutofChina = data.IsIgnoreOutofChina
 	conf.GlobalWorkerConfig().Domainscan.IsPortScan = data.IsPortscan
@@ -616,6 +656,113 @@ func getCustomFilename(customType string) (customFile string) {
 	return
 }
 
+// WorkerSecretListAction 获取集中管理的worker密钥（不返回密钥的内容）
+func (c *ConfigController) WorkerSecretListAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	req := DatableRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+	}
+	resp := DataTableResponseData{}
+	ws := db.WorkerSecret{}
+	results, total := ws.Gets(make(map[string]interface{}), -1, -1)
+	for i, r := range results {
+		resp.Data = append(resp.Data, WorkerSecretData{
+			Id:             r.Id,
+			Index:          i + 1,
+			SecretName:     r.SecretName,
+			Scope:          getWorkerSecretScopeDescription(r.ScopeType, r.ScopeValue),
+			Description:    r.Description,
+			UpdateDatetime: FormatDateTime(r.UpdateDatetime),
+		})
+	}
+	resp.Draw = req.Draw
+	resp.RecordsTotal = total
+	resp.RecordsFiltered = total
+	c.Data["json"] = resp
+}
+
+// SaveWorkerSecretAction 保存worker密钥，相同名称及范围的密钥会被更新；worker在执行任务时获取，不需要文件同步
+func (c *ConfigController) SaveWorkerSecretAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	req := workerSecretRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		c.FailedStatus(err.Error())
+		return
+	}
+	if strings.TrimSpace(req.SecretValue) == "" {
+		c.FailedStatus("密钥内容为空！")
+		return
+	}
+	scopeValue := strings.TrimSpace(req.ScopeValue)
+	// 工作空间使用名称指定，保存为工作空间的ID
+	if req.ScopeType == comm.SecretScopeWorkspace {
+		workspace := db.Workspace{}
+		results, _ := workspace.Gets(map[string]interface{}{"workspace_name": scopeValue}, -1, -1)
+		if len(results) != 1 {
+			c.FailedStatus("工作空间不存在！")
+			return
+		}
+		scopeValue = strconv.Itoa(results[0].Id)
+	}
+	if err = comm.SaveWorkerSecret(req.SecretName, req.ScopeType, scopeValue, strings.TrimSpace(req.SecretValue), req.Description); err != nil {
+		logging.RuntimeLog.Error(err)
+		c.FailedStatus(err.Error())
+		return
+	}
+	c.SucceededStatus("保存密钥成功")
+}
+
+// DeleteWorkerSecretAction 删除worker密钥
+func (c *ConfigController) DeleteWorkerSecretAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin, Admin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	id, err := c.GetInt("id")
+	if err != nil {
+		c.FailedStatus(err.Error())
+		return
+	}
+	ws := db.WorkerSecret{Id: id}
+	if !ws.Delete() {
+		c.FailedStatus("删除密钥失败")
+		return
+	}
+	c.SucceededStatus("删除密钥成功")
+}
+
+// getWorkerSecretScopeDescription 密钥作用范围的显示
+func getWorkerSecretScopeDescription(scopeType, scopeValue string) string {
+	switch scopeType {
+	case comm.SecretScopeLabel:
+		return fmt.Sprintf("标签:%s", scopeValue)
+	case comm.SecretScopeWorkspace:
+		id, _ := strconv.Atoi(scopeValue)
+		workspace := db.Workspace{Id: id}
+		if workspace.Get() {
+			return fmt.Sprintf("工作空间:%s", workspace.WorkspaceName)
+		}
+		return fmt.Sprintf("工作空间:%s", scopeValue)
+	}
+	return "全局"
+}
+
 // testOnineAPI 多线程方式测试在线接口的可用性
 func testOnineAPI(apiName string, swg *sizedwaitgroup.SizedWaitGroup, testMsgChan chan string) {
 	defer swg.Done()
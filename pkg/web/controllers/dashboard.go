@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"github.com/hanc00l/nemo_go/pkg/comm"
 	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/filesync"
 	"github.com/hanc00l/nemo_go/pkg/logging"
 	"github.com/hanc00l/nemo_go/pkg/task/ampq"
 	"github.com/hanc00l/nemo_go/pkg/task/custom"
 	"github.com/hanc00l/nemo_go/pkg/task/runner"
 	"github.com/hanc00l/nemo_go/pkg/utils"
+	"math"
+	"sort"
 	"strings"
 	"time"
 )
@@ -34,6 +37,32 @@ type WorkerStatusData struct {
 	EnableManualReloadFlag   bool   `json:"enable_manual_reload_flag"`
 	EnableManualFileSyncFlag bool   `json:"enable_manual_file_sync_flag"`
 	HeartColor               string `json:"heart_color"`
+	Capability               string `json:"capability"`
+	EgressIP                 string `json:"egress_ip"`
+	Resource                 string `json:"resource"`
+	LowResource              bool   `json:"low_resource"`
+	FileSync                 string `json:"file_sync"`
+}
+
+// WorkerResourceHistoryData worker资源使用的历史数据，用于dashboard的趋势图
+type WorkerResourceHistoryData struct {
+	Time        []string  `json:"time"`
+	CPUPercent  []float64 `json:"cpu_percent"`
+	MemPercent  []float64 `json:"mem_percent"`
+	Load1       []float64 `json:"load1"`
+	NetSentRate []uint64  `json:"net_sent_rate"`
+	NetRecvRate []uint64  `json:"net_recv_rate"`
+}
+
+type WorkerCertData struct {
+	Id             int    `json:"id"`
+	Index          int    `json:"index"`
+	WorkerName     string `json:"worker_name"`
+	SerialNumber   string `json:"serial_number"`
+	Fingerprint    string `json:"fingerprint"`
+	NotAfter       string `json:"not_after"`
+	IsRevoked      bool   `json:"is_revoked"`
+	CreateDatetime string `json:"create_time"`
 }
 
 type TaskInfoData struct {
@@ -153,6 +182,7 @@ func (c *DashboardController) WorkerAliveListAction() {
 	for _, v := range comm.WorkerStatus {
 		if time.Now().Sub(v.UpdateTime).Minutes() > 5 {
 			delete(comm.WorkerStatus, v.WorkerName)
+			delete(comm.WorkerResourceHistory, v.WorkerName)
 			continue
 		}
 		wsd := WorkerStatusData{
@@ -163,6 +193,13 @@ func (c *DashboardController) WorkerAliveListAction() {
 			UpdateTime:         fmt.Sprintf("%s前", time.Now().Sub(v.UpdateTime).Truncate(time.Second).String()),
 			TaskExecutedNumber: v.TaskExecutedNumber,
 			HeartColor:         "green",
+			Capability:         c.getWorkerCapabilityDescription(v.Capability),
+			EgressIP:           v.Capability.EgressIP,
+			Resource:           c.getWorkerResourceDescription(v.Resource),
+			LowResource:        v.Resource.LowResource,
+		}
+		if progress, ok := comm.WorkerFileSyncProgress[comm.GetWorkerHostName(v.WorkerName)]; ok {
+			wsd.FileSync = c.getWorkerFileSyncDescription(progress)
 		}
 		workerHeartDt := time.Now().Sub(v.UpdateTime).Minutes()
 		daemonHeartDt := time.Now().Sub(v.WorkerDaemonUpdateTime).Minutes()
@@ -187,6 +224,30 @@ func (c *DashboardController) WorkerAliveListAction() {
 	c.Data["json"] = resp
 }
 
+// WorkerResourceHistoryAction 获取worker资源使用的历史数据
+func (c *DashboardController) WorkerResourceHistoryAction() {
+	defer c.ServeJSON()
+
+	worker := c.GetString("worker_name")
+	if worker == "" {
+		c.FailedStatus("worker name is empty")
+		return
+	}
+	comm.WorkerStatusMutex.Lock()
+	defer comm.WorkerStatusMutex.Unlock()
+
+	data := WorkerResourceHistoryData{}
+	for _, sample := range comm.WorkerResourceHistory[worker] {
+		data.Time = append(data.Time, sample.Time.Format("15:04"))
+		data.CPUPercent = append(data.CPUPercent, math.Round(sample.CPUPercent*10)/10)
+		data.MemPercent = append(data.MemPercent, math.Round(sample.MemUsedPercent*10)/10)
+		data.Load1 = append(data.Load1, math.Round(sample.Load1*100)/100)
+		data.NetSentRate = append(data.NetSentRate, sample.NetSentRate)
+		data.NetRecvRate = append(data.NetRecvRate, sample.NetRecvRate)
+	}
+	c.Data["json"] = data
+}
+
 // ManualReloadWorkerAction 重启worker
 func (c *DashboardController) ManualReloadWorkerAction() {
 	defer c.ServeJSON()
@@ -276,6 +337,73 @@ func (c *DashboardController) OnlineUserListAction() {
 	c.Data["json"] = resp
 }
 
+// WorkerCertListAction 获取已注册的worker证书
+func (c *DashboardController) WorkerCertListAction() {
+	defer c.ServeJSON()
+
+	req := DatableRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+	}
+	resp := DataTableResponseData{}
+	wc := db.WorkerCert{}
+	results, total := wc.Gets(make(map[string]interface{}), -1, -1)
+	for i, r := range results {
+		resp.Data = append(resp.Data, WorkerCertData{
+			Id:             r.Id,
+			Index:          i + 1,
+			WorkerName:     r.WorkerName,
+			SerialNumber:   r.SerialNumber,
+			Fingerprint:    r.Fingerprint,
+			NotAfter:       FormatDateTime(r.NotAfter),
+			IsRevoked:      r.IsRevoked,
+			CreateDatetime: FormatDateTime(r.CreateDatetime),
+		})
+	}
+	resp.Draw = req.Draw
+	resp.RecordsTotal = total
+	resp.RecordsFiltered = total
+	c.Data["json"] = resp
+}
+
+// NewWorkerEnrollTokenAction 生成worker的注册令牌
+func (c *DashboardController) NewWorkerEnrollTokenAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	token, err := comm.NewEnrollToken()
+	if err != nil {
+		c.FailedStatus(err.Error())
+		return
+	}
+	c.SucceededStatus(token)
+}
+
+// RevokeWorkerCertAction 吊销worker证书
+func (c *DashboardController) RevokeWorkerCertAction() {
+	defer c.ServeJSON()
+	if c.CheckMultiAccessRequest([]RequestRole{SuperAdmin}, false) == false {
+		c.FailedStatus("当前用户权限不允许！")
+		return
+	}
+
+	id, err := c.GetInt("id")
+	if err != nil {
+		c.FailedStatus(err.Error())
+		return
+	}
+	if !comm.RevokeWorkerCert(id) {
+		c.FailedStatus("吊销证书失败")
+		return
+	}
+	c.SucceededStatus("已吊销worker证书！")
+}
+
 func (c *DashboardController) getWorkerTopicDescription(taskMode string) string {
 	var modeNameMap = map[string]string{
 		"default": "全部任务",
@@ -286,9 +414,18 @@ func (c *DashboardController) getWorkerTopicDescription(taskMode string) string
 		"custom":  "自定义任务",
 	}
 	workerTopicDescription := make(map[string]struct{})
-	topicsArray := strings.Split(taskMode, ",")
+	var topicsArray, labels []string
+	for _, v := range strings.Split(taskMode, ",") {
+		// worker标签的队列单独显示
+		if strings.HasPrefix(v, ampq.TopicLabel+".") {
+			labels = append(labels, fmt.Sprintf("标签:%s", strings.TrimPrefix(v, ampq.TopicLabel+".")))
+		} else {
+			topicsArray = append(topicsArray, v)
+		}
+	}
+	sort.Strings(labels)
 	if len(topicsArray) >= 4 {
-		return modeNameMap["default"]
+		return strings.Join(append([]string{modeNameMap["default"]}, labels...), ",")
 	}
 	for _, v := range topicsArray {
 		if modeName, ok := modeNameMap[v]; ok {
@@ -299,5 +436,66 @@ func (c *DashboardController) getWorkerTopicDescription(taskMode string) string
 			return "未知模式"
 		}
 	}
+	if len(labels) > 0 {
+		return strings.Join(append([]string{utils.SetToString(workerTopicDescription)}, labels...), ",")
+	}
 	return utils.SetToString(workerTopicDescription)
 }
+
+// getWorkerCapabilityDescription worker的能力描述
+func (c *DashboardController) getWorkerCapabilityDescription(capability ampq.WorkerCapability) string {
+	var items []string
+	for name, version := range capability.Items {
+		items = append(items, fmt.Sprintf("%s(%s)", name, version))
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// getWorkerResourceDescription worker的资源使用描述
+func (c *DashboardController) getWorkerResourceDescription(resource ampq.WorkerResource) string {
+	if resource.MemTotal == 0 {
+		return ""
+	}
+	description := fmt.Sprintf("CPU:%.1f%%(%d核),内存:%.1f%%(%.1fG),负载:%.2f,网络:%s/s↑ %s/s↓",
+		resource.CPUPercent, resource.CPUNumber,
+		resource.MemUsedPercent, float64(resource.MemTotal)/1024/1024/1024,
+		resource.Load1,
+		formatBytes(resource.NetSentRate), formatBytes(resource.NetRecvRate))
+	if resource.MaxRunningTask > 0 {
+		description += fmt.Sprintf(",执行任务:%d/%d", resource.RunningTask, resource.MaxRunningTask)
+	}
+	return description
+}
+
+// getWorkerFileSyncDescription worker文件同步进度的显示，同步完成10分钟后不再显示
+func (c *DashboardController) getWorkerFileSyncDescription(progress filesync.SyncProgress) string {
+	transfer := fmt.Sprintf("传输%s(共%s)", formatBytes(uint64(progress.TransferredBytes)), formatBytes(uint64(progress.ReceivedBytes)))
+	if progress.FailedFiles > 0 {
+		transfer += fmt.Sprintf(",失败%d", progress.FailedFiles)
+	}
+	if progress.IsFinished {
+		if time.Now().Sub(progress.UpdateTime).Minutes() > 10 {
+			return ""
+		}
+		return fmt.Sprintf("文件同步完成:%d个文件,%s", progress.DoneFiles, transfer)
+	}
+	if time.Now().Sub(progress.UpdateTime).Minutes() > 1 {
+		return fmt.Sprintf("文件同步中断:%d/%d,%s", progress.DoneFiles, progress.TotalFiles, transfer)
+	}
+	return fmt.Sprintf("文件同步中:%d/%d,%s", progress.DoneFiles, progress.TotalFiles, transfer)
+}
+
+// formatBytes 字节数转换为K、M、G的表示
+func formatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%dB", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit && exp < 2; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(bytes)/float64(div), "KMG"[exp])
+}
@@ -63,6 +63,7 @@ type DomainListData struct {
 	DomainCDN      string   `json:"domaincdn"`
 	DomainCNAME    string   `json:"domaincname"`
 	IsIPCDN        bool     `json:"ipcdn"`
+	Waf            string   `json:"waf"`
 	IconImage      []string `json:"iconimage"`
 	WorkspaceId    int      `json:"workspace"`
 	WorkspaceGUID  string   `json:"workspace_guid"`
@@ -93,6 +94,7 @@ type DomainInfo struct {
 	TlsData       []string
 	DomainCDN     string
 	DomainCNAME   string
+	Waf           []string
 	Workspace     string
 	WorkspaceGUID string
 	PinIndex      string
@@ -121,6 +123,7 @@ type DomainAttrFullInfo struct {
 	IconImageSet  map[string]string
 	TlsData       map[string]struct{}
 	SourceSet     map[string]struct{}
+	WafSet        map[string]struct{}
 	DomainCDN     string
 	DomainCNAME   string
 }
@@ -585,6 +588,7 @@ func (c *DomainController) getDomainListData(req domainRequestParam) (resp DataT
 		domainData.Vulnerability = strings.Join(vulSet, "\r\n")
 		domainData.DomainCDN = domainInfo.DomainCDN
 		domainData.DomainCNAME = domainInfo.DomainCNAME
+		domainData.Waf = strings.Join(domainInfo.Waf, ",")
 		for _, ip := range domainData.IP {
 			if cdn.CheckIP(ip) || cdn.CheckASN(ip) {
 				domainData.IsIPCDN = true
@@ -729,6 +733,7 @@ func getDomainInfo(domain *db.Domain, portInfoCacheMap map[int]PortInfo, disable
 	r.TlsData = utils.SetToSlice(domainAttrInfo.TlsData)
 	r.DomainCDN = domainAttrInfo.DomainCDN
 	r.DomainCNAME = domainAttrInfo.DomainCNAME
+	r.Waf = utils.SetToSlice(domainAttrInfo.WafSet)
 	for hash, image := range domainAttrInfo.IconImageSet {
 		r.IconHashes = append(r.IconHashes, IconHashWithFofa{
 			IconHash:  hash,
@@ -764,6 +769,7 @@ func getDomainAttrFullInfo(workspaceGUID string, id int, disableFofa, disableBan
 		TlsData:       make(map[string]struct{}),
 		IconImageSet:  make(map[string]string),
 		SourceSet:     make(map[string]struct{}),
+		WafSet:        make(map[string]struct{}),
 		FingerSet:     make(map[string]struct{}),
 		StatusCodeSet: make(map[string]struct{}),
 	}
@@ -806,7 +812,12 @@ func getDomainAttrFullInfo(workspaceGUID string, id int, disableFofa, disableBan
 					UpdateTime: FormatDateTime(da.UpdateDatetime),
 				})
 			}
-		} else if da.Tag == "httpx" {
+		} else if da.Tag == "httpx" || da.Tag == "vhost" || da.Tag == "origin" || da.Tag == "waf" || strings.HasPrefix(da.Tag, "js_") {
+			if da.Tag == "waf" {
+				if _, ok := r.WafSet[da.Content]; !ok {
+					r.WafSet[da.Content] = struct{}{}
+				}
+			}
 			r.DomainAttr = append(r.DomainAttr, DomainAttrInfo{
 				Id:         da.Id,
 				Tag:        da.Tag,
@@ -1042,6 +1053,25 @@ func (c *DomainController) ExportDomainResultAction() {
 	http.ServeContent(rw, c.Ctx.Request, "domain-result.csv", time.Now(), bytes.NewReader(content))
 }
 
+// ExportDomainJSONLAction 按JSON Lines格式导出域名资产，包括属性、HTTP信息、截图及漏洞
+func (c *DomainController) ExportDomainJSONLAction() {
+	req := domainRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+		return
+	}
+	c.validateRequestParam(&req)
+	domain := db.Domain{}
+	domainResults, _ := domain.Gets(c.getSearchMap(req), -1, -1, req.OrderByDate)
+	e := newJSONLExporter()
+	for i := range domainResults {
+		e.addDomain(&domainResults[i])
+	}
+	serveJSONLContent(&c.BaseController, "domain-result.jsonl", e.content())
+}
+
 // getDomainExportData 获取域名输出数据
 func (c *DomainController) getDomainExportData(req domainRequestParam) (result []DomainExportInfo) {
 	domain := db.Domain{}
@@ -49,6 +49,10 @@ type ipRequestParam struct {
 	SelectNoOpenedPort    bool   `form:"select_no_openedport"`
 	OrderByDate           bool   `form:"select_order_by_date"`
 	IpHttp                string `form:"ip_http"`
+	ASN                   string `form:"asn"`
+	Country               string `form:"country"`
+	Region                string `form:"region"`
+	City                  string `form:"city"`
 }
 
 // IPListData 列表中每一行显示的IP数据
@@ -57,6 +61,8 @@ type IPListData struct {
 	Index          int      `json:"index"`
 	IP             string   `json:"ip"`
 	Location       string   `json:"location"`
+	ASN            string   `json:"asn"`
+	ASName         string   `json:"as_name"`
 	Port           []string `json:"port"`
 	Title          string   `json:"title"`
 	Banner         string   `json:"banner"`
@@ -64,8 +70,10 @@ type IPListData struct {
 	MemoContent    string   `json:"memo_content"`
 	Vulnerability  string   `json:"vulnerability"`
 	HoneyPot       string   `json:"honeypot"`
+	HoneyPotScore  int      `json:"honeypot_score"`
 	ScreenshotFile []string `json:"screenshot"`
 	IsCDN          bool     `json:"cdn"`
+	Waf            string   `json:"waf"`
 	IconImage      []string `json:"iconimage"`
 	WorkspaceId    int      `json:"workspace"`
 	WorkspaceGUID  string   `json:"workspace_guid"`
@@ -85,6 +93,11 @@ type IPInfo struct {
 	Organization  string
 	Status        string
 	Location      string
+	ASN           string
+	ASName        string
+	ASPrefix      string
+	HoneyPotScore int
+	HoneyPot      string
 	Port          []int
 	Title         []string
 	Banner        []string
@@ -135,6 +148,7 @@ type PortInfo struct {
 	PortAttr         []PortAttrInfo
 	IconHashImageSet map[string]string
 	TlsDataSet       map[string]struct{}
+	WafSet           map[string]struct{}
 }
 
 // IPStatisticInfo IP统计信息
@@ -473,8 +487,8 @@ func (c *IPController) ImportPortscanResultAction() {
 		}
 		// 文件后缀检查
 		ext := path.Ext(fileHeader.Filename)
-		if ext != ".json" && ext != ".xml" && ext != ".txt" && ext != ".csv" && ext != ".dat" {
-			c.FailedStatus("只允许.json、.xml、.csv、.dat或.txt文件")
+		if ext != ".json" && ext != ".jsonl" && ext != ".xml" && ext != ".txt" && ext != ".csv" && ext != ".dat" && ext != ".html" && ext != ".nessus" {
+			c.FailedStatus("只允许.json、.jsonl、.xml、.csv、.dat、.html、.nessus或.txt文件")
 			return
 		}
 		// 读取文件内容
@@ -494,7 +508,7 @@ func (c *IPController) ImportPortscanResultAction() {
 		config.OrgId = nil
 	}
 	var result string
-	if bin == "nmap" || bin == "masscan" || bin == "fscan" || bin == "gogo" {
+	if bin == "nmap" || bin == "masscan" || bin == "fscan" || bin == "gogo" || bin == "naabu" || bin == "rustscan" || bin == "nessus" || bin == "openvas" {
 		// 导入IP资产
 		i := portscan.NewImportOfflineResult(bin)
 		i.Parse(fileContent)
@@ -502,12 +516,22 @@ func (c *IPController) ImportPortscanResultAction() {
 		resultIpPort := i.IpResult.SaveResult(config)
 		result = fmt.Sprintf("%s", resultIpPort)
 		// 导入漏洞资产
-		if bin == "fscan" || bin == "gogo" {
+		if bin == "fscan" || bin == "gogo" || bin == "nessus" || bin == "openvas" {
 			v := pocscan.NewImportOfflineResult(bin, workspaceId)
 			v.Parse(fileContent)
 			resultVul := pocscan.SaveResult(v.VulResult)
 			result = fmt.Sprintf("%s,%s", resultIpPort, resultVul)
 		}
+	} else if bin == "nuclei" || bin == "xray" {
+		// 只导入漏洞资产
+		v := pocscan.NewImportOfflineResult(bin, workspaceId)
+		v.Parse(fileContent)
+		result = pocscan.SaveResult(v.VulResult)
+	} else if bin == "subfinder" || bin == "amass" || bin == "oneforall" {
+		// 导入子域名资产
+		d := domainscan.NewImportOfflineResult(bin)
+		d.Parse(fileContent)
+		result = d.DomainResult.SaveResult(domainscan.Config{OrgId: config.OrgId, WorkspaceId: workspaceId})
 	} else if bin == "httpx" {
 		i := portscan.NewImportOfflineResultWithInterface("httpx", new(fingerprint.Httpx))
 		i.Parse(fileContent)
@@ -522,6 +546,9 @@ func (c *IPController) ImportPortscanResultAction() {
 		resultIpPort := s.IpResult.SaveResult(config)
 		resultDomain := s.DomainResult.SaveResult(domainscan.Config{OrgId: config.OrgId, WorkspaceId: workspaceId})
 		result = fmt.Sprintf("%s,%s", resultDomain, resultIpPort)
+	} else if bin == "jsonl" {
+		// 导入nemo的JSON Lines格式的资产、漏洞及截图
+		result = saveJSONLResult(fileContent, workspaceId, config.OrgId)
 	} else {
 		c.FailedStatus("未知的扫描方法")
 		return
@@ -583,6 +610,20 @@ func (c *IPController) getSearchMap(req ipRequestParam) (searchMap map[string]in
 	if req.IpHttp != "" {
 		searchMap["ip_http"] = req.IpHttp
 	}
+	if req.ASN != "" {
+		if asn, ok := custom.ParseASN(req.ASN); ok {
+			searchMap["asn"] = custom.FormatASN(asn)
+		}
+	}
+	if req.Country != "" {
+		searchMap["country"] = req.Country
+	}
+	if req.Region != "" {
+		searchMap["region"] = req.Region
+	}
+	if req.City != "" {
+		searchMap["city"] = req.City
+	}
 	return searchMap
 }
 
@@ -617,6 +658,8 @@ func (c *IPController) GetIPListData(req ipRequestParam) (resp DataTableResponse
 		if req.SelectNoOpenedPort && len(ipInfo.Port) > 0 {
 			continue
 		}
+		ipData.ASN = ipInfo.ASN
+		ipData.ASName = ipInfo.ASName
 		ipData.ColorTag = ipInfo.ColorTag
 		ipData.MemoContent = ipInfo.Memo
 		ipData.Title = strings.Join(ipInfo.Title, ", ")
@@ -661,9 +704,15 @@ func (c *IPController) GetIPListData(req ipRequestParam) (resp DataTableResponse
 		if isHoneypot && len(systemList) > 0 {
 			ipData.HoneyPot = strings.Join(systemList, "\n")
 		}
+		// 根据端口信息评分的蜜罐
+		ipData.HoneyPotScore = ipInfo.HoneyPotScore
+		if ipData.HoneyPot == "" && ipInfo.HoneyPotScore >= custom.HoneyPotScoreThreshold {
+			ipData.HoneyPot = fmt.Sprintf("score:%d\n%s", ipInfo.HoneyPotScore, ipInfo.HoneyPot)
+		}
 		if cdn.CheckIP(ipRow.IpName) || cdn.CheckASN(ipRow.IpName) {
 			ipData.IsCDN = true
 		}
+		ipData.Waf = utils.SetToString(ipPortInfo.WafSet)
 		resp.Data = append(resp.Data, ipData)
 	}
 	resp.Draw = req.Draw
@@ -682,6 +731,7 @@ func getPortInfo(workspaceGUID string, ip string, ipId int, disableFofa, disable
 	r.TitleSet = make(map[string]struct{})
 	r.TlsDataSet = make(map[string]struct{})
 	r.IconHashImageSet = make(map[string]string)
+	r.WafSet = make(map[string]struct{})
 
 	port := db.Port{IpId: ipId}
 	portData := port.GetsByIPId()
@@ -754,6 +804,10 @@ func getPortInfo(workspaceGUID string, ip string, ipId int, disableFofa, disable
 				if _, ok := r.TlsDataSet[pad.Content]; !ok {
 					r.TlsDataSet[pad.Content] = struct{}{}
 				}
+			} else if pad.Tag == "waf" {
+				if _, ok := r.WafSet[pad.Content]; !ok {
+					r.WafSet[pad.Content] = struct{}{}
+				}
 			}
 		}
 		// http header info
@@ -815,6 +869,23 @@ func getIPInfo(ip *db.Ip, getReleatedDomain, disableFofa, disableBanner bool) (r
 			r.Organization = org.OrgName
 		}
 	}
+	// asn：按更新时间倒序，取最新的属性
+	ipAttr := db.IpAttr{RelatedId: ip.Id}
+	for _, attr := range ipAttr.GetsByRelatedId() {
+		switch {
+		case attr.Tag == "asn" && r.ASN == "":
+			r.ASN = attr.Content
+		case attr.Tag == "as_name" && r.ASName == "":
+			r.ASName = attr.Content
+		case attr.Tag == "as_prefix" && r.ASPrefix == "":
+			r.ASPrefix = attr.Content
+		case attr.Tag == "honeypot":
+			if score, ok := custom.ParseHoneyPotScore(attr.Content); ok {
+				r.HoneyPotScore = score.Score
+				r.HoneyPot = strings.Join(score.Reasons, "\n")
+			}
+		}
+	}
 	// port
 	portInfo := getPortInfo(r.WorkspaceGUID, ip.IpName, ip.Id, disableFofa, disableBanner)
 	r.PortAttr = portInfo.PortAttr
@@ -988,6 +1059,49 @@ func (c *IPController) BlackIPAction() {
 	c.SucceededStatus("success")
 }
 
+// ExpandASNAction 获取组织的IP（或指定的ASN）所属ASN宣告的全部网段，作为端口扫描的目标
+func (c *IPController) ExpandASNAction() {
+	defer c.ServeJSON()
+
+	var asnList []string
+	if asnText := c.GetString("asn"); asnText != "" {
+		for _, s := range strings.Split(asnText, ",") {
+			if asn, ok := custom.ParseASN(s); ok {
+				asnList = append(asnList, custom.FormatASN(asn))
+			}
+		}
+	} else {
+		orgId, err := c.GetInt("org_id", 0)
+		if err != nil || orgId <= 0 {
+			c.FailedStatus("请选择组织机构或输入ASN！")
+			return
+		}
+		searchMap := map[string]interface{}{"org_id": orgId}
+		if workspaceId := c.GetCurrentWorkspace(); workspaceId > 0 {
+			searchMap["workspace_id"] = workspaceId
+		}
+		ipAttr := db.IpAttr{}
+		asnList = ipAttr.GetsDistinctContentByIp("asn", searchMap)
+	}
+	if len(asnList) == 0 {
+		c.FailedStatus("未找到IP的ASN信息！")
+		return
+	}
+	asnQuery := custom.GetASNQuery()
+	var targets []string
+	for _, s := range asnList {
+		asn, _ := custom.ParseASN(s)
+		prefixes := asnQuery.FindPrefix(asn)
+		logging.RuntimeLog.Infof("expand %s get %d prefixes", s, len(prefixes))
+		targets = append(targets, prefixes...)
+	}
+	if len(targets) == 0 {
+		c.FailedStatus(fmt.Sprintf("未找到%s宣告的网段！", strings.Join(asnList, ",")))
+		return
+	}
+	c.SucceededStatus(strings.Join(targets, "\n"))
+}
+
 // ExportIPResultAction 导出IP资产
 func (c *IPController) ExportIPResultAction() {
 	req := ipRequestParam{}
@@ -1007,6 +1121,25 @@ func (c *IPController) ExportIPResultAction() {
 	http.ServeContent(rw, c.Ctx.Request, "ip-result.csv", time.Now(), bytes.NewReader(content))
 }
 
+// ExportIPJSONLAction 按JSON Lines格式导出IP资产，包括端口、属性、HTTP信息、截图及漏洞
+func (c *IPController) ExportIPJSONLAction() {
+	req := ipRequestParam{}
+	err := c.ParseForm(&req)
+	if err != nil {
+		logging.RuntimeLog.Error(err)
+		logging.CLILog.Error(err)
+		return
+	}
+	c.validateRequestParam(&req)
+	ip := db.Ip{}
+	ipResult, _ := ip.Gets(c.getSearchMap(req), -1, -1, req.OrderByDate)
+	e := newJSONLExporter()
+	for i := range ipResult {
+		e.addIP(&ipResult[i])
+	}
+	serveJSONLContent(&c.BaseController, "ip-result.jsonl", e.content())
+}
+
 // getIPExportData 获取IP的资产
 func (c *IPController) getIPExportData(req ipRequestParam) (result []IPExportInfo) {
 	ip := db.Ip{}
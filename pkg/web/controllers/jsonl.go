@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hanc00l/nemo_go/pkg/conf"
+	"github.com/hanc00l/nemo_go/pkg/db"
+	"github.com/hanc00l/nemo_go/pkg/logging"
+	"github.com/hanc00l/nemo_go/pkg/task/domainscan"
+	"github.com/hanc00l/nemo_go/pkg/task/fingerprint"
+	"github.com/hanc00l/nemo_go/pkg/task/jsonl"
+	"github.com/hanc00l/nemo_go/pkg/task/pocscan"
+	"github.com/hanc00l/nemo_go/pkg/task/portscan"
+	"github.com/hanc00l/nemo_go/pkg/utils"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonlExporter 将资产按JSON Lines格式输出，截图及漏洞按IP或域名关联输出
+type jsonlExporter struct {
+	buf        bytes.Buffer
+	writer     *jsonl.Writer
+	ss         *fingerprint.ScreenShot
+	guidCache  map[int]string
+	ipResult   portscan.Result
+	domainRes  domainscan.Result
+	vulResult  []pocscan.Result
+	screenshot []fingerprint.ScreenshotFileInfo
+}
+
+// newJSONLExporter 创建资产的JSON Lines输出
+func newJSONLExporter() *jsonlExporter {
+	e := &jsonlExporter{
+		ss:        fingerprint.NewScreenShot(),
+		guidCache: make(map[int]string),
+		ipResult:  portscan.Result{IPResult: make(map[string]*portscan.IPResult)},
+		domainRes: domainscan.Result{DomainResult: make(map[string]*domainscan.DomainResult)},
+	}
+	e.writer = jsonl.NewWriter(&e.buf)
+	return e
+}
+
+// getWorkspaceGUID 获取工作空间的GUID（截图保存的目录）
+func (e *jsonlExporter) getWorkspaceGUID(workspaceId int) string {
+	if guid, ok := e.guidCache[workspaceId]; ok {
+		return guid
+	}
+	workspace := db.Workspace{Id: workspaceId}
+	if workspace.Get() {
+		e.guidCache[workspaceId] = workspace.WorkspaceGUID
+	} else {
+		e.guidCache[workspaceId] = ""
+	}
+	return e.guidCache[workspaceId]
+}
+
+// addIP 添加IP及端口、属性、HTTP信息
+func (e *jsonlExporter) addIP(ipRow *db.Ip) {
+	e.ipResult.SetIP(ipRow.IpName)
+	e.ipResult.IPResult[ipRow.IpName].Location = ipRow.Location
+	e.ipResult.IPResult[ipRow.IpName].Status = ipRow.Status
+	port := db.Port{IpId: ipRow.Id}
+	for _, pd := range port.GetsByIPId() {
+		e.ipResult.SetPort(ipRow.IpName, pd.PortNum)
+		e.ipResult.IPResult[ipRow.IpName].Ports[pd.PortNum].Status = pd.Status
+		portAttr := db.PortAttr{RelatedId: pd.Id}
+		for _, pad := range portAttr.GetsByRelatedId() {
+			e.ipResult.SetPortAttr(ipRow.IpName, pd.PortNum, portscan.PortAttrResult{Source: pad.Source, Tag: pad.Tag, Content: pad.Content})
+		}
+		ipHttp := db.IpHttp{RelatedId: pd.Id}
+		for _, hd := range ipHttp.GetsByRelatedId() {
+			e.ipResult.SetPortHttpInfo(ipRow.IpName, pd.PortNum, portscan.HttpResult{Source: hd.Source, Tag: hd.Tag, Content: hd.Content})
+		}
+	}
+	e.addScreenshotAndVul(ipRow.WorkspaceId, ipRow.IpName)
+}
+
+// addDomain 添加域名及属性、HTTP信息
+func (e *jsonlExporter) addDomain(domainRow *db.Domain) {
+	e.domainRes.SetDomain(domainRow.DomainName)
+	domainAttr := db.DomainAttr{RelatedId: domainRow.Id}
+	for _, dar := range domainAttr.GetsByRelatedId() {
+		e.domainRes.SetDomainAttr(domainRow.DomainName, domainscan.DomainAttrResult{Source: dar.Source, Tag: dar.Tag, Content: dar.Content})
+	}
+	domainHttp := db.DomainHttp{RelatedId: domainRow.Id}
+	for _, hd := range domainHttp.GetsByRelatedId() {
+		e.domainRes.SetHttpInfo(domainRow.DomainName, domainscan.HttpResult{Port: hd.Port, Source: hd.Source, Tag: hd.Tag, Content: hd.Content})
+	}
+	e.addScreenshotAndVul(domainRow.WorkspaceId, domainRow.DomainName)
+}
+
+// addScreenshotAndVul 添加IP或域名的截图及同一工作空间中的漏洞
+func (e *jsonlExporter) addScreenshotAndVul(workspaceId int, target string) {
+	if guid := e.getWorkspaceGUID(workspaceId); guid != "" {
+		for _, f := range e.ss.LoadScreenshotFile(guid, target) {
+			// 截图的文件名为：端口_协议.png
+			portProtocol := strings.SplitN(strings.TrimSuffix(f, ".png"), "_", 2)
+			if len(portProtocol) != 2 {
+				continue
+			}
+			port, err := strconv.Atoi(portProtocol[0])
+			if err != nil {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(conf.GlobalServerConfig().Web.WebFiles, guid, "screenshot", target, f))
+			if err != nil {
+				logging.RuntimeLog.Error(err)
+				continue
+			}
+			e.screenshot = append(e.screenshot, fingerprint.ScreenshotFileInfo{Domain: target, Port: port, Protocol: portProtocol[1], Content: content})
+		}
+	}
+	vul := db.Vulnerability{Target: target}
+	for _, v := range vul.GetsByTarget() {
+		if v.WorkspaceId != workspaceId {
+			continue
+		}
+		e.vulResult = append(e.vulResult, pocscan.Result{Target: v.Target, Url: v.Url, PocFile: v.PocFile, Source: v.Source, Extra: v.Extra})
+	}
+}
+
+// content 输出JSON Lines格式的内容
+func (e *jsonlExporter) content() []byte {
+	e.writer.WriteIPResult(&e.ipResult)
+	e.writer.WriteDomainResult(&e.domainRes)
+	e.writer.WriteVulnerability(e.vulResult)
+	e.writer.WriteScreenshot(e.screenshot)
+	return e.buf.Bytes()
+}
+
+// serveJSONLContent 下载JSON Lines格式的文件
+func serveJSONLContent(c *BaseController, fileName string, content []byte) {
+	rw := c.Ctx.ResponseWriter
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	rw.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	rw.WriteHeader(http.StatusOK)
+
+	http.ServeContent(rw, c.Ctx.Request, fileName, time.Now(), bytes.NewReader(content))
+}
+
+// saveJSONLResult 解析并保存JSON Lines格式的资产、漏洞及截图到指定的工作空间
+func saveJSONLResult(content []byte, workspaceId int, orgId *int) string {
+	r := jsonl.Parse(content)
+	portscan.FilterIPHasTooMuchPort(&r.IPResult, false)
+	resultIpPort := r.IPResult.SaveResult(portscan.Config{OrgId: orgId, WorkspaceId: workspaceId})
+	resultDomain := r.DomainResult.SaveResult(domainscan.Config{OrgId: orgId, WorkspaceId: workspaceId})
+	for i := range r.VulResult {
+		r.VulResult[i].WorkspaceId = workspaceId
+	}
+	resultVul := pocscan.SaveResult(r.VulResult)
+	var screenshotCount int
+	if len(r.Screenshot) > 0 {
+		workspace := db.Workspace{Id: workspaceId}
+		if workspace.Get() {
+			screenshotPath := filepath.Join(conf.GlobalServerConfig().Web.WebFiles, workspace.WorkspaceGUID, "screenshot")
+			if utils.MakePath(screenshotPath) {
+				screenshotCount = fingerprint.NewScreenShot().SaveFile(screenshotPath, r.Screenshot)
+			} else {
+				logging.RuntimeLog.Error("创建保存screenshot的目录失败！")
+			}
+		}
+	}
+	result := fmt.Sprintf("%s,%s,%s,screenshot:%d", resultDomain, resultIpPort, resultVul, screenshotCount)
+	if r.InvalidLines > 0 {
+		result = fmt.Sprintf("%s,invalid:%d", result, r.InvalidLines)
+	}
+	return result
+}
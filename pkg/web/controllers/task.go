@@ -386,6 +386,10 @@ func (c *TaskController) StartPortScanTaskAction() {
 		c.FailedStatus(err.Error())
 		return
 	}
+	if req.WorkerLabel != "" && !ampq.CheckWorkerLabel(req.WorkerLabel) {
+		c.FailedStatus("worker标签格式错误")
+		return
+	}
 	if req.Target == "" {
 		c.FailedStatus("no target")
 		return
@@ -438,6 +442,10 @@ func (c *TaskController) StartBatchScanTaskAction() {
 		c.FailedStatus(err.Error())
 		return
 	}
+	if req.WorkerLabel != "" && !ampq.CheckWorkerLabel(req.WorkerLabel) {
+		c.FailedStatus("worker标签格式错误")
+		return
+	}
 	if req.Target == "" {
 		c.FailedStatus("no target")
 		return
@@ -488,6 +496,10 @@ func (c *TaskController) StartDomainScanTaskAction() {
 		c.FailedStatus(err.Error())
 		return
 	}
+	if req.WorkerLabel != "" && !ampq.CheckWorkerLabel(req.WorkerLabel) {
+		c.FailedStatus("worker标签格式错误")
+		return
+	}
 	if req.Target == "" {
 		c.FailedStatus("no target")
 		return
@@ -538,6 +550,10 @@ func (c *TaskController) StartPocScanTaskAction() {
 		c.FailedStatus(err.Error())
 		return
 	}
+	if req.WorkerLabel != "" && !ampq.CheckWorkerLabel(req.WorkerLabel) {
+		c.FailedStatus("worker标签格式错误")
+		return
+	}
 	// 格式化Target
 	if req.Target == "" {
 		c.FailedStatus("no target")
@@ -588,6 +604,10 @@ func (c *TaskController) StartXScanTaskAction() {
 		c.FailedStatus(err.Error())
 		return
 	}
+	if reqByForm.WorkerLabel != "" && !ampq.CheckWorkerLabel(reqByForm.WorkerLabel) {
+		c.FailedStatus("worker标签格式错误")
+		return
+	}
 	if !reqByForm.IsXrayPocscan {
 		reqByForm.XrayPocFile = ""
 	}
@@ -26,6 +26,9 @@ func init() {
 	web.CtrlPost("/config-test-api", (*controllers.ConfigController).TestOnlineAPIKeyAction)
 	web.CtrlPost("/config-test-notify", (*controllers.ConfigController).TestTaskNotifyAction)
 	web.CtrlPost("/config-save-domainscan", (*controllers.ConfigController).SaveDomainscanAction)
+	web.CtrlPost("/config-worker-secret-list", (*controllers.ConfigController).WorkerSecretListAction)
+	web.CtrlPost("/config-worker-secret-save", (*controllers.ConfigController).SaveWorkerSecretAction)
+	web.CtrlPost("/config-worker-secret-delete", (*controllers.ConfigController).DeleteWorkerSecretAction)
 	web.CtrlPost("/custom-save-taskworkspace", (*controllers.ConfigController).SaveCustomTaskWorkspaceConfigAction)
 
 	web.CtrlGet("/dashboard", (*controllers.DashboardController).IndexAction)
@@ -36,6 +39,10 @@ func init() {
 	web.CtrlPost("/dashboard-task-started-info", (*controllers.DashboardController).GetStartedTaskInfoAction)
 	web.CtrlPost("/worker-reload", (*controllers.DashboardController).ManualReloadWorkerAction)
 	web.CtrlPost("/worker-filesync", (*controllers.DashboardController).ManualWorkerFileSyncAction)
+	web.CtrlPost("/worker-resource-history", (*controllers.DashboardController).WorkerResourceHistoryAction)
+	web.CtrlPost("/worker-cert-list", (*controllers.DashboardController).WorkerCertListAction)
+	web.CtrlPost("/worker-cert-token", (*controllers.DashboardController).NewWorkerEnrollTokenAction)
+	web.CtrlPost("/worker-cert-revoke", (*controllers.DashboardController).RevokeWorkerCertAction)
 
 	web.CtrlGet("/ip-list", (*controllers.IPController).IndexAction)
 	web.CtrlPost("/ip-list", (*controllers.IPController).ListAction)
@@ -51,7 +58,9 @@ func init() {
 	web.CtrlPost("/ip-pin-top", (*controllers.IPController).PinTopAction)
 	web.CtrlPost("/ip-info-http", (*controllers.IPController).InfoHttpAction)
 	web.CtrlPost("/ip-block", (*controllers.IPController).BlackIPAction)
+	web.CtrlPost("/ip-expand-asn", (*controllers.IPController).ExpandASNAction)
 	web.CtrlGet("/ip-export", (*controllers.IPController).ExportIPResultAction)
+	web.CtrlGet("/ip-export-jsonl", (*controllers.IPController).ExportIPJSONLAction)
 
 	web.CtrlGet("/domain-list", (*controllers.DomainController).IndexAction)
 	web.CtrlPost("/domain-list", (*controllers.DomainController).ListAction)
@@ -68,6 +77,7 @@ func init() {
 	web.CtrlPost("/domain-info-http", (*controllers.DomainController).InfoHttpAction)
 	web.CtrlPost("/domain-block", (*controllers.DomainController).BlockDomainAction)
 	web.CtrlGet("/domain-export", (*controllers.DomainController).ExportDomainResultAction)
+	web.CtrlGet("/domain-export-jsonl", (*controllers.DomainController).ExportDomainJSONLAction)
 
 	web.CtrlGet("/vulnerability-list", (*controllers.VulController).IndexAction)
 	web.CtrlPost("/vulnerability-list", (*controllers.VulController).ListAction)
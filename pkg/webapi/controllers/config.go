@@ -52,11 +52,17 @@ func (c *ConfigController) LoadDefaultConfig() {
 		IsScreenshot:     fingerprint.IsScreenshot,
 		IsFingerprintHub: fingerprint.IsFingerprintHub,
 		IsIconHash:       fingerprint.IsIconHash,
+		IsLeak:           fingerprint.IsLeak,
+		IsWaf:            fingerprint.IsWaf,
 		// domainscan
 		Wordlist:           domainscan.Wordlist,
 		IsSubDomainFinder:  domainscan.IsSubDomainFinder,
 		IsSubDomainBrute:   domainscan.IsSubDomainBrute,
 		IsSubDomainCrawler: domainscan.IsSubdomainCrawler,
+		IsPermutation:      domainscan.IsPermutation,
+		IsTakeover:         domainscan.IsTakeover,
+		IsZoneTransfer:     domainscan.IsZoneTransfer,
+		IsJSAnalysis:       domainscan.IsJSAnalysis,
 		IsIgnoreCDN:        domainscan.IsIgnoreCDN,
 		IsIgnoreOutofChina: domainscan.IsIgnoreOutofChina,
 		IsPortscan:         domainscan.IsPortScan,
@@ -89,6 +95,10 @@ func (c *ConfigController) LoadDefaultConfig() {
 // @Param subfinder			formData bool true "是否进行子域名枚举"
 // @Param subdomainBrute	formData bool true "是否进行子域名Brute"
 // @Param subdomainCrawler	formData bool true "是否进行子域名爬虫"
+// @Param permutation		formData bool true "是否进行子域名变换"
+// @Param takeover			formData bool true "是否进行子域名接管检测"
+// @Param zonetransfer		formData bool true "是否进行域传送及NSEC遍历"
+// @Param jsanalysis		formData bool true "是否进行JS分析"
 // @Param ignoreCDN			formData bool true "是否忽略CDN"
 // @Param ignoreOutofChina	formData bool true "是否忽略非中国大陆IP"
 // @Param portscan			formData bool true "是否对域名收集结果的IP进行端口扫描"
@@ -98,6 +108,8 @@ func (c *ConfigController) LoadDefaultConfig() {
 // @Param fingerprinthub	formData bool true "是否使用fingerprinthub获取指纹"
 // @Param screenshot		formData bool true "是否进行屏幕截图"
 // @Param iconhash			formData bool true "是否获取icon的哈希值"
+// @Param leak				formData bool true "是否进行敏感信息泄露检测"
+// @Param waf				formData bool true "是否进行WAF检测"
 // @Param ipslicenumber		formData int true "ip拆分的数量"
 // @Param portslicenumber	formData int true "端口拆分的数量"
 // @Param fofa				formData bool true "是否执行fofa"
@@ -152,6 +164,10 @@ func (c *ConfigController) SaveDefaultConfig() {
 	conf.GlobalWorkerConfig().Domainscan.IsSubDomainFinder = data.IsSubDomainFinder
 	conf.GlobalWorkerConfig().Domainscan.IsSubDomainBrute = data.IsSubDomainBrute
 	conf.GlobalWorkerConfig().Domainscan.IsSubdomainCrawler = data.IsSubDomainCrawler
+	conf.GlobalWorkerConfig().Domainscan.IsPermutation = data.IsPermutation
+	conf.GlobalWorkerConfig().Domainscan.IsTakeover = data.IsTakeover
+	conf.GlobalWorkerConfig().Domainscan.IsZoneTransfer = data.IsZoneTransfer
+	conf.GlobalWorkerConfig().Domainscan.IsJSAnalysis = data.IsJSAnalysis
 	conf.GlobalWorkerConfig().Domainscan.IsIgnoreCDN = data.IsIgnoreCDN
 	conf.GlobalWorkerConfig().Domainscan.IsIgnoreOutofChina = data.IsIgnoreOutofChina
 	conf.GlobalWorkerConfig().Domainscan.IsPortScan = data.IsPortscan
@@ -162,6 +178,8 @@ func (c *ConfigController) SaveDefaultConfig() {
 	conf.GlobalWorkerConfig().Fingerprint.IsFingerprintHub = data.IsFingerprintHub
 	conf.GlobalWorkerConfig().Fingerprint.IsScreenshot = data.IsScreenshot
 	conf.GlobalWorkerConfig().Fingerprint.IsIconHash = data.IsIconHash
+	conf.GlobalWorkerConfig().Fingerprint.IsLeak = data.IsLeak
+	conf.GlobalWorkerConfig().Fingerprint.IsWaf = data.IsWaf
 	//onlineapi
 	conf.GlobalWorkerConfig().OnlineAPI.IsFofa = data.IsFofa
 	conf.GlobalWorkerConfig().OnlineAPI.IsQuake = data.IsQuake
@@ -134,3 +134,18 @@ func (c *DomainController) InfoHttp() {
 	c.IsServerAPI = true
 	c.InfoHttpAction()
 }
+
+// @Title ExportResult
+// @Description 根据指定筛选条件，按JSON Lines格式导出域名资产（包括属性、HTTP信息、截图及漏洞）
+// @Param authorization		header string true "token"
+// @Param org_id 			formData int false "组织机构的ID"
+// @Param ip_address 		formData string false "IP地址，单个IP"
+// @Param domain_address 	formData string false "域名地址"
+// @Param content 			formData string false "域名资产的属性"
+// @Param date_delta 		formData int false "更新的日期范围"
+// @Success 200 {string} string "JSON Lines格式的资产"
+// @router /result/export [post]
+func (c *DomainController) ExportResult() {
+	c.IsServerAPI = true
+	c.ExportDomainJSONLAction()
+}
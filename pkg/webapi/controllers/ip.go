@@ -31,6 +31,10 @@ type IPController struct {
 // @Param select_no_openedport 	formData bool false "选择没有开放端口的IP"
 // @Param select_order_by_date 	formData bool false "IP按更新日期排序"
 // @Param ip_http 			formData string false "http协议中的属性"
+// @Param asn 				formData string false "IP所属的ASN"
+// @Param country 			formData string false "IP归属地的国家"
+// @Param region 			formData string false "IP归属地的省份（州）"
+// @Param city 				formData string false "IP归属地的城市"
 // @Success 200 {object} models.IPDataTableResponseData
 // @router /list [post]
 func (c *IPController) List() {
@@ -134,7 +138,7 @@ func (c *IPController) InfoHttp() {
 // @Title ImportPortscanResult
 // @Description 导入portscan扫描结果
 // @Param authorization	header string true "token"
-// @Param bin 			formData string true "扫描结果类型"
+// @Param bin 			formData string true "扫描结果类型（nmap、masscan、fscan、gogo、naabu、rustscan、httpx、nuclei、xray、nessus、openvas、subfinder、amass、oneforall、0zone、fofa、hunter、jsonl）"
 // @Param org_id 		formData int true "关联的组织id"
 // @Param file 			formData string true "文件内容"
 // @Success 200 {object} models.StatusResponseData
@@ -143,3 +147,19 @@ func (c *IPController) ImportPortscanResult() {
 	c.IsServerAPI = true
 	c.ImportPortscanResultAction()
 }
+
+// @Title ExportResult
+// @Description 根据指定筛选条件，按JSON Lines格式导出IP资产（包括端口、属性、HTTP信息、截图及漏洞）
+// @Param authorization		header string true "token"
+// @Param org_id 			formData int false "组织机构的ID"
+// @Param ip_address 		formData string false "IP地址，单个IP或者掩码"
+// @Param domain_address 	formData string false "域名地址"
+// @Param port 				formData string false "端口，单个或多个"
+// @Param content 			formData string false "IP端口的属性"
+// @Param date_delta 		formData int false "更新的日期范围"
+// @Success 200 {string} string "JSON Lines格式的资产"
+// @router /result/export [post]
+func (c *IPController) ExportResult() {
+	c.IsServerAPI = true
+	c.ExportIPJSONLAction()
+}
@@ -198,9 +198,109 @@ type XScanRequestParam struct {
 // @Param taskcron 		formData bool false "是否为计划任务"
 // @Param cronrule 		formData string false "计划任务的规则"
 // @Param croncomment 	formData string false "计划任务的名称"
+// @Param worker_label 	formData string false "指定执行任务的worker标签"
 // @Success 200 {object} models.StatusResponseData
 // @router /xscan [post]
 func (c *TaskController) StartXScanTask() {
 	c.IsServerAPI = true
 	c.StartXScanTaskAction()
 }
+
+// @Title StartPortScanTask
+// @Description 执行一个端口扫描任务
+// @Param authorization	header string true "token"
+// @Param target 	formData string true "任务目标(ip、ip/掩码），多个任务以,分开"
+// @Param port 	formData string false "扫描的端口"
+// @Param portscan 	formData bool false "是否执行端口扫描"
+// @Param rate 	formData int false "扫描速率"
+// @Param nmap_tech 	formData string false "nmap的扫描方式"
+// @Param bin 	formData string false "端口扫描的程序（nmap、masscan或gogo）"
+// @Param org_id 	formData int false "关联的组机构"
+// @Param iplocation 	formData bool false "是否查询IP归属地"
+// @Param ping 	formData bool false "是否先进行ping"
+// @Param exclude 	formData string false "排除的IP"
+// @Param httpx 	formData bool false "是否执行httpx"
+// @Param screenshot 	formData bool false "是否执行屏幕截图"
+// @Param fingerprinthub 	formData bool false "是否执行fingerprinthub"
+// @Param iconhash 	formData bool false "是否获取iconhash"
+// @Param fofasearch 	formData bool false "是否执行fofa查询"
+// @Param quakesearch 	formData bool false "是否执行quake查询"
+// @Param huntersearch 	formData bool false "是否执行hunter查询"
+// @Param taskmode 	formData int false "任务切分的模式"
+// @Param load_opened_port 	formData bool false "是否加载已发现的开放端口"
+// @Param vhost 	formData bool false "是否执行虚拟主机发现"
+// @Param ignoreoutofchina 	formData bool false "是否忽略境外IP"
+// @Param ignorecdn 	formData bool false "是否忽略CDN"
+// @Param taskcron 	formData bool false "是否为计划任务"
+// @Param cronrule 	formData string false "计划任务的规则"
+// @Param croncomment 	formData string false "计划任务的名称"
+// @Param worker_label 	formData string false "指定执行任务的worker标签"
+// @Success 200 {object} models.StatusResponseData
+// @router /portscan [post]
+func (c *TaskController) StartPortScanTask() {
+	c.IsServerAPI = true
+	c.StartPortScanTaskAction()
+}
+
+// @Title StartDomainScanTask
+// @Description 执行一个域名扫描任务
+// @Param authorization	header string true "token"
+// @Param target 	formData string true "任务目标(域名），多个任务以,分开"
+// @Param org_id 	formData int false "关联的组机构"
+// @Param subfinder 	formData bool false "是否执行子域名被动枚举"
+// @Param subdomainbrute 	formData bool false "是否执行子域名暴力枚举"
+// @Param crawler 	formData bool false "是否执行子域名爬虫"
+// @Param permutation 	formData bool false "是否执行子域名变换"
+// @Param zonetransfer 	formData bool false "是否执行域传送"
+// @Param jsanalysis 	formData bool false "是否执行JS分析"
+// @Param takeover 	formData bool false "是否执行子域名接管检测"
+// @Param cdnorigin 	formData bool false "是否执行CDN源站发现"
+// @Param fld_domain 	formData bool false "是否使用主域名"
+// @Param httpx 	formData bool false "是否执行httpx"
+// @Param portscan 	formData bool false "是否对域名的IP执行端口扫描"
+// @Param networkscan 	formData bool false "是否对域名IP的C段执行端口扫描"
+// @Param screenshot 	formData bool false "是否执行屏幕截图"
+// @Param fingerprinthub 	formData bool false "是否执行fingerprinthub"
+// @Param iconhash 	formData bool false "是否获取iconhash"
+// @Param fofasearch 	formData bool false "是否执行fofa查询"
+// @Param quakesearch 	formData bool false "是否执行quake查询"
+// @Param huntersearch 	formData bool false "是否执行hunter查询"
+// @Param icpquery 	formData bool false "是否执行ICP备案查询"
+// @Param whoisquery 	formData bool false "是否执行Whois查询"
+// @Param taskmode 	formData int false "任务切分的模式"
+// @Param porttaskmode 	formData int false "端口扫描任务切分的模式"
+// @Param ignoreoutofchina 	formData bool false "是否忽略境外IP"
+// @Param ignorecdn 	formData bool false "是否忽略CDN"
+// @Param taskcron 	formData bool false "是否为计划任务"
+// @Param cronrule 	formData string false "计划任务的规则"
+// @Param croncomment 	formData string false "计划任务的名称"
+// @Param worker_label 	formData string false "指定执行任务的worker标签"
+// @Success 200 {object} models.StatusResponseData
+// @router /domainscan [post]
+func (c *TaskController) StartDomainScanTask() {
+	c.IsServerAPI = true
+	c.StartDomainScanTaskAction()
+}
+
+// @Title StartPocScanTask
+// @Description 执行一个漏洞扫描任务
+// @Param authorization	header string true "token"
+// @Param target 	formData string true "任务目标(ip、ip:port或url），多个任务以,分开"
+// @Param xrayverify 	formData bool false "是否执行xray扫描"
+// @Param xray_poc_file 	formData string false "xray使用的pocfile"
+// @Param nucleiverify 	formData bool false "是否执行nuclei扫描"
+// @Param nuclei_poc_file 	formData string false "nuclei使用的pocfile"
+// @Param gobyverify 	formData bool false "是否执行goby扫描"
+// @Param dirsearch 	formData bool false "是否执行dirsearch"
+// @Param ext 	formData string false "dirsearch的扩展名"
+// @Param load_opened_port 	formData bool false "是否加载已发现的开放端口"
+// @Param taskcron 	formData bool false "是否为计划任务"
+// @Param cronrule 	formData string false "计划任务的规则"
+// @Param croncomment 	formData string false "计划任务的名称"
+// @Param worker_label 	formData string false "指定执行任务的worker标签"
+// @Success 200 {object} models.StatusResponseData
+// @router /pocscan [post]
+func (c *TaskController) StartPocScanTask() {
+	c.IsServerAPI = true
+	c.StartPocScanTaskAction()
+}
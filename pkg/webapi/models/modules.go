@@ -14,6 +14,8 @@ type IPListData struct {
 	Index          int      `json:"index"`
 	IP             string   `json:"ip"`
 	Location       string   `json:"location"`
+	ASN            string   `json:"asn"`
+	ASName         string   `json:"as_name"`
 	Port           []string `json:"port"`
 	Title          string   `json:"title"`
 	Banner         string   `json:"banner"`
@@ -21,8 +23,10 @@ type IPListData struct {
 	MemoContent    string   `json:"memo_content"`
 	Vulnerability  string   `json:"vulnerability"`
 	HoneyPot       string   `json:"honeypot"`
+	HoneyPotScore  int      `json:"honeypot_score"`
 	ScreenshotFile []string `json:"screenshot"`
 	IsCDN          bool     `json:"cdn"`
+	Waf            string   `json:"waf"`
 	IconImage      []string `json:"iconimage"`
 	WorkspaceId    int      `json:"workspace"`
 	WorkspaceGUID  string   `json:"workspace_guid"`
@@ -97,6 +101,11 @@ type IPInfo struct {
 	Organization  string
 	Status        string
 	Location      string
+	ASN           string
+	ASName        string
+	ASPrefix      string
+	HoneyPotScore int
+	HoneyPot      string
 	Port          []int
 	Title         []string
 	Banner        []string
@@ -211,6 +220,7 @@ type DomainListData struct {
 	DomainCDN      string   `json:"domaincdn"`
 	DomainCNAME    string   `json:"domaincname"`
 	IsIPCDN        bool     `json:"ipcdn"`
+	Waf            string   `json:"waf"`
 	IconImage      []string `json:"iconimage"`
 	WorkspaceId    int      `json:"workspace"`
 	WorkspaceGUID  string   `json:"workspace_guid"`
@@ -377,6 +387,10 @@ type DefaultConfigData struct {
 	IsSubDomainFinder  bool   `json:"subfinder"`
 	IsSubDomainBrute   bool   `json:"subdomainBrute"`
 	IsSubDomainCrawler bool   `json:"subdomainCrawler"`
+	IsPermutation      bool   `json:"permutation"`
+	IsTakeover         bool   `json:"takeover"`
+	IsZoneTransfer     bool   `json:"zonetransfer"`
+	IsJSAnalysis       bool   `json:"jsanalysis"`
 	IsIgnoreCDN        bool   `json:"ignoreCDN"`
 	IsIgnoreOutofChina bool   `json:"ignoreOutofChina"`
 	IsPortscan         bool   `json:"portscan"`
@@ -387,6 +401,8 @@ type DefaultConfigData struct {
 	IsScreenshot     bool `json:"screenshot"`
 	IsFingerprintHub bool `json:"fingerprinthub"`
 	IsIconHash       bool `json:"iconhash"`
+	IsLeak           bool `json:"leak"`
+	IsWaf            bool `json:"waf"`
 	// onlineapi
 	IsFofa   bool `json:"fofa"`
 	IsQuake  bool `json:"quake"`
@@ -178,6 +178,15 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:DomainController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:DomainController"],
+        beego.ControllerComments{
+            Method: "ExportResult",
+            Router: `/result/export`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:IPController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:IPController"],
         beego.ControllerComments{
             Method: "MarkColor",
@@ -259,6 +268,15 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:IPController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:IPController"],
+        beego.ControllerComments{
+            Method: "ExportResult",
+            Router: `/result/export`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:IPController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:IPController"],
         beego.ControllerComments{
             Method: "ImportPortscanResult",
@@ -466,6 +484,33 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"],
+        beego.ControllerComments{
+            Method: "StartPortScanTask",
+            Router: `/portscan`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"],
+        beego.ControllerComments{
+            Method: "StartDomainScanTask",
+            Router: `/domainscan`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:TaskController"],
+        beego.ControllerComments{
+            Method: "StartPocScanTask",
+            Router: `/pocscan`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:UserController"] = append(beego.GlobalControllerRouter["github.com/hanc00l/nemo_go/pkg/webapi/controllers:UserController"],
         beego.ControllerComments{
             Method: "DeleteUser",
@@ -33,6 +33,8 @@ $(function () {
                 "fingerprinthub": $('#checkbox_fingerprinthub').is(":checked"),
                 "screenshot": $('#checkbox_screenshot').is(":checked"),
                 "iconhash": $('#checkbox_iconhash').is(":checked"),
+                "leak": $('#checkbox_leak').is(":checked"),
+                "waf": $('#checkbox_waf').is(":checked"),
 
             }, function (data, e) {
                 if (e === "success" && data['status'] == 'success') {
@@ -141,6 +143,68 @@ $(function () {
                 }
             });
     });
+    $('#worker-secret-table').DataTable(
+        {
+            "rowID": 'id',
+            "paging": false,
+            "searching": false,
+            "processing": true,
+            "serverSide": true,
+            "autowidth": true,
+            "sort": false,
+            "dom": '<t>',
+            "ajax": {
+                "url": "/config-worker-secret-list",
+                "type": "post",
+                "data": {start: 0, length: 100}
+            },
+            columns: [
+                {data: "index", title: "序号", width: "10%"},
+                {data: "secret_name", title: "名称", width: "15%"},
+                {data: "scope", title: "范围", width: "20%"},
+                {data: "description", title: "说明", width: "20%"},
+                {data: "update_time", title: "更新时间", width: "20%"},
+                {
+                    title: "操作", width: "15%",
+                    render: function (data, type, row, meta) {
+                        return '<button class="btn btn-sm btn-danger" type="button" onclick="delete_worker_secret(' + row['id'] + ')" ><i class="fa fa-trash-o"></i>删除</button>';
+                    }
+                }
+            ]
+        }
+    );//end datatable
+    $('#select_secret_scope').change(function () {
+        $('#input_secret_scope_value').prop("disabled", $(this).val() === 'global');
+        if ($(this).val() === 'global') {
+            $('#input_secret_scope_value').val('');
+        }
+    });
+    $("#buttonSaveWorkerSecret").click(function () {
+        $.post("/config-worker-secret-save",
+            {
+                "secret_name": $('#select_secret_name').val(),
+                "scope_type": $('#select_secret_scope').val(),
+                "scope_value": $('#input_secret_scope_value').val(),
+                "secret_value": $('#text_secret_value').val(),
+                "description": $('#input_secret_description').val(),
+            }, function (data, e) {
+                if (e === "success" && data['status'] == 'success') {
+                    $('#text_secret_value').val('');
+                    $('#worker-secret-table').DataTable().draw(false);
+                    swal({
+                        title: "保存成功！",
+                        text: "",
+                        type: "success",
+                        confirmButtonText: "确定",
+                        confirmButtonColor: "#41b883",
+                        closeOnConfirm: true,
+                        timer: 3000
+                    });
+                } else {
+                    swal('Warning', data['msg'], 'error');
+                }
+            });
+    });
     $("#buttonTestAPIToken").click(function () {
         $.post("/config-test-api", {}, function (data, e) {
             if (e === "success" && data['status'] == 'success') {
@@ -163,6 +227,11 @@ $(function () {
                 "subfinder": $('#checkbox_subfinder').is(":checked"),
                 "subdomainbrute": $('#checkbox_subdomainbrute').is(":checked"),
                 "subdomaincrawler": $('#checkbox_subdomaincrawler').is(":checked"),
+                "permutation": $('#checkbox_permutation').is(":checked"),
+                "zonetransfer": $('#checkbox_zonetransfer').is(":checked"),
+                "jsanalysis": $('#checkbox_jsanalysis').is(":checked"),
+                "cdnorigin": $('#checkbox_cdnorigin').is(":checked"),
+                "takeover": $('#checkbox_takeover').is(":checked"),
                 "icp": $('#checkbox_icp').is(":checked"),
                 "whois": $('#checkbox_whois').is(":checked"),
                 "portscan": $('#checkbox_portscan').is(":checked"),
@@ -238,6 +307,8 @@ function load_config() {
         $('#checkbox_fingerprinthub').prop("checked", data['fingerprinthub']);
         $('#checkbox_screenshot').prop("checked", data['screenshot']);
         $('#checkbox_iconhash').prop("checked", data['iconhash']);
+        $('#checkbox_leak').prop("checked", data['leak']);
+        $('#checkbox_waf').prop("checked", data['waf']);
 
         $('#input_ipslicenumber').val(data['ipslicenumber']);
         $('#input_portslicenumber').val(data['portslicenumber']);
@@ -254,6 +325,11 @@ function load_config() {
         $('#checkbox_subfinder').prop("checked", data['subfinder']);
         $('#checkbox_subdomainbrute').prop("checked", data['subdomainbrute']);
         $('#checkbox_subdomaincrawler').prop("checked", data['subdomaincrawler']);
+        $('#checkbox_permutation').prop("checked", data['permutation']);
+        $('#checkbox_zonetransfer').prop("checked", data['zonetransfer']);
+        $('#checkbox_jsanalysis').prop("checked", data['jsanalysis']);
+        $('#checkbox_cdnorigin').prop("checked", data['cdnorigin']);
+        $('#checkbox_takeover').prop("checked", data['takeover']);
         $('#checkbox_icp').prop("checked", data['icp']);
         $('#checkbox_whois').prop("checked", data['whois']);
         $('#checkbox_ignorecdn').prop("checked", data['ignorecdn']);
@@ -307,3 +383,32 @@ function save_custom(type, content, url = "/custom-save") {
             }
         });
 }
+
+/**
+ * 删除worker密钥
+ * @param id
+ */
+function delete_worker_secret(id) {
+    swal({
+            title: "确定要删除密钥吗?",
+            text: "删除后worker将使用其它范围的密钥或本地的配置！",
+            type: "warning",
+            showCancelButton: true,
+            confirmButtonColor: "#DD6B55",
+            confirmButtonText: "确认删除",
+            cancelButtonText: "取消",
+            closeOnConfirm: true
+        },
+        function () {
+            $.post("/config-worker-secret-delete",
+                {
+                    "id": id,
+                }, function (data, e) {
+                    if (e === "success" && data['status'] == 'success') {
+                        $('#worker-secret-table').DataTable().draw(false);
+                    } else {
+                        swal('Warning', "删除失败! " + data['msg'], 'error');
+                    }
+                })
+        })
+}
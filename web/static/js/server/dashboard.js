@@ -72,9 +72,33 @@ $(function () {
             },
             columns: [
                 {data: "index", title: "序号", width: "5%"},
-                {data: "worker_name", title: "Worker", width: "25%"},
-                {data: "worker_topic", title: "任务模式", width: "20%"},
-                {data: 'create_time', title: '启动时间', width: '15%',},
+                {
+                    data: "worker_name", title: "Worker", width: "20%",
+                    render: function (data, type, row, meta) {
+                        let str = data;
+                        if (row["egress_ip"]) {
+                            str += '<br/><small class="text-muted">出口IP:' + row["egress_ip"] + '</small>';
+                        }
+                        if (row["file_sync"]) {
+                            str += '<br/><small class="text-info">' + row["file_sync"] + '</small>';
+                        }
+                        return str;
+                    }
+                },
+                {data: "worker_topic", title: "任务模式", width: "10%"},
+                {
+                    data: "capability", title: "能力", width: "20%",
+                    render: function (data, type, row, meta) {
+                        let str = "";
+                        if (data) {
+                            for (let item of data.split(",")) {
+                                str += '<span class="badge badge-secondary">' + item + '</span>&nbsp;';
+                            }
+                        }
+                        return str;
+                    }
+                },
+                {data: 'create_time', title: '启动时间', width: '10%',},
                 {
                     data: 'update_time', title: '心跳时间', width: '10%',
                     render: function (data, type, row, meta) {
@@ -85,11 +109,26 @@ $(function () {
                         } else return '<span class="text-danger">' + data + '</span>';
                     }
                 },
+                {
+                    data: 'resource', title: '资源', width: '15%',
+                    render: function (data, type, row, meta) {
+                        let str = "";
+                        if (data) {
+                            for (let item of data.split(",")) {
+                                str += item + '<br/>';
+                            }
+                        }
+                        if (row["low_resource"] === true) {
+                            str += '<span class="badge badge-danger">资源不足</span>';
+                        }
+                        return '<small>' + str + '</small>';
+                    }
+                },
                 {data: 'task_number', title: '已执行任务数', width: '10%'},
                 {
                     title: "操作", width: '10%',
                     render: function (data, type, row, meta) {
-                        let str = "";
+                        let str = '<button class="btn btn-sm btn-info" type="button" onclick="show_worker_resource(\'' + row['worker_name'] + '\')" ><i class="fa fa-line-chart"></i>趋势</button>';
                         if (row["enable_manual_reload_flag"] === true) {
                             str += '&nbsp;<button class="btn btn-sm btn-primary" type="button" onclick="reload_worker(\'' + row['worker_name'] + '\')" ><i class="fa fa-play-circle"></i>重启</button>';
                         }
@@ -99,6 +138,61 @@ $(function () {
             ]
         }
     );//end datatable
+    let worker_cert_table = $('#worker-cert-table').DataTable(
+        {
+            "rowID": 'id',
+            "paging": false,
+            "searching": false,
+            "processing": true,
+            "serverSide": true,
+            "autowidth": true,
+            "sort": false,
+            "dom": '<t>',
+            "ajax": {
+                "url": "/worker-cert-list",
+                "type": "post",
+                "data": {start: 0, length: 100} //显示全部记录
+            },
+            columns: [
+                {data: "index", title: "序号", width: "5%"},
+                {data: "worker_name", title: "Worker", width: "15%"},
+                {data: "serial_number", title: "序列号", width: "15%"},
+                {
+                    data: "fingerprint", title: "指纹(SHA256)", width: "25%",
+                    render: function (data, type, row, meta) {
+                        return '<small>' + data + '</small>';
+                    }
+                },
+                {data: 'create_time', title: '注册时间', width: '10%'},
+                {data: 'not_after', title: '过期时间', width: '10%'},
+                {
+                    title: "操作", width: '10%',
+                    render: function (data, type, row, meta) {
+                        if (row["is_revoked"] === true) {
+                            return '<span class="badge badge-danger">已吊销</span>';
+                        }
+                        return '<button class="btn btn-sm btn-danger" type="button" onclick="revoke_worker_cert(' + row['id'] + ',\'' + row['worker_name'] + '\')" ><i class="fa fa-ban"></i>吊销</button>';
+                    }
+                }
+            ]
+        }
+    );//end datatable
+    $('#new_enroll_token').click(function () {
+        $.post("/worker-cert-token", function (data, e) {
+            if (e === "success" && data['status'] == 'success') {
+                swal({
+                    title: "Worker注册令牌",
+                    text: "令牌只能使用一次，24小时内有效；在worker上执行：\n\ndaemon_worker -enroll " + data['msg'],
+                    type: "success",
+                    confirmButtonText: "确定",
+                    confirmButtonColor: "#41b883",
+                    closeOnConfirm: true,
+                });
+            } else {
+                swal('Warning', "生成注册令牌失败! " + data['msg'], 'error');
+            }
+        });
+    });
     let onlineuser_table = $('#onlineuser-table').DataTable(
         {
             "rowID": 'id',
@@ -236,4 +330,84 @@ function filesync_worker(worker_name) {
                     }
                 })
         })
-}
\ No newline at end of file
+}
+
+// worker资源趋势图
+let worker_resource_charts = [];
+
+/**
+ * 显示worker资源使用的趋势
+ * @param worker_name
+ */
+function show_worker_resource(worker_name) {
+    $.post("/worker-resource-history",
+        {
+            "worker_name": worker_name,
+        }, function (data, e) {
+            if (e !== "success" || !data['time']) {
+                swal('Warning', "暂无worker的资源数据！", 'error');
+                return;
+            }
+            for (let chart of worker_resource_charts) {
+                chart.destroy();
+            }
+            worker_resource_charts = [];
+            $('#workerResourceLabel').html("资源趋势：" + worker_name);
+            // 模态框显示后再绘制，否则图表的宽度为0
+            $('#workerResource').one('shown.bs.modal', function () {
+                let options = {animation: false, responsive: true, pointDot: false, datasetFill: false};
+                worker_resource_charts.push(new Chart(document.getElementById("chart_worker_usage").getContext("2d")).Line({
+                    labels: data['time'],
+                    datasets: [
+                        {label: "CPU", strokeColor: "#007bff", data: data['cpu_percent']},
+                        {label: "内存", strokeColor: "#28a745", data: data['mem_percent']},
+                    ]
+                }, options));
+                worker_resource_charts.push(new Chart(document.getElementById("chart_worker_load").getContext("2d")).Line({
+                    labels: data['time'],
+                    datasets: [
+                        {label: "负载", strokeColor: "#ffc107", data: data['load1']},
+                    ]
+                }, options));
+                worker_resource_charts.push(new Chart(document.getElementById("chart_worker_net").getContext("2d")).Line({
+                    labels: data['time'],
+                    datasets: [
+                        {label: "发送", strokeColor: "#007bff", data: data['net_sent_rate'].map(x => (x / 1024).toFixed(1))},
+                        {label: "接收", strokeColor: "#28a745", data: data['net_recv_rate'].map(x => (x / 1024).toFixed(1))},
+                    ]
+                }, options));
+            });
+            $('#workerResource').modal('show');
+        });
+}
+
+
+/**
+ * 吊销worker证书
+ * @param id
+ * @param worker_name
+ */
+function revoke_worker_cert(id, worker_name) {
+    swal({
+            title: "确定要吊销worker证书吗?",
+            text: "吊销后worker " + worker_name + " 将不能再使用该证书连接server，需要重新注册！",
+            type: "warning",
+            showCancelButton: true,
+            confirmButtonColor: "#DD6B55",
+            confirmButtonText: "确认吊销",
+            cancelButtonText: "取消",
+            closeOnConfirm: true
+        },
+        function () {
+            $.post("/worker-cert-revoke",
+                {
+                    "id": id,
+                }, function (data, e) {
+                    if (e === "success" && data['status'] == 'success') {
+                        $('#worker-cert-table').DataTable().draw(false);
+                    } else {
+                        swal('Warning', "吊销失败! " + data['msg'], 'error');
+                    }
+                })
+        })
+}
@@ -86,6 +86,11 @@ $(function () {
                     'porttaskmode': $('#select_porttaskmode').val(),
                     'subfinder': $('#checkbox_subfinder').is(":checked"),
                     'crawler': $('#checkbox_crawler').is(":checked"),
+                    'permutation': $('#checkbox_permutation').is(":checked"),
+                    'zonetransfer': $('#checkbox_zonetransfer').is(":checked"),
+                    'jsanalysis': $('#checkbox_jsanalysis').is(":checked"),
+                    'takeover': $('#checkbox_takeover').is(":checked"),
+                    'cdnorigin': $('#checkbox_cdnorigin').is(":checked"),
                     'httpx': $('#checkbox_httpx').is(":checked"),
                     'screenshot': $('#checkbox_screenshot').is(":checked"),
                     'icpquery': $('#checkbox_icpquery').is(":checked"),
@@ -95,6 +100,7 @@ $(function () {
                     'taskcron': $('#checkbox_cron_task').is(":checked"),
                     'cronrule': cron_rule,
                     'croncomment': $('#input_cron_comment').val(),
+                    'worker_label': $('#input_worker_label').val(),
                     'ignoreoutofchina': $('#checkbox_ignorecdn_outofchina').is(":checked"),
                     'ignorecdn': $('#checkbox_ignorecdn_outofchina').is(":checked"),
                 }, function (data, e) {
@@ -144,6 +150,7 @@ $(function () {
                 'taskcron': $('#checkbox_cron_task').is(":checked"),
                 'cronrule': cron_rule,
                 'croncomment': $('#input_cron_comment').val(),
+                'worker_label': $('#input_worker_label').val(),
             }, function (data, e) {
                 if (e === "success" && data['status'] == 'success') {
                     swal({
@@ -218,6 +225,7 @@ $(function () {
         formData.append("taskcron", $('#checkbox_cron_task_xscan').is(":checked"));
         formData.append("cronrule", cron_rule);
         formData.append("croncomment", $('#input_cron_comment_xscan').val());
+        formData.append("worker_label", $('#input_worker_label_xscan').val());
 
         if ((formData.get("xraypoc") === "true" || formData.get("nucleipoc") === "true" || formData.get("gobypoc") === "true") && formData.get("fingerprint") === "false") {
             swal('Warning', '漏洞扫描需要开启指纹扫描步骤选项', 'error');
@@ -280,6 +288,12 @@ $(function () {
 
         window.open(url);
     });
+    $("#domain_export_jsonl").click(function () {
+        let url = 'domain-export-jsonl?';
+        url += get_export_options();
+
+        window.open(url);
+    });
     $("#domain_statistics").click(function () {
         let url = 'domain-statistics?';
         url += get_export_options();
@@ -384,6 +398,9 @@ $(function () {
                         if (row["domaincname"].length > 0) {
                             strData += "&nbsp;<span class=\"badge badge-pill badge-info\" title=\"" + row["domaincname"] + "\">CNAME</span>\n";
                         }
+                        if (row["waf"]) {
+                            strData += "&nbsp;<span class=\"badge badge-pill badge-secondary\" title=\"" + html2Escape(row["waf"]) + "\">WAF</span>\n";
+                        }
                         if (row["statuscode"].length > 0) {
                             strData += "<br>[" + row["statuscode"] + "]";
                         }
@@ -518,6 +535,10 @@ function load_domainscan_config() {
         $('#checkbox_subfinder').prop("checked", data['subfinder']);
         $('#checkbox_subdomainbrute').prop("checked", data['subdomainbrute']);
         $('#checkbox_crawler').prop("checked", data['subdomaincrawler']);
+        $('#checkbox_permutation').prop("checked", data['permutation']);
+        $('#checkbox_zonetransfer').prop("checked", data['zonetransfer']);
+        $('#checkbox_jsanalysis').prop("checked", data['jsanalysis']);
+        $('#checkbox_takeover').prop("checked", data['takeover']);
         //onlineapi
         $('#checkbox_fofasearch').prop("checked", data['fofa']);
         $('#checkbox_huntersearch').prop("checked", data['hunter']);
@@ -54,6 +54,25 @@ $(function () {
                 $('#ip_table').DataTable().draw(false);
             });
     });
+    //按ASN扩展组织：获取ASN宣告的网段作为新建任务的目标
+    $("#expand_asn").click(function () {
+        const org_id = $("#select_org_id_search").val();
+        $.post("/ip-expand-asn",
+            {
+                "org_id": org_id,
+                "asn": $('#asn').val(),
+            }, function (data, e) {
+                if (e === "success" && data['status'] == 'success') {
+                    $('#text_target').val(data['msg']);
+                    if (org_id) {
+                        $('#select_org_id_task').val(org_id);
+                    }
+                    $('#newTask').modal('toggle');
+                } else {
+                    swal('Warning', data['msg'], 'error');
+                }
+            });
+    });
     $("#start_import").click(function () {
         var formData = new FormData();
         formData.append('file', $('#file')[0].files[0]);
@@ -132,9 +151,11 @@ $(function () {
                     'screenshot': $('#checkbox_screenshot').is(":checked"),
                     'fingerprinthub': $('#checkbox_fingerprinthub').is(":checked"),
                     'iconhash': $('#checkbox_iconhash').is(":checked"),
+                    'vhost': $('#checkbox_vhost').is(":checked"),
                     'taskcron': $('#checkbox_cron_task').is(":checked"),
                     'cronrule': cron_rule,
                     'croncomment': $('#input_cron_comment').val(),
+                    'worker_label': $('#input_worker_label').val(),
                     'load_opened_port': $('#checkbox_ip_load_opened_port').is(":checked"),
                     'ignoreoutofchina': $('#checkbox_ignorecdn_outofchina').is(":checked"),
                     'ignorecdn': $('#checkbox_ignorecdn_outofchina').is(":checked"),
@@ -187,6 +208,7 @@ $(function () {
                     'taskcron': $('#checkbox_cron_task').is(":checked"),
                     'cronrule': cron_rule,
                     'croncomment': $('#input_cron_comment').val(),
+                    'worker_label': $('#input_worker_label').val(),
                 }, function (data, e) {
                     if (e === "success" && data['status'] == 'success') {
                         swal({
@@ -236,6 +258,7 @@ $(function () {
                     'taskcron': $('#checkbox_cron_task').is(":checked"),
                     'cronrule': cron_rule,
                     'croncomment': $('#input_cron_comment').val(),
+                    'worker_label': $('#input_worker_label').val(),
                 }, function (data, e) {
                     if (e === "success" && data['status'] == 'success') {
                         swal({
@@ -315,6 +338,7 @@ $(function () {
         formData.append("taskcron", $('#checkbox_cron_task_xscan').is(":checked"));
         formData.append("cronrule", cron_rule);
         formData.append("croncomment", $('#input_cron_comment_xscan').val());
+        formData.append("worker_label", $('#input_worker_label_xscan').val());
 
         if ((formData.get("nucleipoc") === "true" || formData.get("gobypoc") === "true" || formData.get("xraypoc") === "true") && formData.get("fingerprint") === "false") {
             swal('Warning', '漏洞扫描需要开启指纹扫描步骤选项', 'error');
@@ -396,6 +420,12 @@ $(function () {
 
         window.open(url);
     });
+    $("#ip_export_jsonl").click(function () {
+        let url = 'ip-export-jsonl?';
+        url += get_export_options();
+
+        window.open(url);
+    });
     $("#ip_statistics").click(function () {
         let url = 'ip-statistics?';
         url += get_export_options();
@@ -446,6 +476,8 @@ $(function () {
                         "port": $('#port').val(),
                         "content": $('#content').val(),
                         "iplocation": $('#iplocation').val(),
+                        "asn": $('#asn').val(),
+                        "country": $('#country').val(),
                         "port_status": $('#port_status').val(),
                         "color_tag": $('#select_color_tag').val(),
                         "memo_content": $('#memo_content').val(),
@@ -499,6 +531,14 @@ $(function () {
                         if (row['vulnerability']) {
                             strData += '&nbsp;<span class="badge badge-danger" data-toggle="tooltip" data-html="true" title="' + html2Escape(row['vulnerability']) + '"><i class="fa fa-bolt"></span>';
                         }
+                        if (row["waf"]) {
+                            strData += "&nbsp;<span class=\"badge badge-pill badge-secondary\" title=\"" + html2Escape(row["waf"]) + "\">WAF</span>\n";
+                        }
+                        if (row["honeypot_score"] > 0) {
+                            let badge = row["honeypot"] ? "badge-danger" : "badge-warning";
+                            let title = row["honeypot"] ? row["honeypot"] : "蜜罐评分:" + row["honeypot_score"];
+                            strData += "&nbsp;<span class=\"badge badge-pill " + badge + "\" title=\"" + html2Escape(title) + "\">蜜罐" + row["honeypot_score"] + "</span>\n";
+                        }
                         return strData;
                     }
                 },
@@ -506,6 +546,9 @@ $(function () {
                     data: "location", title: "归属地", width: "12%",
                     render: function (data, type, row, meta) {
                         let strData = data;
+                        if (row["asn"]) {
+                            strData += "&nbsp;<span class=\"badge badge-pill badge-info\" title=\"" + row["as_name"] + "\">" + row["asn"] + "</span>\n";
+                        }
                         if (row["cdn"]) {
                             strData += "&nbsp;<span class=\"badge badge-pill badge-warning\" title=\"IP可能使用了CDN\">CDN</span>\n";
                         }
@@ -616,6 +659,8 @@ function get_export_options() {
     url += '&port=' + encodeURI($('#port').val());
     url += '&content=' + encodeURI($('#content').val());
     url += '&iplocation=' + encodeURI($('#iplocation').val());
+    url += '&asn=' + encodeURI($('#asn').val());
+    url += '&country=' + encodeURI($('#country').val());
     url += '&port_status=' + encodeURI($('#port_status').val());
     url += '&color_tag=' + encodeURI($('#select_color_tag').val());
     url += '&memo_content=' + encodeURI($('#memo_content').val());